@@ -0,0 +1,137 @@
+package apply
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/sharedflags"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/fatih/color"
+	"github.com/hashicorp/go-hclog"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Converge the deployed SBCs to a desired state file",
+	Long: "Read the desired list of SBCs from a YAML file, compare it with the deployed SBCs, print the plan " +
+		"and then run or recreate SBCs until the deployment matches the file. " +
+		"SBCs that are deployed but not defined in the file are kept, unless --prune is set.",
+	Example: "tsbc apply -f sbcs.yaml\n" +
+		"tsbc apply -f sbcs.yaml --host-ip 192.168.10.1\n" +
+		"tsbc apply -f sbcs.yaml --prune",
+	PreRun: sharedflags.Bind(flagnames.HostIP, flagnames.Staging, flagnames.IgnoreRateLimit),
+	Run:    applyCommandHandler,
+}
+
+func GetCmd() *cobra.Command {
+	applyCmd.Flags().StringP(flagnames.ApplyFile, "f", "", "desired state YAML file")
+	applyCmd.Flags().String(flagnames.HostIP, "", "the static lan ip address of the docker host, "+
+		"used for the SBCs that do not set host_ip")
+	applyCmd.Flags().Bool(flagnames.Staging, false, "use LetsEncrypt staging environment")
+	applyCmd.Flags().Bool(flagnames.IgnoreRateLimit, false,
+		"issue certificates even if LetsEncrypt rate limits would be exceeded")
+	applyCmd.Flags().Bool(flagnames.ApplyPrune, false, "destroy the deployed SBCs that are not defined in the file")
+
+	_ = applyCmd.MarkFlagRequired(flagnames.ApplyFile)
+
+	// bind flags to viper
+	if err := viper.BindPFlag("apply.file", applyCmd.Flag(flagnames.ApplyFile)); err != nil {
+		log.Fatalln("Could not bind apply.file err:", err.Error())
+	}
+
+	if err := viper.BindPFlag("apply.prune", applyCmd.Flag(flagnames.ApplyPrune)); err != nil {
+		log.Fatalln("Could not bind apply.prune err:", err.Error())
+	}
+
+	return applyCmd
+}
+
+func applyCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "apply",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	desiredState, err := readDesiredState(viper.GetString("apply.file"))
+	if err != nil {
+		lg.Error("Could not read desired state file", "file", viper.GetString("apply.file"), "err", err)
+		os.Exit(1)
+	}
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	plan, err := sbcInst.Plan(desiredState.Sbcs, viper.GetBool("apply.prune"))
+	if err != nil {
+		lg.Error("Could not create the plan", "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+
+	displayPlan(plan)
+
+	if err = sbcInst.Apply(plan); err != nil {
+		lg.Error("Could not converge to the desired state", "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+
+	lg.Info("Desired state applied")
+}
+
+func readDesiredState(fileName string) (types.DesiredState, error) {
+	var desiredState types.DesiredState
+
+	file, err := os.Open(fileName)
+	if err != nil {
+		return desiredState, err
+	}
+
+	defer file.Close()
+
+	// fail on misspelled keys, instead of silently using the defaults
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+
+	if err = decoder.Decode(&desiredState); err != nil {
+		return desiredState, fmt.Errorf("could not decode yaml: %w", err)
+	}
+
+	return desiredState, nil
+}
+
+func displayPlan(plan []types.ApplyAction) {
+	fmt.Println("[PLAN]")
+
+	headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+	actionFmt := map[string]func(a ...interface{}) string{
+		types.ApplyActionCreate:   color.New(color.FgGreen).SprintFunc(),
+		types.ApplyActionRecreate: color.New(color.FgYellow).SprintFunc(),
+		types.ApplyActionDestroy:  color.New(color.FgRed).SprintFunc(),
+		types.ApplyActionNone:     fmt.Sprint,
+	}
+
+	tbl := table.New("ACTION", "FQDN", "REASON")
+	tbl.WithHeaderFormatter(headerFmt)
+
+	for _, action := range plan {
+		tbl.AddRow(actionFmt[action.Action](action.Action), action.Fqdn, strings.Join(action.Reasons, "; "))
+	}
+
+	tbl.Print()
+}
@@ -0,0 +1,59 @@
+package backup
+
+import (
+	"log"
+	"os"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Back up the database, volumes and image digests of all SBCs",
+	Long: "Back up a consistent snapshot of the database, the certificates volume, the Kamailio configuration " +
+		"volume of every SBC, the key of the encrypted database secrets and the image digests of all containers " +
+		"to a single archive. The archive holds the private keys of the certificates and is readable only by its owner.",
+	Example: "tsbc backup --out tsbc-backup.tar.gz",
+	Run:     backupCommandHandler,
+}
+
+func GetCmd() *cobra.Command {
+	backupCmd.Flags().String(flagnames.BackupOut, "", "backup archive file, written as tar.gz")
+
+	_ = backupCmd.MarkFlagRequired(flagnames.BackupOut)
+
+	// bind flags to viper
+	if err := viper.BindPFlag("backup.out", backupCmd.Flag(flagnames.BackupOut)); err != nil {
+		log.Fatalln("Could not bind backup.out err:", err.Error())
+	}
+
+	return backupCmd
+}
+
+func backupCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "backup",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	err = sbcInst.Backup(viper.GetString("backup.out"))
+
+	sbcInst.Close()
+
+	if err != nil {
+		lg.Error("Could not back up tsbc", "err", err)
+		os.Exit(1)
+	}
+}
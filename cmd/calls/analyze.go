@@ -0,0 +1,229 @@
+package calls
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/output"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/fatih/color"
+	"github.com/hashicorp/go-hclog"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze",
+	Short: "Summarize the captured calls or show the ladder diagram of a single call",
+	Long: "Read the SIP messages captured in the last --since duration, or between --from and --to, " +
+		"group them by Call-ID and print a summary of every call: the caller, the callee, the final response, " +
+		"the duration of the answered calls and the failure reason of the failed ones. " +
+		"With --call-id, the messages of that call are shown as an ASCII ladder diagram.",
+	Example: "tsbc calls analyze --sbc-fqdn sbc.test.com --since 1h\n" +
+		"tsbc calls analyze --sbc-fqdn sbc.test.com --since 1h --call-id 5f0c1a2b-3c4d@10.0.0.5\n" +
+		"tsbc calls analyze --sbc-fqdn sbc.test.com --from 2023-03-01T10:00:00Z --to 2023-03-01T11:00:00Z " +
+		"--output csv",
+	Run: analyzeCommandHandler,
+}
+
+func getAnalyzeCmd() *cobra.Command {
+	analyzeCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster")
+	analyzeCmd.Flags().Duration(flagnames.CaptureSince, time.Hour, "analyze the messages of the last duration")
+	analyzeCmd.Flags().String(flagnames.CaptureFrom, "",
+		"start of the time window in the RFC3339 format, overrides --since")
+	analyzeCmd.Flags().String(flagnames.CaptureTo, "", "end of the time window in the RFC3339 format (default now)")
+	analyzeCmd.Flags().String(flagnames.CallsCallID, "", "show the ladder diagram of the call with this Call-ID")
+	analyzeCmd.Flags().Bool(flagnames.CallsAll, false, "include the dialogs of the other methods, e.g. OPTIONS")
+
+	_ = analyzeCmd.MarkFlagRequired(flagnames.SbcFqdn)
+
+	_ = analyzeCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
+	// bind flags to viper
+	for key, flagName := range map[string]string{
+		"calls-analyze.fqdn":    flagnames.SbcFqdn,
+		"calls-analyze.since":   flagnames.CaptureSince,
+		"calls-analyze.from":    flagnames.CaptureFrom,
+		"calls-analyze.to":      flagnames.CaptureTo,
+		"calls-analyze.call-id": flagnames.CallsCallID,
+		"calls-analyze.all":     flagnames.CallsAll,
+	} {
+		if err := viper.BindPFlag(key, analyzeCmd.Flag(flagName)); err != nil {
+			log.Fatalln("Could not bind", key, "err:", err.Error())
+		}
+	}
+
+	return analyzeCmd
+}
+
+func analyzeCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "calls-analyze",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	outputFormat := viper.GetString(flagnames.Output)
+	if err := output.Validate(outputFormat); err != nil {
+		lg.Error("Invalid output format", "err", err)
+		os.Exit(1)
+	}
+
+	sbcFqdn := viper.GetString("calls-analyze.fqdn")
+	callID := viper.GetString("calls-analyze.call-id")
+
+	from, to, err := analyzeWindow()
+	if err != nil {
+		lg.Error("Invalid time window", "err", err)
+		os.Exit(1)
+	}
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	// the single call is looked up among all the dialogs, it may be e.g. an OPTIONS dialog
+	calls, err := sbcInst.Calls(sbcFqdn, from, to, callID != "" || viper.GetBool("calls-analyze.all"))
+	if err != nil {
+		lg.Error("Could not analyze the captured calls", "fqdn", sbcFqdn, "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+
+	if callID != "" {
+		call, found := findCall(calls, callID)
+		if !found {
+			lg.Error("Call not found in the time window", "call_id", callID, "from", from.Format(time.RFC3339),
+				"to", to.Format(time.RFC3339))
+			sbcInst.Close()
+			os.Exit(1)
+		}
+
+		if outputFormat != output.Table {
+			if err = output.Write(os.Stdout, outputFormat, call.Messages); err != nil {
+				lg.Error("Could not write call messages", "format", outputFormat, "err", err)
+				sbcInst.Close()
+				os.Exit(1)
+			}
+
+			return
+		}
+
+		displayCalls([]types.CallSummary{call.Summary})
+		fmt.Println()
+		printLadder(os.Stdout, call.Messages)
+
+		return
+	}
+
+	summaries := make([]types.CallSummary, 0, len(calls))
+	for _, call := range calls {
+		summaries = append(summaries, call.Summary)
+	}
+
+	if outputFormat != output.Table {
+		if err = output.Write(os.Stdout, outputFormat, summaries); err != nil {
+			lg.Error("Could not write call summaries", "format", outputFormat, "err", err)
+			sbcInst.Close()
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	if len(summaries) == 0 {
+		lg.Info("No calls captured in the time window", "from", from.Format(time.RFC3339),
+			"to", to.Format(time.RFC3339))
+
+		return
+	}
+
+	displayCalls(summaries)
+}
+
+func findCall(calls []types.Call, callID string) (types.Call, bool) {
+	for _, call := range calls {
+		if call.Summary.CallID == callID {
+			return call, true
+		}
+	}
+
+	return types.Call{}, false
+}
+
+func displayCalls(summaries []types.CallSummary) {
+	headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+	columnFmt := color.New(color.FgYellow).SprintfFunc()
+
+	tbl := table.New("CALL_ID", "START", "METHOD", "CALLER", "CALLEE", "RESULT", "STATUS", "DURATION", "REASON",
+		"MESSAGES")
+	tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+
+	for _, summary := range summaries {
+		status := "-"
+		if summary.Status != 0 {
+			status = fmt.Sprintf("%d %s", summary.Status, summary.Reason)
+		}
+
+		duration := "-"
+		if summary.Duration != "" {
+			duration = summary.Duration
+		}
+
+		reason := "-"
+		if summary.FailureReason != "" {
+			reason = summary.FailureReason
+		}
+
+		tbl.AddRow(
+			summary.CallID,
+			summary.Start.Format(time.RFC3339),
+			summary.Method,
+			summary.Caller,
+			summary.Callee,
+			summary.Result,
+			status,
+			duration,
+			reason,
+			summary.Messages,
+		)
+	}
+
+	tbl.Print()
+}
+
+// analyzeWindow returns the time window of the --from and --to flags, or of the --since flag
+func analyzeWindow() (time.Time, time.Time, error) {
+	to := time.Now()
+
+	if toValue := viper.GetString("calls-analyze.to"); toValue != "" {
+		var err error
+
+		if to, err = time.Parse(time.RFC3339, toValue); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("could not parse --%s: %w", flagnames.CaptureTo, err)
+		}
+	}
+
+	fromValue := viper.GetString("calls-analyze.from")
+	if fromValue == "" {
+		return to.Add(-viper.GetDuration("calls-analyze.since")), to, nil
+	}
+
+	from, err := time.Parse(time.RFC3339, fromValue)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("could not parse --%s: %w", flagnames.CaptureFrom, err)
+	}
+
+	return from, to, nil
+}
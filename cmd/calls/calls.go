@@ -0,0 +1,18 @@
+package calls
+
+import "github.com/spf13/cobra"
+
+var callsCmd = &cobra.Command{
+	Use:   "calls",
+	Short: "Analyze the calls of an SBC",
+	Long: "Analyze the calls found in the SIP capture of an SBC. The SIP capture must be started with " +
+		"tsbc capture start, the calls are read from its capture files.",
+}
+
+func GetCmd() *cobra.Command {
+	callsCmd.AddCommand(
+		getAnalyzeCmd(),
+	)
+
+	return callsCmd
+}
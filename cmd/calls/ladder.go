@@ -0,0 +1,110 @@
+package calls
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+)
+
+const (
+	// ladderMinColumnWidth fits the labels of the common requests and responses between two neighbour columns
+	ladderMinColumnWidth = 26
+	ladderOffsetWidth    = 11
+	ladderTransportWidth = 5
+)
+
+// printLadder prints the messages as an ASCII ladder diagram, with one column per ip:port
+// in the order of their first appearance and the time offset of every message from the first one
+func printLadder(w io.Writer, messages []types.CallMessage) {
+	if len(messages) == 0 {
+		return
+	}
+
+	endpoints := make([]string, 0)
+	columns := make(map[string]int)
+	columnWidth := ladderMinColumnWidth
+
+	for _, message := range messages {
+		for _, endpoint := range []string{message.Src, message.Dst} {
+			if _, found := columns[endpoint]; found {
+				continue
+			}
+
+			columns[endpoint] = len(endpoints)
+			endpoints = append(endpoints, endpoint)
+
+			if len(endpoint)+2 > columnWidth {
+				columnWidth = len(endpoint) + 2
+			}
+		}
+	}
+
+	prefix := strings.Repeat(" ", ladderOffsetWidth+ladderTransportWidth)
+	center := func(column int) int {
+		return column*columnWidth + columnWidth/2
+	}
+
+	header := []byte(strings.Repeat(" ", len(endpoints)*columnWidth))
+	for column, endpoint := range endpoints {
+		copy(header[center(column)-len(endpoint)/2:], endpoint)
+	}
+
+	fmt.Fprintln(w, prefix+strings.TrimRight(string(header), " "))
+	fmt.Fprintln(w, prefix+strings.TrimRight(string(ladderLine(len(endpoints), columnWidth)), " "))
+
+	for _, message := range messages {
+		line := ladderLine(len(endpoints), columnWidth)
+		src, dst := center(columns[message.Src]), center(columns[message.Dst])
+
+		switch {
+		case src < dst:
+			drawArrow(line[src+1:dst], message.Label, '>')
+		case src > dst:
+			drawArrow(line[dst+1:src], message.Label, '<')
+		default:
+			// a message to the own address is labelled next to its column
+			copy(line[src+1:], " "+message.Label)
+		}
+
+		offset := message.Time.Sub(messages[0].Time)
+
+		fmt.Fprintf(w, "%-*s%-*s%s\n", ladderOffsetWidth, fmt.Sprintf("+%.3fs", offset.Seconds()),
+			ladderTransportWidth, message.Transport, strings.TrimRight(string(line), " "))
+	}
+}
+
+// ladderLine returns a line with the vertical bars of the columns
+func ladderLine(columnCount, columnWidth int) []byte {
+	line := []byte(strings.Repeat(" ", columnCount*columnWidth+columnWidth))
+
+	for column := 0; column < columnCount; column++ {
+		line[column*columnWidth+columnWidth/2] = '|'
+	}
+
+	return line
+}
+
+// drawArrow draws the arrow with the centered label over the span between two columns,
+// the label is shortened if it does not fit
+func drawArrow(span []byte, label string, head byte) {
+	for i := range span {
+		span[i] = '-'
+	}
+
+	if head == '>' {
+		span[len(span)-1] = head
+	} else {
+		span[0] = head
+	}
+
+	// the label keeps at least one dash and the head on both sides
+	maxLen := len(span) - 4
+	if len(label) > maxLen {
+		label = strings.TrimSpace(label[:maxLen])
+	}
+
+	label = " " + label + " "
+	copy(span[(len(span)-len(label))/2:], label)
+}
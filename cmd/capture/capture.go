@@ -0,0 +1,23 @@
+package capture
+
+import "github.com/spf13/cobra"
+
+var captureCmd = &cobra.Command{
+	Use:   "capture",
+	Short: "Manage the SIP capture of an SBC",
+	Long: "Switch the Kamailio SIP capture of a running SBC, limit the size and the age of the capture files " +
+		"and export the captured messages as pcap files. The capture files are written to the captures/<fqdn> " +
+		"directory next to the database.",
+}
+
+func GetCmd() *cobra.Command {
+	captureCmd.AddCommand(
+		getStartCmd(),
+		getStopCmd(),
+		getStatusCmd(),
+		getRotateCmd(),
+		getExportCmd(),
+	)
+
+	return captureCmd
+}
@@ -0,0 +1,122 @@
+package capture
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the captured SIP messages of a time window as a pcap file",
+	Long: "Export the SIP messages captured in the last --since duration, or between --from and --to, " +
+		"as a pcap file. All the messages are written as UDP packets with their addresses and ports, " +
+		"so Wireshark decodes the messages received over TLS, which are captured decrypted, as well.",
+	Example: "tsbc capture export --sbc-fqdn sbc.test.com --since 30m\n" +
+		"tsbc capture export --sbc-fqdn sbc.test.com --from 2023-03-01T10:00:00Z --to 2023-03-01T11:00:00Z " +
+		"--out ./incident.pcap",
+	Run: exportCommandHandler,
+}
+
+func getExportCmd() *cobra.Command {
+	exportCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster")
+	exportCmd.Flags().Duration(flagnames.CaptureSince, time.Hour, "export the messages of the last duration")
+	exportCmd.Flags().String(flagnames.CaptureFrom, "",
+		"start of the time window in the RFC3339 format, overrides --since")
+	exportCmd.Flags().String(flagnames.CaptureTo, "", "end of the time window in the RFC3339 format (default now)")
+	exportCmd.Flags().String(flagnames.CaptureOut, "", "pcap file name (default <fqdn>-<time>.pcap)")
+
+	_ = exportCmd.MarkFlagRequired(flagnames.SbcFqdn)
+
+	_ = exportCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
+	// bind flags to viper
+	for key, flagName := range map[string]string{
+		"capture-export.fqdn":  flagnames.SbcFqdn,
+		"capture-export.since": flagnames.CaptureSince,
+		"capture-export.from":  flagnames.CaptureFrom,
+		"capture-export.to":    flagnames.CaptureTo,
+		"capture-export.out":   flagnames.CaptureOut,
+	} {
+		if err := viper.BindPFlag(key, exportCmd.Flag(flagName)); err != nil {
+			log.Fatalln("Could not bind", key, "err:", err.Error())
+		}
+	}
+
+	return exportCmd
+}
+
+func exportCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "capture-export",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	sbcFqdn := viper.GetString("capture-export.fqdn")
+
+	from, to, err := exportWindow()
+	if err != nil {
+		lg.Error("Invalid time window", "err", err)
+		os.Exit(1)
+	}
+
+	outFile := viper.GetString("capture-export.out")
+	if outFile == "" {
+		outFile = fmt.Sprintf("%s-%s.pcap", sbcFqdn, to.Format("20060102-150405"))
+	}
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	count, err := sbcInst.ExportCapture(sbcFqdn, from, to, outFile)
+	if err != nil {
+		lg.Error("Could not export SIP capture", "fqdn", sbcFqdn, "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+
+	if count == 0 {
+		lg.Warn("No SIP messages captured in the time window", "from", from.Format(time.RFC3339),
+			"to", to.Format(time.RFC3339))
+	}
+}
+
+// exportWindow returns the time window of the --from and --to flags, or of the --since flag
+func exportWindow() (time.Time, time.Time, error) {
+	to := time.Now()
+
+	if toValue := viper.GetString("capture-export.to"); toValue != "" {
+		var err error
+
+		if to, err = time.Parse(time.RFC3339, toValue); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("could not parse --%s: %w", flagnames.CaptureTo, err)
+		}
+	}
+
+	fromValue := viper.GetString("capture-export.from")
+	if fromValue == "" {
+		return to.Add(-viper.GetDuration("capture-export.since")), to, nil
+	}
+
+	from, err := time.Parse(time.RFC3339, fromValue)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("could not parse --%s: %w", flagnames.CaptureFrom, err)
+	}
+
+	return from, to, nil
+}
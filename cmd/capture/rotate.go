@@ -0,0 +1,69 @@
+package capture
+
+import (
+	"log"
+	"os"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var rotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Remove the capture files over the retention limits",
+	Long: "Remove the oldest capture files of the SBC, that are over its size or age limit. " +
+		"Kamailio starts a new capture file every hour and the file it writes to is always kept. " +
+		"The limits are applied only by capture start, stop and rotate, " +
+		"so run the command periodically, e.g. from cron, to keep them while the capture is running.",
+	Example: "tsbc capture rotate --sbc-fqdn sbc.test.com",
+	Run:     rotateCommandHandler,
+}
+
+func getRotateCmd() *cobra.Command {
+	rotateCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster")
+
+	_ = rotateCmd.MarkFlagRequired(flagnames.SbcFqdn)
+
+	_ = rotateCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
+	// bind flags to viper
+	if err := viper.BindPFlag("capture-rotate.fqdn", rotateCmd.Flag(flagnames.SbcFqdn)); err != nil {
+		log.Fatalln("Could not bind capture-rotate.fqdn err:", err.Error())
+	}
+
+	return rotateCmd
+}
+
+func rotateCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "capture-rotate",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	sbcFqdn := viper.GetString("capture-rotate.fqdn")
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	removed, err := sbcInst.RotateCaptures(sbcFqdn)
+	for _, fileName := range removed {
+		lg.Info("Capture file removed", "file", fileName)
+	}
+
+	if err != nil {
+		lg.Error("Could not rotate capture files", "fqdn", sbcFqdn, "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+}
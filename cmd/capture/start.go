@@ -0,0 +1,77 @@
+package capture
+
+import (
+	"log"
+	"os"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var startCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start the SIP capture of a running SBC",
+	Long: "Start the SIP capture of a running SBC over Kamailio RPC. " +
+		"The retention limits are stored per SBC, the previous limits are kept if they are not set. " +
+		"They are applied only by capture start, stop and rotate, so schedule rotate to keep them.",
+	Example: "tsbc capture start --sbc-fqdn sbc.test.com\n" +
+		"tsbc capture start --sbc-fqdn sbc.test.com --max-size 2048 --max-age 72h",
+	Run: startCommandHandler,
+}
+
+func getStartCmd() *cobra.Command {
+	startCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster")
+	startCmd.Flags().Int(flagnames.CaptureMaxSize, 0, "size limit of the capture files in MB (default 1024)")
+	startCmd.Flags().Duration(flagnames.CaptureMaxAge, 0, "age limit of the capture files (default 168h)")
+
+	_ = startCmd.MarkFlagRequired(flagnames.SbcFqdn)
+
+	_ = startCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
+	// bind flags to viper
+	for key, flagName := range map[string]string{
+		"capture-start.fqdn":     flagnames.SbcFqdn,
+		"capture-start.max-size": flagnames.CaptureMaxSize,
+		"capture-start.max-age":  flagnames.CaptureMaxAge,
+	} {
+		if err := viper.BindPFlag(key, startCmd.Flag(flagName)); err != nil {
+			log.Fatalln("Could not bind", key, "err:", err.Error())
+		}
+	}
+
+	return startCmd
+}
+
+func startCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "capture-start",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	sbcFqdn := viper.GetString("capture-start.fqdn")
+	retention := types.CaptureRetention{
+		MaxSizeMB: viper.GetInt("capture-start.max-size"),
+		MaxAge:    viper.GetDuration("capture-start.max-age"),
+	}
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	if err = sbcInst.StartCapture(sbcFqdn, retention); err != nil {
+		lg.Error("Could not start SIP capture", "fqdn", sbcFqdn, "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+}
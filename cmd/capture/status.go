@@ -0,0 +1,112 @@
+package capture
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/output"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/fatih/color"
+	"github.com/hashicorp/go-hclog"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the SIP capture state and the capture files of a running SBC",
+	Example: "tsbc capture status --sbc-fqdn sbc.test.com\n" +
+		"tsbc capture status --sbc-fqdn sbc.test.com --output json",
+	Run: statusCommandHandler,
+}
+
+func getStatusCmd() *cobra.Command {
+	statusCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster")
+
+	_ = statusCmd.MarkFlagRequired(flagnames.SbcFqdn)
+
+	_ = statusCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
+	// bind flags to viper
+	if err := viper.BindPFlag("capture-status.fqdn", statusCmd.Flag(flagnames.SbcFqdn)); err != nil {
+		log.Fatalln("Could not bind capture-status.fqdn err:", err.Error())
+	}
+
+	return statusCmd
+}
+
+func statusCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "capture-status",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	outputFormat := viper.GetString(flagnames.Output)
+	if err := output.Validate(outputFormat); err != nil {
+		lg.Error("Invalid output format", "err", err)
+		os.Exit(1)
+	}
+
+	sbcFqdn := viper.GetString("capture-status.fqdn")
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	status, err := sbcInst.CaptureStatus(sbcFqdn)
+	if err != nil {
+		lg.Error("Could not get SIP capture status", "fqdn", sbcFqdn, "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+
+	if outputFormat != output.Table {
+		if err = output.Write(os.Stdout, outputFormat, []types.CaptureStatus{status}); err != nil {
+			lg.Error("Could not write SIP capture status", "format", outputFormat, "err", err)
+			sbcInst.Close()
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	displayCaptureStatus(status)
+}
+
+func displayCaptureStatus(status types.CaptureStatus) {
+	headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+	columnFmt := color.New(color.FgYellow).SprintfFunc()
+
+	tbl := table.New("FQDN", "ENABLED", "ON_START", "FILES", "SIZE", "OLDEST", "LIMITS", "DIRECTORY")
+	tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+
+	oldest := "-"
+	if !status.Oldest.IsZero() {
+		oldest = status.Oldest.Format(time.RFC3339)
+	}
+
+	tbl.AddRow(
+		status.Fqdn,
+		status.Enabled,
+		status.EnabledOnStart,
+		status.Files,
+		fmt.Sprintf("%.1fMB", float64(status.SizeBytes)/(1024*1024)),
+		oldest,
+		fmt.Sprintf("%dMB %s", status.MaxSizeMB, status.MaxAge),
+		status.Directory,
+	)
+
+	tbl.Print()
+}
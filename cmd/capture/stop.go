@@ -0,0 +1,60 @@
+package capture
+
+import (
+	"log"
+	"os"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var stopCmd = &cobra.Command{
+	Use:     "stop",
+	Short:   "Stop the SIP capture of a running SBC, the capture files are kept",
+	Example: "tsbc capture stop --sbc-fqdn sbc.test.com",
+	Run:     stopCommandHandler,
+}
+
+func getStopCmd() *cobra.Command {
+	stopCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster")
+
+	_ = stopCmd.MarkFlagRequired(flagnames.SbcFqdn)
+
+	_ = stopCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
+	// bind flags to viper
+	if err := viper.BindPFlag("capture-stop.fqdn", stopCmd.Flag(flagnames.SbcFqdn)); err != nil {
+		log.Fatalln("Could not bind capture-stop.fqdn err:", err.Error())
+	}
+
+	return stopCmd
+}
+
+func stopCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "capture-stop",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	sbcFqdn := viper.GetString("capture-stop.fqdn")
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	if err = sbcInst.StopCapture(sbcFqdn); err != nil {
+		lg.Error("Could not stop SIP capture", "fqdn", sbcFqdn, "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,19 @@
+package cert
+
+import "github.com/spf13/cobra"
+
+var certCmd = &cobra.Command{
+	Use:   "cert",
+	Short: "Manage SBC TLS certificates",
+}
+
+func GetCmd() *cobra.Command {
+	certCmd.AddCommand(
+		getWatchCmd(),
+		getWildcardCmd(),
+		getPromoteCmd(),
+		getExportCmd(),
+	)
+
+	return certCmd
+}
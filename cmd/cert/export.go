@@ -0,0 +1,93 @@
+package cert
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export SBC certificate and private key to files",
+	Example: "tsbc cert export --sbc-fqdn sbc.test.com --out ./certs\n" +
+		"tsbc cert export --sbc-fqdn sbc.test.com --out ./certs --pkcs12-password-file ./p12.pass",
+	Run: exportCommandHandler,
+}
+
+func getExportCmd() *cobra.Command {
+	exportCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc which certificate is exported")
+	exportCmd.Flags().String(flagnames.CertExportOut, "", "output directory")
+	exportCmd.Flags().String(flagnames.CertExportPKCS12PasswordFile, "",
+		"file holding the password of the exported PKCS#12 bundle, the bundle is not exported if not set")
+
+	_ = exportCmd.MarkFlagRequired(flagnames.SbcFqdn)
+	_ = exportCmd.MarkFlagRequired(flagnames.CertExportOut)
+
+	_ = exportCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
+	// bind flags to viper
+	if err := viper.BindPFlag("cert-export.fqdn", exportCmd.Flag(flagnames.SbcFqdn)); err != nil {
+		log.Fatalln("Could not bind cert-export.fqdn err:", err.Error())
+	}
+
+	if err := viper.BindPFlag("cert-export.out", exportCmd.Flag(flagnames.CertExportOut)); err != nil {
+		log.Fatalln("Could not bind cert-export.out err:", err.Error())
+	}
+
+	if err := viper.BindPFlag(
+		"cert-export.pkcs12-password-file", exportCmd.Flag(flagnames.CertExportPKCS12PasswordFile)); err != nil {
+		log.Fatalln("Could not bind cert-export.pkcs12-password-file err:", err.Error())
+	}
+
+	return exportCmd
+}
+
+func exportCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "cert-export",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	var pkcs12Password string
+
+	if passwordFile := viper.GetString("cert-export.pkcs12-password-file"); passwordFile != "" {
+		password, err := os.ReadFile(passwordFile)
+		if err != nil {
+			lg.Error("Could not read PKCS#12 password file", "err", err)
+			os.Exit(1)
+		}
+
+		pkcs12Password = strings.TrimSpace(string(password))
+		if pkcs12Password == "" {
+			lg.Error("PKCS#12 password file is empty")
+			os.Exit(1)
+		}
+	}
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	if err = sbcInst.ExportCertificate(
+		viper.GetString("cert-export.fqdn"),
+		viper.GetString("cert-export.out"),
+		pkcs12Password,
+	); err != nil {
+		lg.Error("Could not export certificate", "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,52 @@
+package cert
+
+import (
+	"os"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/sharedflags"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var promoteCmd = &cobra.Command{
+	Use:   "promote",
+	Short: "Re-issue staging certificates against LetsEncrypt production",
+	Long: "Re-issue every certificate issued by the LetsEncrypt staging environment against production, " +
+		"switch the LetsEncrypt node to production and reload the new certificates in Kamailio.",
+	Example: "tsbc cert promote",
+	PreRun:  sharedflags.Bind(flagnames.Staging, flagnames.IgnoreRateLimit),
+	Run:     promoteCommandHandler,
+}
+
+func getPromoteCmd() *cobra.Command {
+	promoteCmd.Flags().Bool(flagnames.IgnoreRateLimit, false,
+		"issue certificates even if LetsEncrypt rate limits would be exceeded")
+
+	return promoteCmd
+}
+
+func promoteCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "cert-promote",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	if err = sbcInst.PromoteCertificates(); err != nil {
+		lg.Error("Could not promote certificates", "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,71 @@
+package cert
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Watch for renewed certificates and reload Kamailio TLS",
+	Example: "tsbc cert watch --interval 12h\n" +
+		"tsbc cert watch --once",
+	Run: watchCommandHandler,
+}
+
+func getWatchCmd() *cobra.Command {
+	watchCmd.Flags().Duration(flagnames.CertWatchInterval, 12*time.Hour, "time between certificate checks, 0 checks once like --once")
+	watchCmd.Flags().Bool(flagnames.CertWatchOnce, false, "check certificates once and exit")
+
+	// bind flags to viper
+	if err := viper.BindPFlag("cert-watch.interval", watchCmd.Flag(flagnames.CertWatchInterval)); err != nil {
+		log.Fatalln("Could not bind cert-watch.interval err:", err.Error())
+	}
+
+	if err := viper.BindPFlag("cert-watch.once", watchCmd.Flag(flagnames.CertWatchOnce)); err != nil {
+		log.Fatalln("Could not bind cert-watch.once err:", err.Error())
+	}
+
+	return watchCmd
+}
+
+func watchCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "cert-watch",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	// an interval of 0 is the single run of --once
+	interval := viper.GetDuration("cert-watch.interval")
+	if interval < 0 {
+		lg.Error("The interval must not be negative", "interval", interval)
+		os.Exit(1)
+	}
+
+	if viper.GetBool("cert-watch.once") {
+		interval = 0
+	}
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	if err = sbcInst.WatchCertificates(interval); err != nil {
+		lg.Error("Could not watch certificates", "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+}
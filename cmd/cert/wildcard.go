@@ -0,0 +1,78 @@
+package cert
+
+import (
+	"log"
+	"os"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/sharedflags"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var wildcardCmd = &cobra.Command{
+	Use:   "wildcard",
+	Short: "Issue a wildcard certificate shared by tenant SBCs",
+	Long: "Issue a wildcard certificate for the base SBC domain using DNS-01 validation. " +
+		"Tenant SBCs deployed as direct subdomains of the base domain use this certificate, " +
+		"without requesting their own.",
+	Example: "tsbc cert wildcard --domain sbc.example.com --dns-plugin cloudflare --dns-credentials ./cloudflare.ini",
+	PreRun:  sharedflags.Bind(flagnames.Staging, flagnames.IgnoreRateLimit),
+	Run:     wildcardCommandHandler,
+}
+
+func getWildcardCmd() *cobra.Command {
+	wildcardCmd.Flags().String(flagnames.WildcardDomain, "", "base domain covered by the wildcard certificate")
+	wildcardCmd.Flags().String(flagnames.WildcardDNSPlugin, "", "certbot dns plugin name of the DNS provider")
+	wildcardCmd.Flags().String(flagnames.WildcardDNSCredentials, "", "DNS provider credentials file for the dns plugin")
+	wildcardCmd.Flags().Bool(flagnames.Staging, false, "use LetsEncrypt staging environment")
+	wildcardCmd.Flags().Bool(flagnames.IgnoreRateLimit, false,
+		"issue the certificate even if LetsEncrypt rate limits would be exceeded")
+
+	_ = wildcardCmd.MarkFlagRequired(flagnames.WildcardDomain)
+	_ = wildcardCmd.MarkFlagRequired(flagnames.WildcardDNSPlugin)
+	_ = wildcardCmd.MarkFlagRequired(flagnames.WildcardDNSCredentials)
+
+	// bind flags to viper
+	if err := viper.BindPFlag("cert-wildcard.domain", wildcardCmd.Flag(flagnames.WildcardDomain)); err != nil {
+		log.Fatalln("Could not bind cert-wildcard.domain err:", err.Error())
+	}
+
+	if err := viper.BindPFlag("cert-wildcard.dns-plugin", wildcardCmd.Flag(flagnames.WildcardDNSPlugin)); err != nil {
+		log.Fatalln("Could not bind cert-wildcard.dns-plugin err:", err.Error())
+	}
+
+	if err := viper.BindPFlag(
+		"cert-wildcard.dns-credentials", wildcardCmd.Flag(flagnames.WildcardDNSCredentials)); err != nil {
+		log.Fatalln("Could not bind cert-wildcard.dns-credentials err:", err.Error())
+	}
+
+	return wildcardCmd
+}
+
+func wildcardCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "cert-wildcard",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	if err = sbcInst.IssueWildcardCertificate(
+		viper.GetString("cert-wildcard.domain"),
+		viper.GetString("cert-wildcard.dns-plugin"),
+		viper.GetString("cert-wildcard.dns-credentials"),
+	); err != nil {
+		lg.Error("Could not issue wildcard certificate", "err", err)
+	}
+}
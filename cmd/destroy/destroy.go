@@ -2,13 +2,15 @@ package destroy
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"os"
 
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/dryrun"
 	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
 	"github.com/ZeljkoBenovic/tsbc/db"
 	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
 	"github.com/docker/docker/client"
 	"github.com/hashicorp/go-hclog"
 	"github.com/spf13/cobra"
@@ -20,7 +22,8 @@ var destroyCmd = &cobra.Command{
 	Use:   "destroy",
 	Short: "Destroy SBC cluster or TLS node",
 	Example: "tsbc destroy --sbc-fqdn sbc.test1.com\n" +
-		"tsbc destroy --tls-node",
+		"tsbc destroy --tls-node\n" +
+		"tsbc destroy --sbc-fqdn sbc.test1.com --dry-run",
 	Run: runCommandHandler,
 }
 
@@ -34,30 +37,26 @@ type destroy struct {
 func GetCmd() *cobra.Command {
 	// define flags
 	destroyCmd.Flags().String(flagnames.SbcFqdn, "", "SBC FQDN to destroy")
-	destroyCmd.Flags().String(flagnames.LogLevel, "info", "set log level")
-	destroyCmd.Flags().String(flagnames.DBFileLocation, "",
-		fmt.Sprintf("sqlite file location, file name must end with .db (default: %s)", db.DefaultDBLocation()))
 	destroyCmd.Flags().Bool(flagnames.DestroyTLSNode, false, "destroy LetsEncrypt instance")
+	destroyCmd.Flags().Bool(flagnames.DryRun, false, "print the containers, volumes and certificate that would be removed")
 
 	destroyCmd.MarkFlagsMutuallyExclusive(flagnames.SbcFqdn, flagnames.DestroyTLSNode)
 
+	_ = destroyCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
 	// bind flags to viper
 	if err := viper.BindPFlag("destroy.fqdn", destroyCmd.Flag(flagnames.SbcFqdn)); err != nil {
 		log.Fatalln("Could not bind destroy.fqdn err:", err.Error())
 	}
 
-	if err := viper.BindPFlag("destroy.log-level", destroyCmd.Flag(flagnames.LogLevel)); err != nil {
-		log.Fatalln("Could not bind destroy.log-level:", err.Error())
-	}
-
-	if err := viper.BindPFlag("destroy.db-file", destroyCmd.Flag(flagnames.DBFileLocation)); err != nil {
-		log.Fatalln("Could not bind destroy.db-file:", err.Error())
-	}
-
 	if err := viper.BindPFlag("destroy.tls-node", destroyCmd.Flag(flagnames.DestroyTLSNode)); err != nil {
 		log.Fatalln("Could not bind destroy.tls-node", err.Error())
 	}
 
+	if err := viper.BindPFlag("destroy.dry-run", destroyCmd.Flag(flagnames.DryRun)); err != nil {
+		log.Fatalln("Could not bind destroy.dry-run", err.Error())
+	}
+
 	// add command to root
 	return destroyCmd
 }
@@ -65,19 +64,34 @@ func GetCmd() *cobra.Command {
 func runCommandHandler(cmd *cobra.Command, _ []string) {
 	hlog := hclog.New(&hclog.LoggerOptions{
 		Name:                 "destroy",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
 		Color:                hclog.AutoColor,
 		ColorHeaderAndFields: true,
 	})
 
 	sbcFqdn := viper.GetString("destroy.fqdn")
 
-	sbcInst, err := sbc.NewSBC()
+	newSBC := sbc.NewSBC
+
+	// the dry-run plan must not create the database or the log directories
+	if viper.GetBool("destroy.dry-run") {
+		newSBC = sbc.NewPlanSBC
+	}
+
+	sbcInst, err := newSBC()
 	if err != nil {
 		hlog.Error("Could not create new sbc instance", "err", err)
 
 		os.Exit(1)
 	}
 
+	// only print what would be destroyed, if selected
+	if viper.GetBool("destroy.dry-run") {
+		printDryRun(hlog, sbcInst, sbcFqdn)
+
+		return
+	}
+
 	// destroy LetsEncrypt instance only, if selected
 	if viper.GetBool("destroy.tls-node") {
 		if err = sbcInst.DestroyLetsEncryptNode(); err != nil {
@@ -99,3 +113,33 @@ func runCommandHandler(cmd *cobra.Command, _ []string) {
 		hlog.Error("Could not destroy cluster", "fqdn")
 	}
 }
+
+func printDryRun(hlog hclog.Logger, sbcInst sbc.ISBC, sbcFqdn string) {
+	defer sbcInst.Close()
+
+	var (
+		plan types.DryRunPlan
+		err  error
+	)
+
+	switch {
+	case viper.GetBool("destroy.tls-node"):
+		plan, err = sbcInst.PlanDestroyLetsEncryptNode()
+	case sbcFqdn == "":
+		hlog.Error("SBC FQDN flag not set, but it is required")
+		sbcInst.Close()
+		os.Exit(1)
+	default:
+		plan, err = sbcInst.PlanDestroy(sbcFqdn)
+	}
+
+	if err == nil {
+		err = dryrun.Print(plan)
+	}
+
+	if err != nil {
+		hlog.Error("Could not plan destroy", "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,49 @@
+package firewall
+
+import (
+	"os"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Load the nftables rules of all the SBCs",
+	Long: "Render the nftables rules of all the SBCs, write them to firewall.nft next to the database " +
+		"and load them with nft, after nft has checked them. Only the inet tsbc table is replaced, " +
+		"the tables of other applications are not changed. Run it again after an SBC or a PBX is added or removed.",
+	Example: "sudo tsbc firewall apply",
+	Run:     applyCommandHandler,
+}
+
+func getApplyCmd() *cobra.Command {
+	return applyCmd
+}
+
+func applyCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "firewall-apply",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	rulesFile, err := sbcInst.ApplyFirewall()
+
+	sbcInst.Close()
+
+	if err != nil {
+		lg.Error("Could not apply firewall rules", "file", rulesFile, "err", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,20 @@
+package firewall
+
+import "github.com/spf13/cobra"
+
+var firewallCmd = &cobra.Command{
+	Use:   "firewall",
+	Short: "Manage the host firewall rules of the SBCs",
+	Long: "Manage the nftables rules, that allow only the MS Teams prefixes to the tls and rtp ports of the SBCs, " +
+		"only the PBXs to the sip port and only the docker host itself to the RTPEngine control port.",
+}
+
+func GetCmd() *cobra.Command {
+	firewallCmd.AddCommand(
+		getRenderCmd(),
+		getApplyCmd(),
+		getPrefixesCmd(),
+	)
+
+	return firewallCmd
+}
@@ -0,0 +1,19 @@
+package firewall
+
+import "github.com/spf13/cobra"
+
+var prefixesCmd = &cobra.Command{
+	Use:   "prefixes",
+	Short: "Manage the MS Teams prefixes allowed by the firewall",
+	Long: "Manage the MS Teams signaling and media prefixes allowed by the firewall. " +
+		"The built-in Microsoft 365 direct routing prefixes are used until a prefix file is loaded.",
+}
+
+func getPrefixesCmd() *cobra.Command {
+	prefixesCmd.AddCommand(
+		getPrefixesListCmd(),
+		getPrefixesUpdateCmd(),
+	)
+
+	return prefixesCmd
+}
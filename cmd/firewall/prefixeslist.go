@@ -0,0 +1,82 @@
+package firewall
+
+import (
+	"os"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/output"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/fatih/color"
+	"github.com/hashicorp/go-hclog"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var prefixesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the MS Teams prefixes allowed by the firewall",
+	Example: "tsbc firewall prefixes list\n" +
+		"tsbc firewall prefixes list --output json",
+	Run: prefixesListCommandHandler,
+}
+
+func getPrefixesListCmd() *cobra.Command {
+	return prefixesListCmd
+}
+
+func prefixesListCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "firewall-prefixes-list",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	outputFormat := viper.GetString(flagnames.Output)
+	if err := output.Validate(outputFormat); err != nil {
+		lg.Error("Invalid output format", "err", err)
+		os.Exit(1)
+	}
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	prefixes, err := sbcInst.TeamsPrefixes()
+
+	sbcInst.Close()
+
+	if err != nil {
+		lg.Error("Could not get Teams prefixes", "err", err)
+		os.Exit(1)
+	}
+
+	if outputFormat != output.Table {
+		if err = output.Write(os.Stdout, outputFormat, prefixes); err != nil {
+			lg.Error("Could not write Teams prefixes", "format", outputFormat, "err", err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	displayTeamsPrefixes(prefixes)
+}
+
+func displayTeamsPrefixes(prefixes []types.TeamsPrefix) {
+	headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+	columnFmt := color.New(color.FgYellow).SprintfFunc()
+
+	tbl := table.New("PREFIX", "KIND", "DEFAULT")
+	tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+
+	for _, prefix := range prefixes {
+		tbl.AddRow(prefix.Prefix, prefix.Kind, prefix.Default)
+	}
+
+	tbl.Print()
+}
@@ -0,0 +1,60 @@
+package firewall
+
+import (
+	"log"
+	"os"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var prefixesUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Replace the MS Teams prefixes with the ones from a file",
+	Long: "Replace the MS Teams prefixes with the ones from a file. Every line holds an IPv4 or IPv6 prefix, " +
+		"optionally followed by signaling or media, otherwise the prefix is allowed for both. " +
+		"Empty lines and lines starting with # are ignored. Apply the firewall rules afterwards to load the prefixes.",
+	Example: "tsbc firewall prefixes update --file teams-prefixes.txt",
+	Run:     prefixesUpdateCommandHandler,
+}
+
+func getPrefixesUpdateCmd() *cobra.Command {
+	prefixesUpdateCmd.Flags().String(flagnames.FirewallPrefixFile, "", "file with the MS Teams prefixes")
+
+	_ = prefixesUpdateCmd.MarkFlagRequired(flagnames.FirewallPrefixFile)
+
+	// bind flags to viper
+	if err := viper.BindPFlag(
+		"firewall-prefixes-update.file", prefixesUpdateCmd.Flag(flagnames.FirewallPrefixFile)); err != nil {
+		log.Fatalln("Could not bind firewall-prefixes-update.file err:", err.Error())
+	}
+
+	return prefixesUpdateCmd
+}
+
+func prefixesUpdateCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "firewall-prefixes-update",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	err = sbcInst.UpdateTeamsPrefixes(viper.GetString("firewall-prefixes-update.file"))
+
+	sbcInst.Close()
+
+	if err != nil {
+		lg.Error("Could not update Teams prefixes", "err", err)
+		os.Exit(1)
+	}
+}
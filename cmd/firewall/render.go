@@ -0,0 +1,70 @@
+package firewall
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var renderCmd = &cobra.Command{
+	Use:   "render",
+	Short: "Render the nftables rules of all the SBCs",
+	Long: "Render the nftables rules of all the SBCs stored in the database, without loading them. " +
+		"The PBX host names are resolved when the rules are rendered.",
+	Example: "tsbc firewall render\n" +
+		"tsbc firewall render --out /etc/nftables.d/tsbc.nft",
+	Run: renderCommandHandler,
+}
+
+func getRenderCmd() *cobra.Command {
+	renderCmd.Flags().String(flagnames.FirewallOut, "", "rules file, the rules are printed if not set")
+
+	// bind flags to viper
+	if err := viper.BindPFlag("firewall-render.out", renderCmd.Flag(flagnames.FirewallOut)); err != nil {
+		log.Fatalln("Could not bind firewall-render.out err:", err.Error())
+	}
+
+	return renderCmd
+}
+
+func renderCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "firewall-render",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	rules, err := sbcInst.RenderFirewall()
+
+	sbcInst.Close()
+
+	if err != nil {
+		lg.Error("Could not render firewall rules", "err", err)
+		os.Exit(1)
+	}
+
+	outFile := viper.GetString("firewall-render.out")
+	if outFile == "" {
+		fmt.Print(rules)
+
+		return
+	}
+
+	if err = os.WriteFile(outFile, []byte(rules), 0600); err != nil {
+		lg.Error("Could not write firewall rules", "file", outFile, "err", err)
+		os.Exit(1)
+	}
+}
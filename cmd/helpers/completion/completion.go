@@ -0,0 +1,90 @@
+package completion
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/config"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/db"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// SbcFqdns completes the fqdns of the sbcs stored in the configured database.
+// Completion must not print anything, so all errors result in no suggestions.
+func SbcFqdns(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	// the database location can be set in the global config, which is not loaded for the completion command
+	_ = config.LoadGlobal()
+
+	dbLocation := viper.GetString(flagnames.DBFileLocation)
+	if dbLocation == "" {
+		dbLocation = db.DefaultDBLocation()
+	}
+
+	// opening a missing sqlite file would create it
+	if _, err := os.Stat(dbLocation); err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	dbInst, err := db.NewDB(hclog.NewNullLogger(), dbLocation)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	defer dbInst.Close()
+
+	fqdns, err := dbInst.GetAllFqdnNames()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return withPrefix(fqdns, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// Images completes the tags of the docker images available on the local docker host
+func Images(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	dCl, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	defer dCl.Close()
+
+	images, err := dCl.ImageList(context.Background(), types.ImageListOptions{})
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	tags := make([]string, 0, len(images))
+
+	for _, image := range images {
+		for _, tag := range image.RepoTags {
+			// dangling images have no usable tag
+			if tag != "<none>:<none>" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	sort.Strings(tags)
+
+	return withPrefix(tags, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+func withPrefix(values []string, prefix string) []string {
+	resp := make([]string, 0, len(values))
+
+	for _, value := range values {
+		if strings.HasPrefix(value, prefix) {
+			resp = append(resp, value)
+		}
+	}
+
+	return resp
+}
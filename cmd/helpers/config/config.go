@@ -0,0 +1,119 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of the environment variables read by tsbc, e.g. TSBC_DB_FILE for --db-file
+const EnvPrefix = "TSBC"
+
+// DefaultGlobalFile returns the default global configuration file location
+func DefaultGlobalFile() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "./tsbc/config.yaml"
+	}
+
+	return filepath.Join(homeDir, ".tsbc", "config.yaml")
+}
+
+// LoadGlobal reads the TSBC_* environment variables and the global configuration file into viper.
+// A missing file is only an error if its location was set explicitly.
+func LoadGlobal() error {
+	viper.SetEnvPrefix(EnvPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	viper.AutomaticEnv()
+
+	fileName := viper.GetString(flagnames.GlobalConfig)
+	explicit := fileName != ""
+
+	if !explicit {
+		fileName = DefaultGlobalFile()
+	}
+
+	viper.SetConfigFile(fileName)
+
+	if err := viper.ReadInConfig(); err != nil {
+		if !explicit && errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+
+		return fmt.Errorf("could not read global config file %s: %w", fileName, err)
+	}
+
+	return nil
+}
+
+// ApplyDefaults sets the command flags, that are not set on the command line,
+// from the global configuration file and environment variables loaded with LoadGlobal
+func ApplyDefaults(cmd *cobra.Command) error {
+	var err error
+
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if err != nil || flag.Changed || !viper.IsSet(flag.Name) {
+			return
+		}
+
+		if setErr := cmd.Flags().Set(flag.Name, viper.GetString(flag.Name)); setErr != nil {
+			err = fmt.Errorf("invalid value for %s in global config: %w", flag.Name, setErr)
+		}
+	})
+
+	return err
+}
+
+// ApplyToFlags sets the command flags from a YAML file with flag names as keys.
+// Flags set on the command line take precedence over the file values.
+func ApplyToFlags(cmd *cobra.Command, fileName string) error {
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return fmt.Errorf("could not read config file: %w", err)
+	}
+
+	values := make(map[string]interface{})
+	if err = yaml.Unmarshal(content, &values); err != nil {
+		return fmt.Errorf("could not decode config file: %w", err)
+	}
+
+	for name, value := range values {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil {
+			return fmt.Errorf("unknown flag %s in config file %s", name, fileName)
+		}
+
+		if flag.Changed {
+			continue
+		}
+
+		if err = cmd.Flags().Set(name, fmt.Sprint(value)); err != nil {
+			return fmt.Errorf("invalid value for %s in config file: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// Write stores the flag values as a YAML file, that can be read with ApplyToFlags
+func Write(fileName string, values map[string]string) error {
+	content, err := yaml.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("could not encode config: %w", err)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		return fmt.Errorf("could not create config directory: %w", err)
+	}
+
+	return os.WriteFile(fileName, content, 0644)
+}
@@ -0,0 +1,110 @@
+package dryrun
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/output"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/fatih/color"
+	"github.com/rodaine/table"
+	"github.com/spf13/viper"
+)
+
+// Print writes the plan in the format selected with the output flag
+func Print(plan types.DryRunPlan) error {
+	outputFormat := viper.GetString(flagnames.Output)
+	if err := output.Validate(outputFormat); err != nil {
+		return err
+	}
+
+	if outputFormat != output.Table {
+		return output.Write(os.Stdout, outputFormat, plan)
+	}
+
+	headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+	sectionFmt := color.New(color.FgGreen).SprintfFunc()
+	warningFmt := color.New(color.FgRed).SprintfFunc()
+
+	fmt.Printf("[DRY RUN] tsbc %s %s\n\n", plan.Command, plan.Fqdn)
+
+	if plan.Sbc != nil {
+		fmt.Println(sectionFmt("[PORTS]"))
+
+		tbl := table.New("TLS_PORT", "UDP_PORT", "PBX_IP", "PBX_PORT", "RTP_ENGINE_PORT",
+			"PUBLIC_IP", "RTP_MIN", "RTP_MAX")
+		tbl.WithHeaderFormatter(headerFmt)
+		tbl.AddRow(plan.Sbc.SbcTLSPort, plan.Sbc.SbcUDPPort, plan.Sbc.PbxIP, plan.Sbc.PbxPort,
+			plan.Sbc.RTPEnginePort, plan.Sbc.MediaPublicIP, plan.Sbc.RTPMinPort, plan.Sbc.RTPMaxPort)
+		tbl.Print()
+		fmt.Println()
+	}
+
+	if len(plan.RemoveContainers) > 0 {
+		fmt.Println(sectionFmt("[REMOVE CONTAINERS]"))
+
+		tbl := table.New("NAME", "ID", "IMAGE", "MOUNTS")
+		tbl.WithHeaderFormatter(headerFmt)
+
+		for _, plannedContainer := range plan.RemoveContainers {
+			tbl.AddRow(plannedContainer.Name, plannedContainer.ID, plannedContainer.Image,
+				strings.Join(plannedContainer.Mounts, ", "))
+		}
+
+		tbl.Print()
+		fmt.Println()
+	}
+
+	for _, plannedContainer := range plan.CreateContainers {
+		fmt.Println(sectionFmt("[CREATE CONTAINER] %s", plannedContainer.Name))
+		fmt.Printf("  image: %s\n", plannedContainer.Image)
+
+		for _, envVar := range plannedContainer.Env {
+			fmt.Printf("  env:   %s\n", envVar)
+		}
+
+		for _, mount := range plannedContainer.Mounts {
+			fmt.Printf("  mount: %s\n", mount)
+		}
+
+		fmt.Println()
+	}
+
+	printList(sectionFmt("[REMOVE VOLUMES]"), plan.RemoveVolumes)
+
+	if plan.Certificate != "" {
+		printList(sectionFmt("[CERTIFICATE]"), []string{plan.Certificate})
+	}
+
+	printList(sectionFmt("[DATABASE]"), plan.Database)
+
+	if len(plan.Warnings) > 0 {
+		fmt.Println(warningFmt("[WARNINGS]"))
+
+		for _, warning := range plan.Warnings {
+			fmt.Printf("  %s\n", warningFmt(warning))
+		}
+
+		fmt.Println()
+	}
+
+	fmt.Println("No changes were made.")
+
+	return nil
+}
+
+func printList(title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+
+	fmt.Println(title)
+
+	for _, item := range items {
+		fmt.Printf("  %s\n", item)
+	}
+
+	fmt.Println()
+}
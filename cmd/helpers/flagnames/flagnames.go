@@ -7,8 +7,78 @@ const (
 	Timezone string = "timezone"
 	Staging  string = "staging"
 
+	IgnoreRateLimit string = "ignore-rate-limit"
+	DryRun          string = "dry-run"
+
 	DestroyTLSNode string = "tls-node"
 
+	CertWatchInterval string = "interval"
+	CertWatchOnce     string = "once"
+
+	WildcardDomain         string = "domain"
+	WildcardDNSPlugin      string = "dns-plugin"
+	WildcardDNSCredentials string = "dns-credentials"
+
+	LogsComponent string = "component"
+	LogsFollow    string = "follow"
+	LogsSince     string = "since"
+	LogsGrep      string = "grep"
+
+	CertExportOut                string = "out"
+	CertExportPKCS12PasswordFile string = "pkcs12-password-file"
+
+	Output       string = "output"
+	Config       string = "config"
+	GlobalConfig string = "global-config"
+
+	ApplyFile  string = "file"
+	ApplyPrune string = "prune"
+
+	BackupOut string = "out"
+
+	PbxTargetAddress   string = "address"
+	PbxTargetPort      string = "port"
+	PbxTargetTransport string = "transport"
+	PbxTargetPriority  string = "priority"
+	PbxTargetWeight    string = "weight"
+
+	PbxAuthUsername     string = "username"
+	PbxAuthPasswordFile string = "password-file"
+	PbxAuthRealm        string = "realm"
+	PbxAuthRegister     string = "register"
+	PbxAuthExpires      string = "expires"
+
+	PbxTransportClientCert string = "client-cert"
+	PbxTransportVerify     string = "verify"
+	PbxTransportCAFile     string = "ca-file"
+
+	NumberRuleID        string = "id"
+	NumberRuleDirection string = "direction"
+	NumberRuleField     string = "field"
+	NumberRulePriority  string = "priority"
+	NumberRuleMatch     string = "match"
+	NumberRuleReplace   string = "replace"
+	NumberRuleNumber    string = "number"
+
+	ProbeTimeout  string = "timeout"
+	ProbeInsecure string = "insecure"
+
+	CaptureMaxSize string = "max-size"
+	CaptureMaxAge  string = "max-age"
+	CaptureSince   string = "since"
+	CaptureFrom    string = "from"
+	CaptureTo      string = "to"
+	CaptureOut     string = "out"
+
+	CallsCallID string = "call-id"
+	CallsAll    string = "all"
+
+	KamailioRPCURL     string = "rpc-url"
+	KamailioStatsGroup string = "group"
+
+	FirewallOut        string = "out"
+	FirewallPrefixFile string = "file"
+
 	LogLevel              string = "log-level"
 	LogFileLocation       string = "log-file"
 	DockerLogFileLocation string = "docker-log"
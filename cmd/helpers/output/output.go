@@ -0,0 +1,152 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// supported output formats
+const (
+	Table string = "table"
+	JSON  string = "json"
+	YAML  string = "yaml"
+	CSV   string = "csv"
+)
+
+var ErrFormatNotSupported = errors.New("output format not supported")
+
+// Validate returns an error if the format is not one of the supported output formats
+func Validate(format string) error {
+	switch format {
+	case Table, JSON, YAML, CSV:
+		return nil
+	default:
+		return fmt.Errorf("%w: %s (use one of %s, %s, %s, %s)",
+			ErrFormatNotSupported, format, Table, JSON, YAML, CSV)
+	}
+}
+
+// Write encodes a slice of structs in a machine-readable format.
+// The table format is command specific and is not handled here.
+func Write(w io.Writer, format string, data any) error {
+	switch format {
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(data)
+	case YAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+
+		if err := enc.Encode(data); err != nil {
+			return err
+		}
+
+		return enc.Close()
+	case CSV:
+		return writeCSV(w, data)
+	default:
+		return fmt.Errorf("%w: %s", ErrFormatNotSupported, format)
+	}
+}
+
+// writeCSV writes one header row built from the json field names and one row per slice element
+func writeCSV(w io.Writer, data any) error {
+	val := reflect.ValueOf(data)
+	if val.Kind() != reflect.Slice {
+		return fmt.Errorf("%w: csv needs a list, got %s", ErrFormatNotSupported, val.Kind())
+	}
+
+	csvWriter := csv.NewWriter(w)
+
+	if err := csvWriter.Write(csvHeader(val.Type().Elem())); err != nil {
+		return err
+	}
+
+	for i := 0; i < val.Len(); i++ {
+		if err := csvWriter.Write(csvRow(val.Index(i))); err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+
+	return csvWriter.Error()
+}
+
+func csvHeader(t reflect.Type) []string {
+	header := make([]string, 0, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			header = append(header, csvHeader(field.Type)...)
+
+			continue
+		}
+
+		name, ok := jsonName(field)
+		if !ok {
+			continue
+		}
+
+		header = append(header, name)
+	}
+
+	return header
+}
+
+func csvRow(v reflect.Value) []string {
+	row := make([]string, 0, v.NumField())
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			row = append(row, csvRow(v.Field(i))...)
+
+			continue
+		}
+
+		if _, ok := jsonName(field); !ok {
+			continue
+		}
+
+		switch fieldVal := v.Field(i).Interface().(type) {
+		case time.Time:
+			row = append(row, fieldVal.Format(time.RFC3339))
+		default:
+			row = append(row, fmt.Sprint(fieldVal))
+		}
+	}
+
+	return row
+}
+
+// jsonName returns the json field name, or false if the field is not exported in json
+func jsonName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name, true
+	}
+
+	return field.Name, true
+}
@@ -0,0 +1,26 @@
+package sharedflags
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Bind returns a PreRun function, that binds the flags to the viper keys of the same name read directly by the
+// sbc package. The flags are bound only when the command executes, as other commands bind the same keys.
+// The flags the command does not define are skipped.
+func Bind(flagNames ...string) func(cmd *cobra.Command, args []string) {
+	return func(cmd *cobra.Command, _ []string) {
+		for _, flagName := range flagNames {
+			flag := cmd.Flag(flagName)
+			if flag == nil {
+				continue
+			}
+
+			if err := viper.BindPFlag(flagName, flag); err != nil {
+				log.Fatalln("Could not bind", flagName, "err:", err.Error())
+			}
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package initialize
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/config"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/fatih/color"
+	"github.com/hashicorp/go-hclog"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Interactively configure and deploy a new SBC",
+	Long: "Prompt for the values needed to deploy a new SBC, with defaults detected from the host, " +
+		"then either deploy the SBC or write the answers to a config file for a later tsbc run --config.",
+	Example: "tsbc init",
+	Run:     initCommandHandler,
+}
+
+// answer is a single wizard question, stored under the run flag name
+type answer struct {
+	flagName     string
+	question     string
+	defaultValue string
+	validate     func(string) error
+}
+
+func GetCmd() *cobra.Command {
+	return initCmd
+}
+
+func initCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "init",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	p := newPrompter(os.Stdin, os.Stdout)
+
+	answers, err := askQuestions(p)
+	if err != nil {
+		lg.Error("Could not complete the wizard", "err", err)
+		os.Exit(1)
+	}
+
+	displaySummary(answers)
+
+	deploy, err := p.confirm("Deploy the SBC now", true)
+	if err != nil {
+		lg.Error("Could not complete the wizard", "err", err)
+		os.Exit(1)
+	}
+
+	if !deploy {
+		configFile, err := p.ask("Config file", defaultConfigFile(), validateRequired)
+		if err != nil {
+			lg.Error("Could not complete the wizard", "err", err)
+			os.Exit(1)
+		}
+
+		if err = config.Write(configFile, answers); err != nil {
+			lg.Error("Could not write config file", "file", configFile, "err", err)
+			os.Exit(1)
+		}
+
+		lg.Info("Config file written, deploy the SBC with", "cmd", "tsbc run --config "+configFile)
+
+		return
+	}
+
+	// the answers are stored under the run flag names, which are read by the sbc package
+	for flagName, value := range answers {
+		viper.Set(flagName, value)
+	}
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	sbcInst.Run()
+}
+
+func askQuestions(p *prompter) (map[string]string, error) {
+	interfaces := detectInterfaces()
+
+	defaultHostIP := ""
+	if len(interfaces) > 0 {
+		defaultHostIP = interfaces[0].ip
+
+		fmt.Fprintln(p.out, "Detected network interfaces:")
+
+		for _, iface := range interfaces {
+			fmt.Fprintf(p.out, "  %s\t%s\n", iface.name, iface.ip)
+		}
+	}
+
+	questions := []answer{
+		{flagnames.SbcFqdn, "SBC fqdn, as configured in MS Teams", "", validateFqdn},
+		{flagnames.HostIP, "Docker host LAN ip address", defaultHostIP, validateIPv4},
+		{flagnames.RTPPublicIP, "Public ip address for media", "", validateIPv4},
+		{flagnames.KamailioPbxIP, "PBX ip address", "", validateIPv4},
+		{flagnames.KamailioPbxPort, "PBX sip port", "5060", validatePort},
+		{flagnames.Timezone, "Timezone", "Europe/Belgrade", validateRequired},
+		{flagnames.Staging, "Use LetsEncrypt staging environment", "no", validateYesNo},
+	}
+
+	answers := make(map[string]string, len(questions))
+
+	for _, q := range questions {
+		value, err := p.ask(q.question, q.defaultValue, q.validate)
+		if err != nil {
+			return nil, err
+		}
+
+		answers[q.flagName] = value
+	}
+
+	answers[flagnames.Staging] = fmt.Sprint(isYes(answers[flagnames.Staging]))
+
+	return answers, nil
+}
+
+func displaySummary(answers map[string]string) {
+	headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+	columnFmt := color.New(color.FgYellow).SprintfFunc()
+
+	fmt.Println("\n[SUMMARY]")
+
+	tbl := table.New("FLAG", "VALUE")
+	tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+
+	for _, flagName := range []string{
+		flagnames.SbcFqdn, flagnames.HostIP, flagnames.RTPPublicIP, flagnames.KamailioPbxIP,
+		flagnames.KamailioPbxPort, flagnames.Timezone, flagnames.Staging,
+	} {
+		tbl.AddRow("--"+flagName, answers[flagName])
+	}
+
+	tbl.Print()
+	fmt.Println()
+}
+
+func defaultConfigFile() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "run.yaml"
+	}
+
+	return filepath.Join(homeDir, ".tsbc", "run.yaml")
+}
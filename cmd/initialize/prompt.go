@@ -0,0 +1,217 @@
+package initialize
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInputClosed   = errors.New("input closed before all the answers were given")
+	ErrInvalidIP     = errors.New("not a valid IPv4 address")
+	ErrInvalidFqdn   = errors.New("not a valid fqdn, at least a host and a domain name are needed")
+	ErrInvalidPort   = errors.New("not a valid port, use a number between 1 and 65535")
+	ErrInvalidAnswer = errors.New("answer with yes or no")
+	ErrEmptyAnswer   = errors.New("a value is required")
+
+	fqdnRegex = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\.)+[a-zA-Z]{2,63}$`)
+)
+
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func newPrompter(in io.Reader, out io.Writer) *prompter {
+	return &prompter{
+		in:  bufio.NewReader(in),
+		out: out,
+	}
+}
+
+// ask prompts until the answer, or the default value if the answer is empty, passes validation
+func (p *prompter) ask(question, defaultValue string, validate func(string) error) (string, error) {
+	for {
+		if defaultValue != "" {
+			fmt.Fprintf(p.out, "%s [%s]: ", question, defaultValue)
+		} else {
+			fmt.Fprintf(p.out, "%s: ", question)
+		}
+
+		answer, err := p.in.ReadString('\n')
+		if err != nil && (!errors.Is(err, io.EOF) || answer == "") {
+			return "", ErrInputClosed
+		}
+
+		answer = strings.TrimSpace(answer)
+		if answer == "" {
+			answer = defaultValue
+		}
+
+		if err = validate(answer); err != nil {
+			fmt.Fprintf(p.out, "  %s\n", err)
+
+			continue
+		}
+
+		return answer, nil
+	}
+}
+
+// confirm asks a yes or no question
+func (p *prompter) confirm(question string, defaultValue bool) (bool, error) {
+	defaultAnswer := "no"
+	if defaultValue {
+		defaultAnswer = "yes"
+	}
+
+	answer, err := p.ask(question, defaultAnswer, validateYesNo)
+	if err != nil {
+		return false, err
+	}
+
+	return isYes(answer), nil
+}
+
+func validateRequired(value string) error {
+	if value == "" {
+		return ErrEmptyAnswer
+	}
+
+	return nil
+}
+
+func validateIPv4(value string) error {
+	if ip := net.ParseIP(value); ip == nil || ip.To4() == nil {
+		return fmt.Errorf("%w: %q", ErrInvalidIP, value)
+	}
+
+	return nil
+}
+
+func validateFqdn(value string) error {
+	if !fqdnRegex.MatchString(value) {
+		return fmt.Errorf("%w: %q", ErrInvalidFqdn, value)
+	}
+
+	return nil
+}
+
+func validatePort(value string) error {
+	if port, err := strconv.Atoi(value); err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("%w: %q", ErrInvalidPort, value)
+	}
+
+	return nil
+}
+
+func validateYesNo(value string) error {
+	switch strings.ToLower(value) {
+	case "y", "yes", "n", "no":
+		return nil
+	default:
+		return ErrInvalidAnswer
+	}
+}
+
+func isYes(value string) bool {
+	return strings.HasPrefix(strings.ToLower(value), "y")
+}
+
+// networkInterface is an up, non-loopback interface with an IPv4 address
+type networkInterface struct {
+	name string
+	ip   string
+}
+
+// dockerInterfacePrefixes are the names of the bridges and veth pairs created by docker,
+// their addresses are not reachable from the LAN
+var dockerInterfacePrefixes = []string{"docker", "br-", "veth"}
+
+// detectInterfaces returns the IPv4 interfaces of the host without the docker interfaces,
+// the interface of the default route first and then the private addresses
+func detectInterfaces() []networkInterface {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+
+	defaultRoute := defaultRouteInterface()
+
+	var preferred, private, public []networkInterface
+
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 || isDockerInterface(iface.Name) {
+			continue
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || ipNet.IP.To4() == nil {
+				continue
+			}
+
+			detected := networkInterface{name: iface.Name, ip: ipNet.IP.String()}
+
+			switch {
+			case iface.Name == defaultRoute:
+				preferred = append(preferred, detected)
+			case ipNet.IP.IsPrivate():
+				private = append(private, detected)
+			default:
+				public = append(public, detected)
+			}
+		}
+	}
+
+	return append(append(preferred, private...), public...)
+}
+
+func isDockerInterface(name string) bool {
+	for _, prefix := range dockerInterfacePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// defaultRouteInterface returns the interface of the IPv4 default route from /proc/net/route,
+// or an empty string if it is not found
+func defaultRouteInterface() string {
+	routes, err := os.ReadFile("/proc/net/route")
+	if err != nil {
+		return ""
+	}
+
+	return parseDefaultRoute(string(routes))
+}
+
+// parseDefaultRoute returns the interface of the route with the 0.0.0.0 destination and mask
+func parseDefaultRoute(routes string) string {
+	for _, line := range strings.Split(routes, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 8 {
+			continue
+		}
+
+		// Iface Destination Gateway Flags RefCnt Use Metric Mask
+		if fields[1] == "00000000" && fields[7] == "00000000" {
+			return fields[0]
+		}
+	}
+
+	return ""
+}
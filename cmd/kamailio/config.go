@@ -0,0 +1,154 @@
+package kamailio
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/output"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/sharedflags"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/fatih/color"
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Render the Kamailio configuration from the tsbc templates",
+	Long: "Render kamailio.cfg, tls.cfg and dispatcher.list of the SBC from the tsbc templates, show the difference " +
+		"to the deployed configuration and write it to the <fqdn>-kamcfg volume. " +
+		"Templates in ~/.tsbc/templates/<fqdn>/, named after the file with the .tmpl extension, " +
+		"override the embedded ones.",
+	Example: "tsbc kamailio config --sbc-fqdn sbc.test.com\n" +
+		"tsbc kamailio config --sbc-fqdn sbc.test.com --dry-run",
+	PreRun: sharedflags.Bind(flagnames.HostIP),
+	Run:    configCommandHandler,
+}
+
+func getConfigCmd() *cobra.Command {
+	configCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster")
+	configCmd.Flags().String(flagnames.HostIP, "",
+		"the static lan ip address of the docker host, taken from the existing Kamailio container if not set")
+	configCmd.Flags().Bool(flagnames.DryRun, false, "show the configuration difference without applying it")
+
+	_ = configCmd.MarkFlagRequired(flagnames.SbcFqdn)
+
+	_ = configCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
+	// bind flags to viper
+	if err := viper.BindPFlag("kamailio-config.fqdn", configCmd.Flag(flagnames.SbcFqdn)); err != nil {
+		log.Fatalln("Could not bind kamailio-config.fqdn err:", err.Error())
+	}
+
+	if err := viper.BindPFlag("kamailio-config.dry-run", configCmd.Flag(flagnames.DryRun)); err != nil {
+		log.Fatalln("Could not bind kamailio-config.dry-run err:", err.Error())
+	}
+
+	return configCmd
+}
+
+func configCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "kamailio-config",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	outputFormat := viper.GetString(flagnames.Output)
+	if err := output.Validate(outputFormat); err != nil {
+		lg.Error("Invalid output format", "err", err)
+		os.Exit(1)
+	}
+
+	sbcFqdn := viper.GetString("kamailio-config.fqdn")
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	diffs, err := sbcInst.DiffKamailioConfig(sbcFqdn)
+	if err != nil {
+		lg.Error("Could not render Kamailio configuration", "fqdn", sbcFqdn, "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+
+	if outputFormat == output.Table {
+		displayDiffs(diffs)
+	} else if err = output.Write(os.Stdout, outputFormat, diffs); err != nil {
+		lg.Error("Could not write Kamailio configuration difference", "format", outputFormat, "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+
+	if !hasChanges(diffs) {
+		lg.Info("Kamailio configuration is up to date", "fqdn", sbcFqdn)
+
+		return
+	}
+
+	if viper.GetBool("kamailio-config.dry-run") {
+		return
+	}
+
+	if err = sbcInst.ApplyKamailioConfig(sbcFqdn); err != nil {
+		lg.Error("Could not apply Kamailio configuration", "fqdn", sbcFqdn, "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+}
+
+func hasChanges(diffs []types.KamailioConfigDiff) bool {
+	for _, diff := range diffs {
+		if diff.Diff != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func displayDiffs(diffs []types.KamailioConfigDiff) {
+	sectionFmt := color.New(color.FgGreen).SprintfFunc()
+	addedFmt := color.New(color.FgGreen).SprintFunc()
+	removedFmt := color.New(color.FgRed).SprintFunc()
+	hunkFmt := color.New(color.FgCyan).SprintFunc()
+
+	for _, diff := range diffs {
+		fmt.Println(sectionFmt("[%s] template: %s", diff.File, diff.Template))
+
+		if diff.Diff == "" {
+			fmt.Print("no changes\n\n")
+
+			continue
+		}
+
+		for _, line := range strings.Split(strings.TrimSuffix(diff.Diff, "\n"), "\n") {
+			switch {
+			case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+				fmt.Println(line)
+			case strings.HasPrefix(line, "@@"):
+				fmt.Println(hunkFmt(line))
+			case strings.HasPrefix(line, "+"):
+				fmt.Println(addedFmt(line))
+			case strings.HasPrefix(line, "-"):
+				fmt.Println(removedFmt(line))
+			default:
+				fmt.Println(line)
+			}
+		}
+
+		fmt.Println()
+	}
+}
@@ -0,0 +1,31 @@
+package kamailio
+
+import (
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/spf13/cobra"
+)
+
+var kamailioCmd = &cobra.Command{
+	Use:   "kamailio",
+	Short: "Manage the Kamailio instances of the SBCs",
+	Long: "Manage the Kamailio instances of the SBCs. The runtime commands call the JSONRPC endpoint, which " +
+		"the tsbc rendered Kamailio configuration serves on http://127.0.0.1:<udp sip port>/RPC of the docker host.",
+}
+
+func GetCmd() *cobra.Command {
+	kamailioCmd.AddCommand(
+		getConfigCmd(),
+		getStatsCmd(),
+		getTLSConnectionsCmd(),
+		getReloadCmd(),
+		getRPCCmd(),
+	)
+
+	return kamailioCmd
+}
+
+// addRPCURLFlag adds the flag, that overrides the JSONRPC endpoint of the sbc, e.g. with a tunnel or a test stub
+func addRPCURLFlag(cmd *cobra.Command) {
+	cmd.Flags().String(flagnames.KamailioRPCURL, "",
+		"url of the Kamailio JSONRPC endpoint (default http://127.0.0.1:<udp sip port>/RPC)")
+}
@@ -0,0 +1,71 @@
+package kamailio
+
+import (
+	"log"
+	"os"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/sharedflags"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var reloadCmd = &cobra.Command{
+	Use:   "reload",
+	Short: "Reload the runtime data of a running SBC",
+	Long: "Reload the dispatcher list, the TLS configuration, the pbx registration and the number rules " +
+		"from the deployed configuration files, without a Kamailio restart. " +
+		"Use tsbc kamailio config to render and deploy the configuration files first.",
+	Example: "tsbc kamailio reload --sbc-fqdn sbc.test.com",
+	PreRun:  sharedflags.Bind(flagnames.KamailioRPCURL),
+	Run:     reloadCommandHandler,
+}
+
+func getReloadCmd() *cobra.Command {
+	reloadCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster")
+	addRPCURLFlag(reloadCmd)
+
+	_ = reloadCmd.MarkFlagRequired(flagnames.SbcFqdn)
+
+	_ = reloadCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
+	// bind flags to viper
+	if err := viper.BindPFlag("kamailio-reload.fqdn", reloadCmd.Flag(flagnames.SbcFqdn)); err != nil {
+		log.Fatalln("Could not bind kamailio-reload.fqdn err:", err.Error())
+	}
+
+	return reloadCmd
+}
+
+func reloadCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "kamailio-reload",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	sbcFqdn := viper.GetString("kamailio-reload.fqdn")
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	reloaded, err := sbcInst.ReloadKamailio(sbcFqdn)
+	for _, method := range reloaded {
+		lg.Info("Kamailio reloaded", "fqdn", sbcFqdn, "method", method)
+	}
+
+	if err != nil {
+		lg.Error("Could not reload Kamailio", "fqdn", sbcFqdn, "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,107 @@
+package kamailio
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/output"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/sharedflags"
+	"github.com/ZeljkoBenovic/tsbc/jsonrpc"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var rpcCmd = &cobra.Command{
+	Use:   "rpc <method> [args]",
+	Short: "Call a Kamailio RPC method of a running SBC",
+	Long: "Call any Kamailio RPC method, like kamcmd inside the container, and print its JSON result. " +
+		"The integer arguments are sent as numbers, the s: prefix sends them as strings, e.g. s:5060.",
+	Example: "tsbc kamailio rpc dispatcher.list --sbc-fqdn sbc.test.com\n" +
+		"tsbc kamailio rpc dispatcher.set_state --sbc-fqdn sbc.test.com ip 2 sip:10.0.0.20:5060\n" +
+		"tsbc kamailio rpc system.listMethods --sbc-fqdn sbc.test.com --output yaml",
+	Args:   cobra.MinimumNArgs(1),
+	PreRun: sharedflags.Bind(flagnames.KamailioRPCURL),
+	Run:    rpcCommandHandler,
+}
+
+func getRPCCmd() *cobra.Command {
+	rpcCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster")
+	addRPCURLFlag(rpcCmd)
+
+	_ = rpcCmd.MarkFlagRequired(flagnames.SbcFqdn)
+
+	_ = rpcCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
+	// bind flags to viper
+	if err := viper.BindPFlag("kamailio-rpc.fqdn", rpcCmd.Flag(flagnames.SbcFqdn)); err != nil {
+		log.Fatalln("Could not bind kamailio-rpc.fqdn err:", err.Error())
+	}
+
+	return rpcCmd
+}
+
+func rpcCommandHandler(_ *cobra.Command, args []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "kamailio-rpc",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	// the result has no fixed columns, so it is printed as json by default
+	outputFormat := viper.GetString(flagnames.Output)
+	if outputFormat != output.Table && outputFormat != output.JSON && outputFormat != output.YAML {
+		lg.Error("Invalid output format, the rpc result is written as json or yaml", "format", outputFormat)
+		os.Exit(1)
+	}
+
+	sbcFqdn := viper.GetString("kamailio-rpc.fqdn")
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	result, err := sbcInst.KamailioRPC(sbcFqdn, args[0], jsonrpc.ParseParams(args[1:])...)
+	if err != nil {
+		lg.Error("Kamailio RPC failed", "fqdn", sbcFqdn, "method", args[0], "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+
+	if outputFormat == output.YAML {
+		var value any
+
+		if err = json.Unmarshal(result, &value); err == nil {
+			err = output.Write(os.Stdout, outputFormat, value)
+		}
+
+		if err != nil {
+			lg.Error("Could not write Kamailio RPC result", "format", outputFormat, "err", err)
+			sbcInst.Close()
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	var indented bytes.Buffer
+
+	if err = json.Indent(&indented, result, "", "  "); err != nil {
+		lg.Error("Could not write Kamailio RPC result", "format", outputFormat, "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+
+	fmt.Println(indented.String())
+}
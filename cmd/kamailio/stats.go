@@ -0,0 +1,108 @@
+package kamailio
+
+import (
+	"log"
+	"os"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/output"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/sharedflags"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/fatih/color"
+	"github.com/hashicorp/go-hclog"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show the Kamailio statistics of a running SBC",
+	Example: "tsbc kamailio stats --sbc-fqdn sbc.test.com\n" +
+		"tsbc kamailio stats --sbc-fqdn sbc.test.com --group dispatcher --output json",
+	PreRun: sharedflags.Bind(flagnames.KamailioRPCURL),
+	Run:    statsCommandHandler,
+}
+
+func getStatsCmd() *cobra.Command {
+	statsCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster")
+	statsCmd.Flags().String(flagnames.KamailioStatsGroup, "",
+		"statistics group, e.g. core, tm, sl, tcp or dispatcher (default all)")
+	addRPCURLFlag(statsCmd)
+
+	_ = statsCmd.MarkFlagRequired(flagnames.SbcFqdn)
+
+	_ = statsCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
+	// bind flags to viper
+	for key, flagName := range map[string]string{
+		"kamailio-stats.fqdn":  flagnames.SbcFqdn,
+		"kamailio-stats.group": flagnames.KamailioStatsGroup,
+	} {
+		if err := viper.BindPFlag(key, statsCmd.Flag(flagName)); err != nil {
+			log.Fatalln("Could not bind", key, "err:", err.Error())
+		}
+	}
+
+	return statsCmd
+}
+
+func statsCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "kamailio-stats",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	outputFormat := viper.GetString(flagnames.Output)
+	if err := output.Validate(outputFormat); err != nil {
+		lg.Error("Invalid output format", "err", err)
+		os.Exit(1)
+	}
+
+	sbcFqdn := viper.GetString("kamailio-stats.fqdn")
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	stats, err := sbcInst.KamailioStats(sbcFqdn, viper.GetString("kamailio-stats.group"))
+	if err != nil {
+		lg.Error("Could not get Kamailio statistics", "fqdn", sbcFqdn, "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+
+	if outputFormat != output.Table {
+		if err = output.Write(os.Stdout, outputFormat, stats); err != nil {
+			lg.Error("Could not write Kamailio statistics", "format", outputFormat, "err", err)
+			sbcInst.Close()
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	displayStats(stats)
+}
+
+func displayStats(stats []types.KamailioStat) {
+	headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+	columnFmt := color.New(color.FgYellow).SprintfFunc()
+
+	tbl := table.New("GROUP", "NAME", "VALUE")
+	tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+
+	for _, stat := range stats {
+		tbl.AddRow(stat.Group, stat.Name, stat.Value)
+	}
+
+	tbl.Print()
+}
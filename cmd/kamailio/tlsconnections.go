@@ -0,0 +1,117 @@
+package kamailio
+
+import (
+	"log"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/output"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/sharedflags"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/fatih/color"
+	"github.com/hashicorp/go-hclog"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var tlsConnectionsCmd = &cobra.Command{
+	Use:   "tls-connections",
+	Short: "List the TLS connections of a running SBC",
+	Long: "List the TLS connections of Kamailio, to the MS Teams SIP proxies and to the tls pbx targets, " +
+		"with their cipher and state.",
+	Example: "tsbc kamailio tls-connections --sbc-fqdn sbc.test.com",
+	PreRun:  sharedflags.Bind(flagnames.KamailioRPCURL),
+	Run:     tlsConnectionsCommandHandler,
+}
+
+func getTLSConnectionsCmd() *cobra.Command {
+	tlsConnectionsCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster")
+	addRPCURLFlag(tlsConnectionsCmd)
+
+	_ = tlsConnectionsCmd.MarkFlagRequired(flagnames.SbcFqdn)
+
+	_ = tlsConnectionsCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
+	// bind flags to viper
+	if err := viper.BindPFlag("kamailio-tls-connections.fqdn", tlsConnectionsCmd.Flag(flagnames.SbcFqdn)); err != nil {
+		log.Fatalln("Could not bind kamailio-tls-connections.fqdn err:", err.Error())
+	}
+
+	return tlsConnectionsCmd
+}
+
+func tlsConnectionsCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "kamailio-tls-connections",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	outputFormat := viper.GetString(flagnames.Output)
+	if err := output.Validate(outputFormat); err != nil {
+		lg.Error("Invalid output format", "err", err)
+		os.Exit(1)
+	}
+
+	sbcFqdn := viper.GetString("kamailio-tls-connections.fqdn")
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	connections, err := sbcInst.KamailioTLSConnections(sbcFqdn)
+	if err != nil {
+		lg.Error("Could not get Kamailio TLS connections", "fqdn", sbcFqdn, "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+
+	if outputFormat != output.Table {
+		if err = output.Write(os.Stdout, outputFormat, connections); err != nil {
+			lg.Error("Could not write Kamailio TLS connections", "format", outputFormat, "err", err)
+			sbcInst.Close()
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	if len(connections) == 0 {
+		lg.Info("No TLS connections", "fqdn", sbcFqdn)
+
+		return
+	}
+
+	displayTLSConnections(connections)
+}
+
+func displayTLSConnections(connections []types.KamailioTLSConnection) {
+	headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+	columnFmt := color.New(color.FgYellow).SprintfFunc()
+
+	tbl := table.New("ID", "SOURCE", "DESTINATION", "CIPHER", "STATE", "TIMEOUT")
+	tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+
+	for _, conn := range connections {
+		tbl.AddRow(
+			conn.ID,
+			net.JoinHostPort(conn.SrcIP, strconv.Itoa(conn.SrcPort)),
+			net.JoinHostPort(conn.DstIP, strconv.Itoa(conn.DstPort)),
+			conn.Cipher,
+			conn.State,
+			strconv.Itoa(conn.Timeout)+"s",
+		)
+	}
+
+	tbl.Print()
+}
@@ -3,20 +3,25 @@ package list
 import (
 	"bytes"
 	"fmt"
+	"os"
 
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/output"
 	"github.com/ZeljkoBenovic/tsbc/sbc"
 	"github.com/ZeljkoBenovic/tsbc/sbc/types"
 	"github.com/fatih/color"
 	"github.com/hashicorp/go-hclog"
 	"github.com/rodaine/table"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var listCmd = &cobra.Command{
-	Use:     "list",
-	Short:   "Get a list of all the deployed SBCs",
-	Example: "tsbc list",
-	Run:     runListCommand,
+	Use:   "list",
+	Short: "Get a list of all the deployed SBCs",
+	Example: "tsbc list\n" +
+		"tsbc list --output json",
+	Run: runListCommand,
 }
 
 func GetCmd() *cobra.Command {
@@ -26,20 +31,40 @@ func GetCmd() *cobra.Command {
 func runListCommand(_ *cobra.Command, _ []string) {
 	hlog := hclog.New(&hclog.LoggerOptions{
 		Name:                 "list",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
 		Color:                hclog.AutoColor,
 		ColorHeaderAndFields: true,
 	})
 
+	outputFormat := viper.GetString(flagnames.Output)
+	if err := output.Validate(outputFormat); err != nil {
+		hlog.Error("Invalid output format", "err", err)
+		os.Exit(1)
+	}
+
 	sbcInst, err := sbc.NewSBC()
 	if err != nil {
 		hlog.Error("Could not create new sbc instance", "err", err)
-
-		return
+		os.Exit(1)
 	}
 
+	defer sbcInst.Close()
+
 	sbcsInfo, err := sbcInst.List()
 	if err != nil {
 		hlog.Error("Could not get a list of SBCs", "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+
+	if outputFormat != output.Table {
+		if err = output.Write(os.Stdout, outputFormat, sbcsInfo); err != nil {
+			hlog.Error("Could not write SBC list", "format", outputFormat, "err", err)
+			sbcInst.Close()
+			os.Exit(1)
+		}
+
+		return
 	}
 
 	displaySBCInformation(sbcsInfo)
@@ -0,0 +1,86 @@
+package logs
+
+import (
+	"log"
+	"os"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Stream and filter component logs of an SBC",
+	Example: "tsbc logs --sbc-fqdn sbc.test.com\n" +
+		"tsbc logs --sbc-fqdn sbc.test.com --component kamailio --follow --since 10m --grep INVITE",
+	Run: logsCommandHandler,
+}
+
+func GetCmd() *cobra.Command {
+	logsCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster")
+	logsCmd.Flags().StringSlice(flagnames.LogsComponent, nil,
+		"component to show logs for: kamailio, rtpengine or certs (default kamailio,rtpengine)")
+	logsCmd.Flags().Bool(flagnames.LogsFollow, false, "follow log output")
+	logsCmd.Flags().String(flagnames.LogsSince, "", "show logs since timestamp or relative time (e.g. 10m)")
+	logsCmd.Flags().String(flagnames.LogsGrep, "", "show only lines matching the regular expression")
+
+	_ = logsCmd.MarkFlagRequired(flagnames.SbcFqdn)
+
+	_ = logsCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
+	// bind flags to viper
+	if err := viper.BindPFlag("logs.fqdn", logsCmd.Flag(flagnames.SbcFqdn)); err != nil {
+		log.Fatalln("Could not bind logs.fqdn err:", err.Error())
+	}
+
+	if err := viper.BindPFlag("logs.component", logsCmd.Flag(flagnames.LogsComponent)); err != nil {
+		log.Fatalln("Could not bind logs.component err:", err.Error())
+	}
+
+	if err := viper.BindPFlag("logs.follow", logsCmd.Flag(flagnames.LogsFollow)); err != nil {
+		log.Fatalln("Could not bind logs.follow err:", err.Error())
+	}
+
+	if err := viper.BindPFlag("logs.since", logsCmd.Flag(flagnames.LogsSince)); err != nil {
+		log.Fatalln("Could not bind logs.since err:", err.Error())
+	}
+
+	if err := viper.BindPFlag("logs.grep", logsCmd.Flag(flagnames.LogsGrep)); err != nil {
+		log.Fatalln("Could not bind logs.grep err:", err.Error())
+	}
+
+	return logsCmd
+}
+
+func logsCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "logs",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	if err = sbcInst.Logs(viper.GetString("logs.fqdn"), types.LogOptions{
+		Components: viper.GetStringSlice("logs.component"),
+		Follow:     viper.GetBool("logs.follow"),
+		Since:      viper.GetString("logs.since"),
+		Grep:       viper.GetString("logs.grep"),
+	}, os.Stdout); err != nil {
+		lg.Error("Could not stream logs", "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,16 @@
+package numbers
+
+import "github.com/spf13/cobra"
+
+var numbersCmd = &cobra.Command{
+	Use:   "numbers",
+	Short: "Manage the number normalization of an SBC",
+}
+
+func GetCmd() *cobra.Command {
+	numbersCmd.AddCommand(
+		getRulesCmd(),
+	)
+
+	return numbersCmd
+}
@@ -0,0 +1,19 @@
+package numbers
+
+import "github.com/spf13/cobra"
+
+var rulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Manage the number rewrite rules, rendered into the Kamailio dialplan",
+}
+
+func getRulesCmd() *cobra.Command {
+	rulesCmd.AddCommand(
+		getRulesAddCmd(),
+		getRulesListCmd(),
+		getRulesRemoveCmd(),
+		getRulesTestCmd(),
+	)
+
+	return rulesCmd
+}
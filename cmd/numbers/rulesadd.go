@@ -0,0 +1,99 @@
+package numbers
+
+import (
+	"log"
+	"os"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/sharedflags"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var rulesAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a number rewrite rule to an SBC",
+	Long: "Add a number rewrite rule to the SBC and reload the Kamailio dialplan. " +
+		"The rules of a direction and field are evaluated by priority, the lowest priority first, " +
+		"and only the first matching rule is applied. The whole user part of the uri is replaced with " +
+		"the replacement, where \\1 to \\9 are the groups of the match expression and \\0 is the whole match. " +
+		"The match expression uses the regular expression syntax supported by both Go and Kamailio.",
+	Example: "tsbc numbers rules add --sbc-fqdn sbc.test.com --direction pbx-to-teams --field from " +
+		"--match '^0([1-9][0-9]+)$' --replace '+385\\1'\n" +
+		"tsbc numbers rules add --sbc-fqdn sbc.test.com --direction teams-to-pbx " +
+		"--match '^\\+385([0-9]+)$' --replace '0\\1' --priority 10",
+	PreRun: sharedflags.Bind(flagnames.HostIP),
+	Run:    rulesAddCommandHandler,
+}
+
+func getRulesAddCmd() *cobra.Command {
+	rulesAddCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster")
+	rulesAddCmd.Flags().String(flagnames.NumberRuleDirection, "",
+		"direction of the calls: teams-to-pbx or pbx-to-teams")
+	rulesAddCmd.Flags().String(flagnames.NumberRuleField, sbc.NumberFieldRURI,
+		"rewritten number: ruri, from, to or pai")
+	rulesAddCmd.Flags().String(flagnames.NumberRuleMatch, "", "regular expression matched against the number")
+	rulesAddCmd.Flags().String(flagnames.NumberRuleReplace, "", "replacement of the matched number")
+	rulesAddCmd.Flags().Int(flagnames.NumberRulePriority, 0, "evaluation order, the lowest priority is used first")
+	rulesAddCmd.Flags().String(flagnames.HostIP, "",
+		"the static lan ip address of the docker host, taken from the existing Kamailio container if not set")
+
+	_ = rulesAddCmd.MarkFlagRequired(flagnames.SbcFqdn)
+	_ = rulesAddCmd.MarkFlagRequired(flagnames.NumberRuleDirection)
+	_ = rulesAddCmd.MarkFlagRequired(flagnames.NumberRuleMatch)
+	_ = rulesAddCmd.MarkFlagRequired(flagnames.NumberRuleReplace)
+
+	_ = rulesAddCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
+	// bind flags to viper
+	for key, flagName := range map[string]string{
+		"numbers-rules-add.fqdn":      flagnames.SbcFqdn,
+		"numbers-rules-add.direction": flagnames.NumberRuleDirection,
+		"numbers-rules-add.field":     flagnames.NumberRuleField,
+		"numbers-rules-add.match":     flagnames.NumberRuleMatch,
+		"numbers-rules-add.replace":   flagnames.NumberRuleReplace,
+		"numbers-rules-add.priority":  flagnames.NumberRulePriority,
+	} {
+		if err := viper.BindPFlag(key, rulesAddCmd.Flag(flagName)); err != nil {
+			log.Fatalln("Could not bind", key, "err:", err.Error())
+		}
+	}
+
+	return rulesAddCmd
+}
+
+func rulesAddCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "numbers-rules-add",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	rule := types.NumberRule{
+		Fqdn:      viper.GetString("numbers-rules-add.fqdn"),
+		Direction: viper.GetString("numbers-rules-add.direction"),
+		Field:     viper.GetString("numbers-rules-add.field"),
+		Priority:  viper.GetInt("numbers-rules-add.priority"),
+		Match:     viper.GetString("numbers-rules-add.match"),
+		Replace:   viper.GetString("numbers-rules-add.replace"),
+	}
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	if _, err = sbcInst.AddNumberRule(rule); err != nil {
+		lg.Error("Could not add number rule", "fqdn", rule.Fqdn, "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+}
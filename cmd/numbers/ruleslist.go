@@ -0,0 +1,98 @@
+package numbers
+
+import (
+	"log"
+	"os"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/output"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/fatih/color"
+	"github.com/hashicorp/go-hclog"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var rulesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the number rewrite rules of an SBC",
+	Example: "tsbc numbers rules list --sbc-fqdn sbc.test.com\n" +
+		"tsbc numbers rules list --sbc-fqdn sbc.test.com --output json",
+	Run: rulesListCommandHandler,
+}
+
+func getRulesListCmd() *cobra.Command {
+	rulesListCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster")
+
+	_ = rulesListCmd.MarkFlagRequired(flagnames.SbcFqdn)
+
+	_ = rulesListCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
+	// bind flags to viper
+	if err := viper.BindPFlag("numbers-rules-list.fqdn", rulesListCmd.Flag(flagnames.SbcFqdn)); err != nil {
+		log.Fatalln("Could not bind numbers-rules-list.fqdn err:", err.Error())
+	}
+
+	return rulesListCmd
+}
+
+func rulesListCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "numbers-rules-list",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	outputFormat := viper.GetString(flagnames.Output)
+	if err := output.Validate(outputFormat); err != nil {
+		lg.Error("Invalid output format", "err", err)
+		os.Exit(1)
+	}
+
+	sbcFqdn := viper.GetString("numbers-rules-list.fqdn")
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	rules, err := sbcInst.NumberRules(sbcFqdn)
+	if err != nil {
+		lg.Error("Could not get number rules", "fqdn", sbcFqdn, "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+
+	if outputFormat != output.Table {
+		if err = output.Write(os.Stdout, outputFormat, rules); err != nil {
+			lg.Error("Could not write number rules", "format", outputFormat, "err", err)
+			sbcInst.Close()
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	displayNumberRules(rules)
+}
+
+func displayNumberRules(rules []types.NumberRule) {
+	headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+	columnFmt := color.New(color.FgYellow).SprintfFunc()
+
+	tbl := table.New("ID", "DIRECTION", "FIELD", "PRIORITY", "MATCH", "REPLACE")
+	tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+
+	for _, rule := range rules {
+		tbl.AddRow(rule.ID, rule.Direction, rule.Field, rule.Priority, rule.Match, rule.Replace)
+	}
+
+	tbl.Print()
+}
@@ -0,0 +1,74 @@
+package numbers
+
+import (
+	"log"
+	"os"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/sharedflags"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var rulesRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Remove a number rewrite rule from an SBC",
+	Long: "Remove a number rewrite rule from the SBC and reload the Kamailio dialplan. " +
+		"The rule ids are listed with the list command.",
+	Example: "tsbc numbers rules remove --sbc-fqdn sbc.test.com --id 3",
+	PreRun:  sharedflags.Bind(flagnames.HostIP),
+	Run:     rulesRemoveCommandHandler,
+}
+
+func getRulesRemoveCmd() *cobra.Command {
+	rulesRemoveCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster")
+	rulesRemoveCmd.Flags().Int64(flagnames.NumberRuleID, 0, "id of the number rule")
+	rulesRemoveCmd.Flags().String(flagnames.HostIP, "",
+		"the static lan ip address of the docker host, taken from the existing Kamailio container if not set")
+
+	_ = rulesRemoveCmd.MarkFlagRequired(flagnames.SbcFqdn)
+	_ = rulesRemoveCmd.MarkFlagRequired(flagnames.NumberRuleID)
+
+	_ = rulesRemoveCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
+	// bind flags to viper
+	for key, flagName := range map[string]string{
+		"numbers-rules-remove.fqdn": flagnames.SbcFqdn,
+		"numbers-rules-remove.id":   flagnames.NumberRuleID,
+	} {
+		if err := viper.BindPFlag(key, rulesRemoveCmd.Flag(flagName)); err != nil {
+			log.Fatalln("Could not bind", key, "err:", err.Error())
+		}
+	}
+
+	return rulesRemoveCmd
+}
+
+func rulesRemoveCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "numbers-rules-remove",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	sbcFqdn := viper.GetString("numbers-rules-remove.fqdn")
+	ruleID := viper.GetInt64("numbers-rules-remove.id")
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	if err = sbcInst.RemoveNumberRule(sbcFqdn, ruleID); err != nil {
+		lg.Error("Could not remove number rule", "fqdn", sbcFqdn, "id", ruleID, "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,124 @@
+package numbers
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/output"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/fatih/color"
+	"github.com/hashicorp/go-hclog"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var rulesTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Evaluate a sample number against the number rewrite rules of an SBC",
+	Long: "Evaluate a sample number against the stored number rewrite rules of the direction and field, " +
+		"the same way as the Kamailio dialplan does. Nothing is changed on the SBC.",
+	Example: "tsbc numbers rules test --sbc-fqdn sbc.test.com --direction pbx-to-teams --field from " +
+		"--number 0911234567\n" +
+		"tsbc numbers rules test --sbc-fqdn sbc.test.com --direction teams-to-pbx --number +385911234567 -o json",
+	Run: rulesTestCommandHandler,
+}
+
+func getRulesTestCmd() *cobra.Command {
+	rulesTestCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster")
+	rulesTestCmd.Flags().String(flagnames.NumberRuleDirection, "",
+		"direction of the calls: teams-to-pbx or pbx-to-teams")
+	rulesTestCmd.Flags().String(flagnames.NumberRuleField, sbc.NumberFieldRURI,
+		"rewritten number: ruri, from, to or pai")
+	rulesTestCmd.Flags().String(flagnames.NumberRuleNumber, "", "sample number")
+
+	_ = rulesTestCmd.MarkFlagRequired(flagnames.SbcFqdn)
+	_ = rulesTestCmd.MarkFlagRequired(flagnames.NumberRuleDirection)
+	_ = rulesTestCmd.MarkFlagRequired(flagnames.NumberRuleNumber)
+
+	_ = rulesTestCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
+	// bind flags to viper
+	for key, flagName := range map[string]string{
+		"numbers-rules-test.fqdn":      flagnames.SbcFqdn,
+		"numbers-rules-test.direction": flagnames.NumberRuleDirection,
+		"numbers-rules-test.field":     flagnames.NumberRuleField,
+		"numbers-rules-test.number":    flagnames.NumberRuleNumber,
+	} {
+		if err := viper.BindPFlag(key, rulesTestCmd.Flag(flagName)); err != nil {
+			log.Fatalln("Could not bind", key, "err:", err.Error())
+		}
+	}
+
+	return rulesTestCmd
+}
+
+func rulesTestCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "numbers-rules-test",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	outputFormat := viper.GetString(flagnames.Output)
+	if err := output.Validate(outputFormat); err != nil {
+		lg.Error("Invalid output format", "err", err)
+		os.Exit(1)
+	}
+
+	sbcFqdn := viper.GetString("numbers-rules-test.fqdn")
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	result, err := sbcInst.TestNumberRules(
+		sbcFqdn,
+		viper.GetString("numbers-rules-test.direction"),
+		viper.GetString("numbers-rules-test.field"),
+		viper.GetString("numbers-rules-test.number"),
+	)
+	if err != nil {
+		lg.Error("Could not test number rules", "fqdn", sbcFqdn, "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+
+	if outputFormat != output.Table {
+		if err = output.Write(os.Stdout, outputFormat, []types.NumberRuleResult{result}); err != nil {
+			lg.Error("Could not write number rule result", "format", outputFormat, "err", err)
+			sbcInst.Close()
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	displayNumberRuleResult(result)
+}
+
+func displayNumberRuleResult(result types.NumberRuleResult) {
+	headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+	columnFmt := color.New(color.FgYellow).SprintfFunc()
+
+	tbl := table.New("INPUT", "OUTPUT", "DIRECTION", "FIELD", "MATCHED", "RULE ID")
+	tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+
+	ruleID := "-"
+	if result.Matched {
+		ruleID = fmt.Sprint(result.RuleID)
+	}
+
+	tbl.AddRow(result.Input, result.Output, result.Direction, result.Field, result.Matched, ruleID)
+
+	tbl.Print()
+}
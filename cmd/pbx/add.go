@@ -0,0 +1,94 @@
+package pbx
+
+import (
+	"log"
+	"os"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/sharedflags"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var addCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a PBX target to an SBC",
+	Long: "Add a PBX target to the Kamailio dispatcher of the SBC, next to the primary PBX set with --kamailio-pbx-ip. " +
+		"Every target is probed with SIP OPTIONS. If all the targets have the same priority, the calls are shared " +
+		"by their relative weight, otherwise the calls go to the available target with the highest priority. " +
+		"The primary PBX has the priority 0, so use a negative priority for backup targets.",
+	Example: "tsbc pbx add --sbc-fqdn sbc.test.com --address 192.168.1.2\n" +
+		"tsbc pbx add --sbc-fqdn sbc.test.com --address pbx2.lan --transport tcp --priority -1",
+	PreRun: sharedflags.Bind(flagnames.HostIP),
+	Run:    addCommandHandler,
+}
+
+func getAddCmd() *cobra.Command {
+	addCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster")
+	addCmd.Flags().String(flagnames.PbxTargetAddress, "", "ip address or host name of the PBX")
+	addCmd.Flags().String(flagnames.PbxTargetPort, "5060", "sip port of the PBX")
+	addCmd.Flags().String(flagnames.PbxTargetTransport, sbc.PbxTransportUDP,
+		"sip transport toward the PBX: udp, tcp or tls")
+	addCmd.Flags().Int(flagnames.PbxTargetPriority, 0, "dispatcher priority, the highest priority is used first")
+	addCmd.Flags().Int(flagnames.PbxTargetWeight, 1,
+		"relative weight between 1 and 100 among the targets with the same priority")
+	addCmd.Flags().String(flagnames.HostIP, "",
+		"the static lan ip address of the docker host, taken from the existing Kamailio container if not set")
+
+	_ = addCmd.MarkFlagRequired(flagnames.SbcFqdn)
+	_ = addCmd.MarkFlagRequired(flagnames.PbxTargetAddress)
+
+	_ = addCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
+	// bind flags to viper
+	for key, flagName := range map[string]string{
+		"pbx-add.fqdn":      flagnames.SbcFqdn,
+		"pbx-add.address":   flagnames.PbxTargetAddress,
+		"pbx-add.port":      flagnames.PbxTargetPort,
+		"pbx-add.transport": flagnames.PbxTargetTransport,
+		"pbx-add.priority":  flagnames.PbxTargetPriority,
+		"pbx-add.weight":    flagnames.PbxTargetWeight,
+	} {
+		if err := viper.BindPFlag(key, addCmd.Flag(flagName)); err != nil {
+			log.Fatalln("Could not bind", key, "err:", err.Error())
+		}
+	}
+
+	return addCmd
+}
+
+func addCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "pbx-add",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	target := types.PbxTarget{
+		Fqdn:      viper.GetString("pbx-add.fqdn"),
+		Address:   viper.GetString("pbx-add.address"),
+		Port:      viper.GetString("pbx-add.port"),
+		Transport: viper.GetString("pbx-add.transport"),
+		Priority:  viper.GetInt("pbx-add.priority"),
+		Weight:    viper.GetInt("pbx-add.weight"),
+	}
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	if err = sbcInst.AddPbxTarget(target); err != nil {
+		lg.Error("Could not add PBX target", "fqdn", target.Fqdn, "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+}
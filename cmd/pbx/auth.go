@@ -0,0 +1,18 @@
+package pbx
+
+import "github.com/spf13/cobra"
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Manage the digest authentication toward the PBX trunk",
+}
+
+func getAuthCmd() *cobra.Command {
+	authCmd.AddCommand(
+		getAuthSetCmd(),
+		getAuthShowCmd(),
+		getAuthRemoveCmd(),
+	)
+
+	return authCmd
+}
@@ -0,0 +1,64 @@
+package pbx
+
+import (
+	"log"
+	"os"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/sharedflags"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var authRemoveCmd = &cobra.Command{
+	Use:     "remove",
+	Short:   "Remove the credentials of the PBX trunk",
+	Example: "tsbc pbx auth remove --sbc-fqdn sbc.test.com",
+	PreRun:  sharedflags.Bind(flagnames.HostIP),
+	Run:     authRemoveCommandHandler,
+}
+
+func getAuthRemoveCmd() *cobra.Command {
+	authRemoveCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster")
+	authRemoveCmd.Flags().String(flagnames.HostIP, "",
+		"the static lan ip address of the docker host, taken from the existing Kamailio container if not set")
+
+	_ = authRemoveCmd.MarkFlagRequired(flagnames.SbcFqdn)
+
+	_ = authRemoveCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
+	// bind flags to viper
+	if err := viper.BindPFlag("pbx-auth-remove.fqdn", authRemoveCmd.Flag(flagnames.SbcFqdn)); err != nil {
+		log.Fatalln("Could not bind pbx-auth-remove.fqdn err:", err.Error())
+	}
+
+	return authRemoveCmd
+}
+
+func authRemoveCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "pbx-auth-remove",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	sbcFqdn := viper.GetString("pbx-auth-remove.fqdn")
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	if err = sbcInst.RemovePbxCredentials(sbcFqdn); err != nil {
+		lg.Error("Could not remove PBX credentials", "fqdn", sbcFqdn, "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,100 @@
+package pbx
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/sharedflags"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var authSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Set the credentials of the PBX trunk",
+	Long: "Set the credentials, that Kamailio uses to answer the digest authentication challenges of the PBX. " +
+		"With --register, Kamailio also registers to the primary PBX. " +
+		"The password is stored encrypted in the database, with the key in the secret.key file next to it.",
+	Example: "tsbc pbx auth set --sbc-fqdn sbc.test.com --username trunk1 --password-file ./trunk.pass\n" +
+		"tsbc pbx auth set --sbc-fqdn sbc.test.com --username trunk1 --password-file ./trunk.pass " +
+		"--realm pbx.example.com --register --expires 600",
+	PreRun: sharedflags.Bind(flagnames.HostIP),
+	Run:    authSetCommandHandler,
+}
+
+func getAuthSetCmd() *cobra.Command {
+	authSetCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster")
+	authSetCmd.Flags().String(flagnames.PbxAuthUsername, "", "authentication username")
+	authSetCmd.Flags().String(flagnames.PbxAuthPasswordFile, "", "file holding the authentication password")
+	authSetCmd.Flags().String(flagnames.PbxAuthRealm, "", "authentication realm, the primary PBX ip if not set")
+	authSetCmd.Flags().Bool(flagnames.PbxAuthRegister, false, "register to the primary PBX")
+	authSetCmd.Flags().Int(flagnames.PbxAuthExpires, 3600, "registration expiry in seconds")
+	authSetCmd.Flags().String(flagnames.HostIP, "",
+		"the static lan ip address of the docker host, taken from the existing Kamailio container if not set")
+
+	_ = authSetCmd.MarkFlagRequired(flagnames.SbcFqdn)
+	_ = authSetCmd.MarkFlagRequired(flagnames.PbxAuthUsername)
+	_ = authSetCmd.MarkFlagRequired(flagnames.PbxAuthPasswordFile)
+
+	_ = authSetCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
+	// bind flags to viper
+	for key, flagName := range map[string]string{
+		"pbx-auth-set.fqdn":          flagnames.SbcFqdn,
+		"pbx-auth-set.username":      flagnames.PbxAuthUsername,
+		"pbx-auth-set.password-file": flagnames.PbxAuthPasswordFile,
+		"pbx-auth-set.realm":         flagnames.PbxAuthRealm,
+		"pbx-auth-set.register":      flagnames.PbxAuthRegister,
+		"pbx-auth-set.expires":       flagnames.PbxAuthExpires,
+	} {
+		if err := viper.BindPFlag(key, authSetCmd.Flag(flagName)); err != nil {
+			log.Fatalln("Could not bind", key, "err:", err.Error())
+		}
+	}
+
+	return authSetCmd
+}
+
+func authSetCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "pbx-auth-set",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	password, err := os.ReadFile(viper.GetString("pbx-auth-set.password-file"))
+	if err != nil {
+		lg.Error("Could not read password file", "err", err)
+		os.Exit(1)
+	}
+
+	creds := types.PbxCredentials{
+		Fqdn:     viper.GetString("pbx-auth-set.fqdn"),
+		Username: viper.GetString("pbx-auth-set.username"),
+		Password: strings.TrimSpace(string(password)),
+		Realm:    viper.GetString("pbx-auth-set.realm"),
+		Register: viper.GetBool("pbx-auth-set.register"),
+		Expires:  viper.GetInt("pbx-auth-set.expires"),
+	}
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	if err = sbcInst.SetPbxCredentials(creds); err != nil {
+		lg.Error("Could not set PBX credentials", "fqdn", creds.Fqdn, "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,108 @@
+package pbx
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/output"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/fatih/color"
+	"github.com/hashicorp/go-hclog"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var authShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the credentials of the PBX trunk, without the password",
+	Example: "tsbc pbx auth show --sbc-fqdn sbc.test.com\n" +
+		"tsbc pbx auth show --sbc-fqdn sbc.test.com --output json",
+	Run: authShowCommandHandler,
+}
+
+func getAuthShowCmd() *cobra.Command {
+	authShowCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster")
+
+	_ = authShowCmd.MarkFlagRequired(flagnames.SbcFqdn)
+
+	_ = authShowCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
+	// bind flags to viper
+	if err := viper.BindPFlag("pbx-auth-show.fqdn", authShowCmd.Flag(flagnames.SbcFqdn)); err != nil {
+		log.Fatalln("Could not bind pbx-auth-show.fqdn err:", err.Error())
+	}
+
+	return authShowCmd
+}
+
+func authShowCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "pbx-auth-show",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	outputFormat := viper.GetString(flagnames.Output)
+	if err := output.Validate(outputFormat); err != nil {
+		lg.Error("Invalid output format", "err", err)
+		os.Exit(1)
+	}
+
+	sbcFqdn := viper.GetString("pbx-auth-show.fqdn")
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	creds, err := sbcInst.PbxCredentials(sbcFqdn)
+	if err != nil {
+		lg.Error("Could not get PBX credentials", "fqdn", sbcFqdn, "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+
+	if creds.Username == "" {
+		lg.Info("The PBX trunk is authenticated by ip address", "fqdn", sbcFqdn)
+
+		return
+	}
+
+	if outputFormat != output.Table {
+		if err = output.Write(os.Stdout, outputFormat, []types.PbxCredentials{creds}); err != nil {
+			lg.Error("Could not write PBX credentials", "format", outputFormat, "err", err)
+			sbcInst.Close()
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	displayPbxCredentials(creds)
+}
+
+func displayPbxCredentials(creds types.PbxCredentials) {
+	headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+	columnFmt := color.New(color.FgYellow).SprintfFunc()
+
+	tbl := table.New("USERNAME", "REALM", "REGISTER", "EXPIRES")
+	tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+
+	expires := "-"
+	if creds.Register {
+		expires = fmt.Sprintf("%ds", creds.Expires)
+	}
+
+	tbl.AddRow(creds.Username, creds.Realm, creds.Register, expires)
+
+	tbl.Print()
+}
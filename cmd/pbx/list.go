@@ -0,0 +1,98 @@
+package pbx
+
+import (
+	"log"
+	"os"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/output"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/fatih/color"
+	"github.com/hashicorp/go-hclog"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the PBX targets of an SBC",
+	Example: "tsbc pbx list --sbc-fqdn sbc.test.com\n" +
+		"tsbc pbx list --sbc-fqdn sbc.test.com --output json",
+	Run: listCommandHandler,
+}
+
+func getListCmd() *cobra.Command {
+	listCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster")
+
+	_ = listCmd.MarkFlagRequired(flagnames.SbcFqdn)
+
+	_ = listCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
+	// bind flags to viper
+	if err := viper.BindPFlag("pbx-list.fqdn", listCmd.Flag(flagnames.SbcFqdn)); err != nil {
+		log.Fatalln("Could not bind pbx-list.fqdn err:", err.Error())
+	}
+
+	return listCmd
+}
+
+func listCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "pbx-list",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	outputFormat := viper.GetString(flagnames.Output)
+	if err := output.Validate(outputFormat); err != nil {
+		lg.Error("Invalid output format", "err", err)
+		os.Exit(1)
+	}
+
+	sbcFqdn := viper.GetString("pbx-list.fqdn")
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	targets, err := sbcInst.PbxTargets(sbcFqdn)
+	if err != nil {
+		lg.Error("Could not get PBX targets", "fqdn", sbcFqdn, "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+
+	if outputFormat != output.Table {
+		if err = output.Write(os.Stdout, outputFormat, targets); err != nil {
+			lg.Error("Could not write PBX targets", "format", outputFormat, "err", err)
+			sbcInst.Close()
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	displayPbxTargets(targets)
+}
+
+func displayPbxTargets(targets []types.PbxTarget) {
+	headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+	columnFmt := color.New(color.FgYellow).SprintfFunc()
+
+	tbl := table.New("ADDRESS", "PORT", "TRANSPORT", "PRIORITY", "WEIGHT", "PRIMARY")
+	tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+
+	for _, target := range targets {
+		tbl.AddRow(target.Address, target.Port, target.Transport, target.Priority, target.Weight, target.Primary)
+	}
+
+	tbl.Print()
+}
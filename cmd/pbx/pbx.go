@@ -0,0 +1,20 @@
+package pbx
+
+import "github.com/spf13/cobra"
+
+var pbxCmd = &cobra.Command{
+	Use:   "pbx",
+	Short: "Manage the PBX trunk of an SBC",
+}
+
+func GetCmd() *cobra.Command {
+	pbxCmd.AddCommand(
+		getAddCmd(),
+		getRemoveCmd(),
+		getListCmd(),
+		getAuthCmd(),
+		getTransportCmd(),
+	)
+
+	return pbxCmd
+}
@@ -0,0 +1,85 @@
+package pbx
+
+import (
+	"log"
+	"os"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/sharedflags"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var removeCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Remove a PBX target from an SBC",
+	Long: "Remove a PBX target from the Kamailio dispatcher of the SBC. " +
+		"The primary PBX can not be removed, change it with the update command instead.",
+	Example: "tsbc pbx remove --sbc-fqdn sbc.test.com --address 192.168.1.2\n" +
+		"tsbc pbx remove --sbc-fqdn sbc.test.com --address pbx2.lan --transport tcp",
+	PreRun: sharedflags.Bind(flagnames.HostIP),
+	Run:    removeCommandHandler,
+}
+
+func getRemoveCmd() *cobra.Command {
+	removeCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster")
+	removeCmd.Flags().String(flagnames.PbxTargetAddress, "", "ip address or host name of the PBX")
+	removeCmd.Flags().String(flagnames.PbxTargetPort, "5060", "sip port of the PBX")
+	removeCmd.Flags().String(flagnames.PbxTargetTransport, sbc.PbxTransportUDP,
+		"sip transport toward the PBX: udp, tcp or tls")
+	removeCmd.Flags().String(flagnames.HostIP, "",
+		"the static lan ip address of the docker host, taken from the existing Kamailio container if not set")
+
+	_ = removeCmd.MarkFlagRequired(flagnames.SbcFqdn)
+	_ = removeCmd.MarkFlagRequired(flagnames.PbxTargetAddress)
+
+	_ = removeCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
+	// bind flags to viper
+	for key, flagName := range map[string]string{
+		"pbx-remove.fqdn":      flagnames.SbcFqdn,
+		"pbx-remove.address":   flagnames.PbxTargetAddress,
+		"pbx-remove.port":      flagnames.PbxTargetPort,
+		"pbx-remove.transport": flagnames.PbxTargetTransport,
+	} {
+		if err := viper.BindPFlag(key, removeCmd.Flag(flagName)); err != nil {
+			log.Fatalln("Could not bind", key, "err:", err.Error())
+		}
+	}
+
+	return removeCmd
+}
+
+func removeCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "pbx-remove",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	target := types.PbxTarget{
+		Fqdn:      viper.GetString("pbx-remove.fqdn"),
+		Address:   viper.GetString("pbx-remove.address"),
+		Port:      viper.GetString("pbx-remove.port"),
+		Transport: viper.GetString("pbx-remove.transport"),
+	}
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	if err = sbcInst.RemovePbxTarget(target); err != nil {
+		lg.Error("Could not remove PBX target", "fqdn", target.Fqdn, "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,17 @@
+package pbx
+
+import "github.com/spf13/cobra"
+
+var transportCmd = &cobra.Command{
+	Use:   "transport",
+	Short: "Manage the transport toward the PBX trunk",
+}
+
+func getTransportCmd() *cobra.Command {
+	transportCmd.AddCommand(
+		getTransportSetCmd(),
+		getTransportShowCmd(),
+	)
+
+	return transportCmd
+}
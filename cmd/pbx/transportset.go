@@ -0,0 +1,100 @@
+package pbx
+
+import (
+	"log"
+	"os"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/sharedflags"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var transportSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Set the transport toward the PBX trunk",
+	Long: "Set the transport of the primary PBX to udp, tcp or tls. " +
+		"The PBX side of Kamailio listens on the sbc udp port for the transport. " +
+		"The certificate settings apply to all the tls PBX targets: --client-cert presents the SBC certificate " +
+		"to the PBX, --verify verifies the PBX certificate with the system CAs, or with the CAs of --ca-file.",
+	Example: "tsbc pbx transport set --sbc-fqdn sbc.test.com --transport tcp\n" +
+		"tsbc pbx transport set --sbc-fqdn sbc.test.com --transport tls --client-cert --ca-file ./pbx-ca.pem\n" +
+		"tsbc pbx transport set --sbc-fqdn sbc.test.com --transport udp",
+	PreRun: sharedflags.Bind(flagnames.HostIP),
+	Run:    transportSetCommandHandler,
+}
+
+func getTransportSetCmd() *cobra.Command {
+	transportSetCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster")
+	transportSetCmd.Flags().String(flagnames.PbxTargetTransport, "", "transport of the primary PBX: udp, tcp or tls")
+	transportSetCmd.Flags().Bool(flagnames.PbxTransportClientCert, false, "present the SBC certificate to the PBX")
+	transportSetCmd.Flags().Bool(flagnames.PbxTransportVerify, false, "verify the PBX certificate")
+	transportSetCmd.Flags().String(flagnames.PbxTransportCAFile, "",
+		"PEM file with the CA certificates of the PBX, enables --verify")
+	transportSetCmd.Flags().String(flagnames.HostIP, "",
+		"the static lan ip address of the docker host, taken from the existing Kamailio container if not set")
+
+	_ = transportSetCmd.MarkFlagRequired(flagnames.SbcFqdn)
+	_ = transportSetCmd.MarkFlagRequired(flagnames.PbxTargetTransport)
+
+	_ = transportSetCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
+	// bind flags to viper
+	for key, flagName := range map[string]string{
+		"pbx-transport-set.fqdn":        flagnames.SbcFqdn,
+		"pbx-transport-set.transport":   flagnames.PbxTargetTransport,
+		"pbx-transport-set.client-cert": flagnames.PbxTransportClientCert,
+		"pbx-transport-set.verify":      flagnames.PbxTransportVerify,
+		"pbx-transport-set.ca-file":     flagnames.PbxTransportCAFile,
+	} {
+		if err := viper.BindPFlag(key, transportSetCmd.Flag(flagName)); err != nil {
+			log.Fatalln("Could not bind", key, "err:", err.Error())
+		}
+	}
+
+	return transportSetCmd
+}
+
+func transportSetCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "pbx-transport-set",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	transport := types.PbxTransport{
+		Fqdn:              viper.GetString("pbx-transport-set.fqdn"),
+		Transport:         viper.GetString("pbx-transport-set.transport"),
+		ClientCertificate: viper.GetBool("pbx-transport-set.client-cert"),
+		VerifyCertificate: viper.GetBool("pbx-transport-set.verify"),
+	}
+
+	if caFile := viper.GetString("pbx-transport-set.ca-file"); caFile != "" {
+		caCert, err := os.ReadFile(caFile)
+		if err != nil {
+			lg.Error("Could not read CA file", "err", err)
+			os.Exit(1)
+		}
+
+		transport.CACertificate = string(caCert)
+	}
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	if err = sbcInst.SetPbxTransport(transport); err != nil {
+		lg.Error("Could not set PBX transport", "fqdn", transport.Fqdn, "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+}
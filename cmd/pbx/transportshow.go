@@ -0,0 +1,105 @@
+package pbx
+
+import (
+	"log"
+	"os"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/output"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/fatih/color"
+	"github.com/hashicorp/go-hclog"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var transportShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the transport toward the PBX trunk",
+	Example: "tsbc pbx transport show --sbc-fqdn sbc.test.com\n" +
+		"tsbc pbx transport show --sbc-fqdn sbc.test.com --output yaml",
+	Run: transportShowCommandHandler,
+}
+
+func getTransportShowCmd() *cobra.Command {
+	transportShowCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster")
+
+	_ = transportShowCmd.MarkFlagRequired(flagnames.SbcFqdn)
+
+	_ = transportShowCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
+	// bind flags to viper
+	if err := viper.BindPFlag("pbx-transport-show.fqdn", transportShowCmd.Flag(flagnames.SbcFqdn)); err != nil {
+		log.Fatalln("Could not bind pbx-transport-show.fqdn err:", err.Error())
+	}
+
+	return transportShowCmd
+}
+
+func transportShowCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "pbx-transport-show",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	outputFormat := viper.GetString(flagnames.Output)
+	if err := output.Validate(outputFormat); err != nil {
+		lg.Error("Invalid output format", "err", err)
+		os.Exit(1)
+	}
+
+	sbcFqdn := viper.GetString("pbx-transport-show.fqdn")
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	defer sbcInst.Close()
+
+	transport, err := sbcInst.PbxTransport(sbcFqdn)
+	if err != nil {
+		lg.Error("Could not get PBX transport", "fqdn", sbcFqdn, "err", err)
+		sbcInst.Close()
+		os.Exit(1)
+	}
+
+	if outputFormat != output.Table {
+		if err = output.Write(os.Stdout, outputFormat, []types.PbxTransport{transport}); err != nil {
+			lg.Error("Could not write PBX transport", "format", outputFormat, "err", err)
+			sbcInst.Close()
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	displayPbxTransport(transport)
+}
+
+func displayPbxTransport(transport types.PbxTransport) {
+	headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+	columnFmt := color.New(color.FgYellow).SprintfFunc()
+
+	tbl := table.New("TRANSPORT", "CLIENT_CERT", "VERIFY", "CA")
+	tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+
+	caList := "-"
+
+	switch {
+	case transport.CACertificate != "":
+		caList = "custom"
+	case transport.VerifyCertificate:
+		caList = "system"
+	}
+
+	tbl.AddRow(transport.Transport, transport.ClientCertificate, transport.VerifyCertificate, caList)
+
+	tbl.Print()
+}
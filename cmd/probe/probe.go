@@ -0,0 +1,136 @@
+package probe
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/output"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/sharedflags"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/fatih/color"
+	"github.com/hashicorp/go-hclog"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var probeCmd = &cobra.Command{
+	Use:   "probe",
+	Short: "Check that an SBC answers SIP OPTIONS",
+	Long: "Send SIP OPTIONS to the SBC over UDP on the sbc udp port, the way the PBX reaches it, " +
+		"and over TLS on the sbc tls port with the fqdn as SNI, the way MS Teams reaches it. " +
+		"The response code and latency are shown for both transports. " +
+		"The command exits with a non-zero code if the SBC does not answer with a 2xx response on any transport.",
+	Example: "tsbc probe --sbc-fqdn sbc.test.com\n" +
+		"tsbc probe --sbc-fqdn sbc.test.com --timeout 2s --output json\n" +
+		"tsbc probe --sbc-fqdn sbc.test.com --host-ip 127.0.0.1 --insecure",
+	PreRun: sharedflags.Bind(flagnames.HostIP),
+	Run:    probeCommandHandler,
+}
+
+func GetCmd() *cobra.Command {
+	probeCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster")
+	probeCmd.Flags().String(flagnames.HostIP, "",
+		"the static lan ip address of the docker host, taken from the existing Kamailio container if not set")
+	probeCmd.Flags().Duration(flagnames.ProbeTimeout, 5*time.Second, "time to wait for the response on each transport")
+	probeCmd.Flags().Bool(flagnames.ProbeInsecure, false,
+		"do not verify the TLS certificate of the SBC, e.g. when probing a test responder")
+
+	_ = probeCmd.MarkFlagRequired(flagnames.SbcFqdn)
+
+	_ = probeCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
+	// bind flags to viper
+	for key, flagName := range map[string]string{
+		"probe.fqdn":     flagnames.SbcFqdn,
+		"probe.timeout":  flagnames.ProbeTimeout,
+		"probe.insecure": flagnames.ProbeInsecure,
+	} {
+		if err := viper.BindPFlag(key, probeCmd.Flag(flagName)); err != nil {
+			log.Fatalln("Could not bind", key, "err:", err.Error())
+		}
+	}
+
+	return probeCmd
+}
+
+func probeCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "probe",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	outputFormat := viper.GetString(flagnames.Output)
+	if err := output.Validate(outputFormat); err != nil {
+		lg.Error("Invalid output format", "err", err)
+		os.Exit(1)
+	}
+
+	sbcFqdn := viper.GetString("probe.fqdn")
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	results, err := sbcInst.Probe(sbcFqdn, types.ProbeOptions{
+		Timeout:            viper.GetDuration("probe.timeout"),
+		InsecureSkipVerify: viper.GetBool("probe.insecure"),
+	})
+
+	sbcInst.Close()
+
+	if err != nil {
+		lg.Error("Could not probe SBC", "fqdn", sbcFqdn, "err", err)
+		os.Exit(1)
+	}
+
+	if outputFormat == output.Table {
+		displayProbeResults(results)
+	} else if err = output.Write(os.Stdout, outputFormat, results); err != nil {
+		lg.Error("Could not write probe results", "format", outputFormat, "err", err)
+		os.Exit(1)
+	}
+
+	// non-zero exit code lets cron jobs and monitoring detect an SBC that does not answer SIP
+	for _, result := range results {
+		if !result.Healthy {
+			os.Exit(1)
+		}
+	}
+}
+
+func displayProbeResults(results []types.ProbeResult) {
+	headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+	columnFmt := color.New(color.FgYellow).SprintfFunc()
+	unhealthyFmt := color.New(color.FgRed).SprintFunc()
+
+	tbl := table.New("TRANSPORT", "ADDRESS", "RESPONSE", "LATENCY", "SERVER", "HEALTHY")
+	tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+
+	for _, result := range results {
+		response := fmt.Sprintf("%d %s", result.StatusCode, result.Reason)
+		latency := fmt.Sprintf("%.2fms", result.LatencyMs)
+
+		if result.Error != "" {
+			response, latency = result.Error, "-"
+		}
+
+		healthy := fmt.Sprint(result.Healthy)
+		if !result.Healthy {
+			healthy = unhealthyFmt(healthy)
+		}
+
+		tbl.AddRow(result.Transport, result.Address, response, latency, result.Server, healthy)
+	}
+
+	tbl.Print()
+}
@@ -2,8 +2,12 @@ package recreate
 
 import (
 	"log"
+	"os"
 
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/dryrun"
 	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/sharedflags"
 	"github.com/ZeljkoBenovic/tsbc/sbc"
 	"github.com/hashicorp/go-hclog"
 	"github.com/spf13/cobra"
@@ -11,26 +15,32 @@ import (
 )
 
 var recreateCmd = &cobra.Command{
-	Use:     "recreate",
-	Short:   "Command used to recreate SBC nodes",
-	Example: "tsbc recreate --fqdn-name sbc.test.com",
-	Run:     recreateCommandHandler,
+	Use:   "recreate",
+	Short: "Command used to recreate SBC nodes",
+	Example: "tsbc recreate --fqdn-name sbc.test.com\n" +
+		"tsbc recreate --sbc-fqdn sbc.test.com --dry-run",
+	PreRun: sharedflags.Bind(flagnames.HostIP, flagnames.IgnoreRateLimit),
+	Run:    recreateCommandHandler,
 }
 
 func GetCmd() *cobra.Command {
 	recreateCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster to restart")
-	recreateCmd.Flags().String(flagnames.LogLevel, "info", "set log level")
 	recreateCmd.Flags().String(flagnames.HostIP, "", "the static lan ip address of the docker host")
+	recreateCmd.Flags().Bool(flagnames.DryRun, false, "print the containers that would be replaced without making changes")
+	recreateCmd.Flags().Bool(flagnames.IgnoreRateLimit, false,
+		"issue certificates even if LetsEncrypt rate limits would be exceeded")
 
 	_ = recreateCmd.MarkFlagRequired(flagnames.SbcFqdn)
 
+	_ = recreateCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
 	// bind flags to viper
 	if err := viper.BindPFlag("recreate.fqdn", recreateCmd.Flag(flagnames.SbcFqdn)); err != nil {
 		log.Fatalln("Could not bind restart.fqdn err:", err.Error())
 	}
 
-	if err := viper.BindPFlag("recreate.log-level", recreateCmd.Flag(flagnames.LogLevel)); err != nil {
-		log.Fatalln("Could not bind restart.log-level err:", err.Error())
+	if err := viper.BindPFlag("recreate.dry-run", recreateCmd.Flag(flagnames.DryRun)); err != nil {
+		log.Fatalln("Could not bind recreate.dry-run err:", err.Error())
 	}
 
 	return recreateCmd
@@ -39,18 +49,41 @@ func GetCmd() *cobra.Command {
 func recreateCommandHandler(_ *cobra.Command, _ []string) {
 	lg := hclog.New(&hclog.LoggerOptions{
 		Name:                 "recreate",
-		Level:                hclog.LevelFromString(viper.GetString("recreate.log-level")),
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
 		Color:                hclog.AutoColor,
 		ColorHeaderAndFields: true,
 	})
 
-	sbcInst, err := sbc.NewSBC()
+	newSBC := sbc.NewSBC
+
+	// the dry-run plan must not create the database or the log directories
+	if viper.GetBool("recreate.dry-run") {
+		newSBC = sbc.NewPlanSBC
+	}
+
+	sbcInst, err := newSBC()
 	if err != nil {
 		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
 	}
 
 	defer sbcInst.Close()
 
+	if viper.GetBool("recreate.dry-run") {
+		plan, err := sbcInst.PlanRecreate(viper.GetString("recreate.fqdn"))
+		if err == nil {
+			err = dryrun.Print(plan)
+		}
+
+		if err != nil {
+			lg.Error("Could not plan sbc recreation", "err", err, "fqdn", viper.GetString("recreate.fqdn"))
+			sbcInst.Close()
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	if err = sbcInst.Recreate(viper.GetString("recreate.fqdn")); err != nil {
 		lg.Error("Could not recreate sbc cluster", "err", err, "fqdn", viper.GetString("recreate.fqdn"))
 	}
@@ -3,6 +3,7 @@ package restart
 import (
 	"log"
 
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
 	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
 	"github.com/ZeljkoBenovic/tsbc/sbc"
 	"github.com/hashicorp/go-hclog"
@@ -19,26 +20,23 @@ var restartCmd = &cobra.Command{
 
 func GetCmd() *cobra.Command {
 	restartCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster to restart")
-	restartCmd.Flags().String(flagnames.LogLevel, "info", "set log level")
 
 	_ = restartCmd.MarkFlagRequired(flagnames.SbcFqdn)
 
+	_ = restartCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
 	// bind flags to viper
 	if err := viper.BindPFlag("restart.fqdn", restartCmd.Flag(flagnames.SbcFqdn)); err != nil {
 		log.Fatalln("Could not bind restart.fqdn err:", err.Error())
 	}
 
-	if err := viper.BindPFlag("restart.log-level", restartCmd.Flag(flagnames.LogLevel)); err != nil {
-		log.Fatalln("Could not bind restart.log-level err:", err.Error())
-	}
-
 	return restartCmd
 }
 
 func restartCommandHandler(_ *cobra.Command, _ []string) {
 	lg := hclog.New(&hclog.LoggerOptions{
 		Name:                 "restart",
-		Level:                hclog.LevelFromString(viper.GetString("restart.log-level")),
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
 		Color:                hclog.AutoColor,
 		ColorHeaderAndFields: true,
 	})
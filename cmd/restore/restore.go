@@ -0,0 +1,57 @@
+package restore
+
+import (
+	"os"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/sharedflags"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <backup file>",
+	Short: "Restore the database, volumes and containers from a backup",
+	Long: "Verify the manifest checksums of a backup archive and recreate the database, the volumes " +
+		"and all the containers with the archived image digests. The restore is refused if SBCs are already " +
+		"deployed with the selected database. The database is replaced only after all the containers are running, " +
+		"so a failed restore can be retried from the same archive.",
+	Example: "tsbc restore tsbc-backup.tar.gz\n" +
+		"tsbc restore tsbc-backup.tar.gz --host-ip 192.168.10.2",
+	Args:   cobra.ExactArgs(1),
+	PreRun: sharedflags.Bind(flagnames.HostIP),
+	Run:    restoreCommandHandler,
+}
+
+func GetCmd() *cobra.Command {
+	restoreCmd.Flags().String(flagnames.HostIP, "",
+		"the static lan ip address of the docker host, taken from the backup if not set")
+
+	return restoreCmd
+}
+
+func restoreCommandHandler(_ *cobra.Command, args []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "restore",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	err = sbcInst.Restore(args[0])
+
+	sbcInst.Close()
+
+	if err != nil {
+		lg.Error("Could not restore tsbc", "file", args[0], "err", err)
+		os.Exit(1)
+	}
+}
@@ -4,12 +4,32 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/ZeljkoBenovic/tsbc/cmd/apply"
+	"github.com/ZeljkoBenovic/tsbc/cmd/backup"
+	"github.com/ZeljkoBenovic/tsbc/cmd/calls"
+	"github.com/ZeljkoBenovic/tsbc/cmd/capture"
+	"github.com/ZeljkoBenovic/tsbc/cmd/cert"
 	"github.com/ZeljkoBenovic/tsbc/cmd/destroy"
+	"github.com/ZeljkoBenovic/tsbc/cmd/firewall"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/config"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/output"
+	"github.com/ZeljkoBenovic/tsbc/cmd/initialize"
+	"github.com/ZeljkoBenovic/tsbc/cmd/kamailio"
 	"github.com/ZeljkoBenovic/tsbc/cmd/list"
+	"github.com/ZeljkoBenovic/tsbc/cmd/logs"
+	"github.com/ZeljkoBenovic/tsbc/cmd/numbers"
+	"github.com/ZeljkoBenovic/tsbc/cmd/pbx"
+	"github.com/ZeljkoBenovic/tsbc/cmd/probe"
 	"github.com/ZeljkoBenovic/tsbc/cmd/recreate"
 	"github.com/ZeljkoBenovic/tsbc/cmd/restart"
+	"github.com/ZeljkoBenovic/tsbc/cmd/restore"
 	"github.com/ZeljkoBenovic/tsbc/cmd/run"
+	"github.com/ZeljkoBenovic/tsbc/cmd/status"
+	"github.com/ZeljkoBenovic/tsbc/cmd/update"
+	"github.com/ZeljkoBenovic/tsbc/db"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 // TODO: fix long description
@@ -20,6 +40,7 @@ var rootCmd = &cobra.Command{
 	Long: "TSBC allows the interconnection between the internal PBX system," +
 		"that is running on plain old SIP on UDP protoco" +
 		"and the MS Teams VoIP platform, which uses SSIP (Secure SIP) on TCP/TLS protocol.",
+	PersistentPreRun: loadConfig,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -32,10 +53,59 @@ func Execute() {
 		restart.GetCmd(),
 		recreate.GetCmd(),
 		list.GetCmd(),
+		cert.GetCmd(),
+		status.GetCmd(),
+		logs.GetCmd(),
+		apply.GetCmd(),
+		update.GetCmd(),
+		initialize.GetCmd(),
+		backup.GetCmd(),
+		restore.GetCmd(),
+		kamailio.GetCmd(),
+		pbx.GetCmd(),
+		numbers.GetCmd(),
+		probe.GetCmd(),
+		firewall.GetCmd(),
+		capture.GetCmd(),
+		calls.GetCmd(),
 	)
 
+	rootCmd.PersistentFlags().StringP(flagnames.Output, "o", output.Table,
+		"output format of the read-only commands: table, json, yaml or csv")
+
+	rootCmd.PersistentFlags().String(flagnames.DBFileLocation, "",
+		fmt.Sprintf("sqlite file location, file name must end with .db (default: %s)", db.DefaultDBLocation()))
+	rootCmd.PersistentFlags().String(flagnames.LogLevel, "info", "log output level")
+	rootCmd.PersistentFlags().String(flagnames.LogFileLocation, "", "log file location")
+	rootCmd.PersistentFlags().String(flagnames.GlobalConfig, "",
+		fmt.Sprintf("global configuration file with flag defaults for every command (default: %s)",
+			config.DefaultGlobalFile()))
+
+	// bind flags to viper
+	if err := viper.BindPFlags(rootCmd.PersistentFlags()); err != nil {
+		log.Fatalln("Could not bind persistent flags err:", err.Error())
+	}
+
 	err := rootCmd.Execute()
 	if err != nil {
 		log.Fatalln(fmt.Sprintf("Could not execute command err=%s", err.Error()))
 	}
 }
+
+// loadConfig sets the flags, that are not set on the command line, first from the flag values file
+// of the command, if it has one, and then from the global configuration file and TSBC_* environment variables
+func loadConfig(cmd *cobra.Command, _ []string) {
+	if err := config.LoadGlobal(); err != nil {
+		log.Fatalln("Could not load global config err=", err.Error())
+	}
+
+	if configFlag := cmd.Flags().Lookup(flagnames.Config); configFlag != nil && configFlag.Value.String() != "" {
+		if err := config.ApplyToFlags(cmd, configFlag.Value.String()); err != nil {
+			log.Fatalln("Could not apply config file err=", err.Error())
+		}
+	}
+
+	if err := config.ApplyDefaults(cmd); err != nil {
+		log.Fatalln("Could not apply global config err=", err.Error())
+	}
+}
@@ -1,11 +1,11 @@
 package run
 
 import (
-	"fmt"
 	"log"
 
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/dryrun"
 	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
-	"github.com/ZeljkoBenovic/tsbc/db"
 	"github.com/ZeljkoBenovic/tsbc/sbc"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -17,20 +17,22 @@ var runCmd = &cobra.Command{
 	Short: "Command used to deploy a new SBC cluster",
 	Run:   runCommandHandler,
 	Example: "tsbc run --kamailio-pbx-ip 192.168.1.1 " +
-		"--sbc-fqdn sbc.test1.com --rtp-public-ip 1.1.1.1  --host-ip 192.168.10.1",
+		"--sbc-fqdn sbc.test1.com --rtp-public-ip 1.1.1.1  --host-ip 192.168.10.1\n" +
+		"tsbc run --config ~/.tsbc/run.yaml\n" +
+		"tsbc run --kamailio-pbx-ip 192.168.1.1 " +
+		"--sbc-fqdn sbc.test1.com --rtp-public-ip 1.1.1.1  --host-ip 192.168.10.1 --dry-run",
 }
 
 func GetCmd() *cobra.Command {
 	// general flags
 	runCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn that Kamailio will advertise")
 	runCmd.Flags().String(flagnames.HostIP, "", "the static lan ip address of the docker host")
-	runCmd.Flags().String(flagnames.LogLevel, "info", "log output level")
-	runCmd.Flags().String(flagnames.LogFileLocation, "", "log file location")
+	runCmd.Flags().String(flagnames.Config, "", "YAML file with flag values, as written by tsbc init")
+	runCmd.Flags().Bool(flagnames.DryRun, false,
+		"print the planned ports, containers and certificate without making changes")
 	runCmd.Flags().String(flagnames.DockerLogFileLocation, "/var/log/tsbc/docker.log", "docker log file location")
-	runCmd.Flags().String(flagnames.DBFileLocation, "",
-		fmt.Sprintf("sqlite file location, file name must end with .db (default: %s)", db.DefaultDBLocation()))
 	// kamailio flags
-	runCmd.Flags().Bool(flagnames.KamailioNewConfig, true, "generate new config file for Kamailio")
+	runCmd.Flags().Bool(flagnames.KamailioNewConfig, true, "render Kamailio config from the tsbc templates")
 	runCmd.Flags().Bool(flagnames.KamailioSIPDump, false, "enable sip capture for Kamailio")
 	runCmd.Flags().String(flagnames.KamailioSbcPort, "5061", "sbc tls port that will be advertised to MS Teams")
 	runCmd.Flags().String(flagnames.KamailioUDPSIPPort, "5060", "sbc udp port that will be advertised to internal PBX")
@@ -46,13 +48,18 @@ func GetCmd() *cobra.Command {
 	runCmd.Flags().String(flagnames.RTPImage, "zeljkoiphouse/rtpengine:latest", "rtp engine docker image name")
 	// letsencrypt flags
 	runCmd.Flags().String(flagnames.Timezone, "Europe/Belgrade", "set the timezone")
-	runCmd.Flags().String(flagnames.Staging, "false", "set staging environment for LetsEncrypt node")
+	runCmd.Flags().Bool(flagnames.Staging, false, "use LetsEncrypt staging environment")
+	runCmd.Flags().Bool(flagnames.IgnoreRateLimit, false,
+		"issue certificates even if LetsEncrypt rate limits would be exceeded")
 
 	_ = runCmd.MarkFlagRequired(flagnames.SbcFqdn)
 	_ = runCmd.MarkFlagRequired(flagnames.RTPPublicIP)
 	_ = runCmd.MarkFlagRequired(flagnames.KamailioPbxIP)
 	_ = runCmd.MarkFlagRequired(flagnames.HostIP)
 
+	_ = runCmd.RegisterFlagCompletionFunc(flagnames.KamailioImage, completion.Images)
+	_ = runCmd.RegisterFlagCompletionFunc(flagnames.RTPImage, completion.Images)
+
 	// bind flags to viper
 	if err := viper.BindPFlags(runCmd.Flags()); err != nil {
 		log.Fatalln("Could not bind to flags err=", err.Error())
@@ -62,13 +69,33 @@ func GetCmd() *cobra.Command {
 }
 
 func runCommandHandler(cmd *cobra.Command, args []string) {
+	newSBC := sbc.NewSBC
+
+	// the dry-run plan must not create the database or the log directories
+	if viper.GetBool(flagnames.DryRun) {
+		newSBC = sbc.NewPlanSBC
+	}
+
 	// create new sbc instance and pass parameters
-	sbcInstance, err := sbc.NewSBC()
+	sbcInstance, err := newSBC()
 	if err != nil {
 		log.Fatalln("Could not create sbc instance err=", err.Error())
 	}
 
 	defer sbcInstance.Close()
 
+	if viper.GetBool(flagnames.DryRun) {
+		plan, err := sbcInstance.PlanRun()
+		if err != nil {
+			log.Fatalln("Could not plan sbc deployment err=", err.Error())
+		}
+
+		if err = dryrun.Print(plan); err != nil {
+			log.Fatalln("Could not print plan err=", err.Error())
+		}
+
+		return
+	}
+
 	sbcInstance.Run()
 }
@@ -0,0 +1,123 @@
+package status
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/output"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/fatih/color"
+	"github.com/hashicorp/go-hclog"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show live container health of the deployed SBCs",
+	Long: "Show live container health of the deployed SBCs and the certificates node. " +
+		"The command exits with a non-zero code if any container is unhealthy.",
+	Example: "tsbc status\n" +
+		"tsbc status --sbc-fqdn sbc.test.com\n" +
+		"tsbc status --output json",
+	Run: statusCommandHandler,
+}
+
+func GetCmd() *cobra.Command {
+	statusCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster, all sbcs are shown if not set")
+
+	_ = statusCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
+	// bind flags to viper
+	if err := viper.BindPFlag("status.fqdn", statusCmd.Flag(flagnames.SbcFqdn)); err != nil {
+		log.Fatalln("Could not bind status.fqdn err:", err.Error())
+	}
+
+	return statusCmd
+}
+
+func statusCommandHandler(_ *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "status",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	outputFormat := viper.GetString(flagnames.Output)
+	if err := output.Validate(outputFormat); err != nil {
+		lg.Error("Invalid output format", "err", err)
+		os.Exit(1)
+	}
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	statuses, err := sbcInst.Status(viper.GetString("status.fqdn"))
+
+	sbcInst.Close()
+
+	if err != nil {
+		lg.Error("Could not get SBC status", "err", err)
+		os.Exit(1)
+	}
+
+	if outputFormat == output.Table {
+		displayStatus(statuses)
+	} else if err = output.Write(os.Stdout, outputFormat, statuses); err != nil {
+		lg.Error("Could not write SBC status", "format", outputFormat, "err", err)
+		os.Exit(1)
+	}
+
+	// non-zero exit code lets cron jobs and monitoring detect unhealthy containers
+	for _, status := range statuses {
+		if !status.Healthy {
+			os.Exit(1)
+		}
+	}
+}
+
+func displayStatus(statuses []types.ContainerStatus) {
+	var buff bytes.Buffer
+
+	if len(statuses) == 0 {
+		buff.WriteString("===========================\n")
+		buff.WriteString("==NO SBCs INSTANCES FOUND==\n")
+		buff.WriteString("===========================\n")
+
+		fmt.Print(buff.String())
+
+		return
+	}
+
+	headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+	columnFmt := color.New(color.FgYellow).SprintfFunc()
+	unhealthyFmt := color.New(color.FgRed).SprintFunc()
+
+	tbl := table.New("FQDN", "COMPONENT", "STATE", "UPTIME", "RESTARTS",
+		"EXIT_CODE", "OOM_KILLED", "IMAGE_DIGEST", "HEALTHY")
+	tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+
+	for _, status := range statuses {
+		healthy := fmt.Sprint(status.Healthy)
+		if !status.Healthy {
+			healthy = unhealthyFmt(healthy)
+		}
+
+		tbl.AddRow(status.Fqdn, status.Component, status.State,
+			time.Duration(status.UptimeSeconds)*time.Second, status.RestartCount,
+			status.ExitCode, status.OOMKilled, status.ImageDigest, healthy)
+	}
+
+	tbl.Print()
+}
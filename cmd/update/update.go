@@ -0,0 +1,96 @@
+package update
+
+import (
+	"log"
+	"os"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/completion"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/sharedflags"
+	"github.com/ZeljkoBenovic/tsbc/sbc"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var updateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update parameters of an existing SBC in place",
+	Long: "Update the PBX address, public media ip or sip dump setting of an existing SBC. " +
+		"Only the containers using the changed parameters are recreated, the ports and the certificate are kept.",
+	Example: "tsbc update --sbc-fqdn sbc.test.com --kamailio-pbx-ip 192.168.1.2\n" +
+		"tsbc update --sbc-fqdn sbc.test.com --rtp-public-ip 2.2.2.2",
+	PreRun: sharedflags.Bind(flagnames.HostIP),
+	Run:    updateCommandHandler,
+}
+
+func GetCmd() *cobra.Command {
+	updateCmd.Flags().String(flagnames.SbcFqdn, "", "fqdn of the sbc cluster to update")
+	updateCmd.Flags().String(flagnames.KamailioPbxIP, "", "ip address of internal PBX")
+	updateCmd.Flags().String(flagnames.KamailioPbxPort, "", "sip port of internal PBX")
+	updateCmd.Flags().String(flagnames.RTPPublicIP, "", "public ip for RTP transport")
+	updateCmd.Flags().Bool(flagnames.KamailioSIPDump, false, "enable sip capture for Kamailio")
+	updateCmd.Flags().String(flagnames.HostIP, "",
+		"the static lan ip address of the docker host, taken from the existing Kamailio container if not set")
+
+	_ = updateCmd.MarkFlagRequired(flagnames.SbcFqdn)
+
+	_ = updateCmd.RegisterFlagCompletionFunc(flagnames.SbcFqdn, completion.SbcFqdns)
+
+	// bind flags to viper
+	for key, flagName := range map[string]string{
+		"update.fqdn":      flagnames.SbcFqdn,
+		"update.pbx-ip":    flagnames.KamailioPbxIP,
+		"update.pbx-port":  flagnames.KamailioPbxPort,
+		"update.public-ip": flagnames.RTPPublicIP,
+		"update.sip-dump":  flagnames.KamailioSIPDump,
+	} {
+		if err := viper.BindPFlag(key, updateCmd.Flag(flagName)); err != nil {
+			log.Fatalln("Could not bind", key, "err:", err.Error())
+		}
+	}
+
+	return updateCmd
+}
+
+func updateCommandHandler(cmd *cobra.Command, _ []string) {
+	lg := hclog.New(&hclog.LoggerOptions{
+		Name:                 "update",
+		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
+		Color:                hclog.AutoColor,
+		ColorHeaderAndFields: true,
+	})
+
+	sbcUpdate := types.SbcUpdate{
+		PbxIP:         viper.GetString("update.pbx-ip"),
+		PbxPort:       viper.GetString("update.pbx-port"),
+		MediaPublicIP: viper.GetString("update.public-ip"),
+	}
+
+	// sip dump is changed only if the flag is set, as false is a valid value
+	if cmd.Flags().Changed(flagnames.KamailioSIPDump) {
+		enableSIPDump := viper.GetBool("update.sip-dump")
+		sbcUpdate.EnableSIPDump = &enableSIPDump
+	}
+
+	if sbcUpdate == (types.SbcUpdate{}) {
+		lg.Error("Nothing to update, set at least one of the parameters to change")
+		os.Exit(1)
+	}
+
+	sbcInst, err := sbc.NewSBC()
+	if err != nil {
+		lg.Error("Could not create new sbc instance", "err", err)
+		os.Exit(1)
+	}
+
+	err = sbcInst.Update(viper.GetString("update.fqdn"), sbcUpdate)
+
+	sbcInst.Close()
+
+	if err != nil {
+		lg.Error("Could not update sbc", "fqdn", viper.GetString("update.fqdn"), "err", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,15 @@
+package db
+
+import "fmt"
+
+// Snapshot writes a consistent copy of the live database to a new file,
+// other tsbc commands can keep using the database while the copy is made
+func (d *db) Snapshot(fileName string) error {
+	if _, err := d.db.Exec("VACUUM INTO ?", fileName); err != nil {
+		return fmt.Errorf("could not write database snapshot: %w", err)
+	}
+
+	d.log.Debug("Database snapshot written", "file", fileName)
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+)
+
+func (d *db) SaveCaptureRetention(sbcFqdn string, retention types.CaptureRetention) error {
+	stmt, err := d.db.Prepare("INSERT OR REPLACE INTO capture_retention" +
+		"(fqdn, max_size_mb, max_age) " +
+		"VALUES (?,?,?);")
+	if err != nil {
+		return fmt.Errorf("could not prepare insert statement err=%w", err)
+	}
+
+	defer stmt.Close()
+
+	// the age is stored in seconds
+	if _, err = stmt.Exec(sbcFqdn, retention.MaxSizeMB, int64(retention.MaxAge/time.Second)); err != nil {
+		return fmt.Errorf("could not execute insert statement err=%w", err)
+	}
+
+	d.log.Debug("Capture retention saved", "fqdn", sbcFqdn)
+
+	return nil
+}
+
+// GetCaptureRetention returns the capture retention of the sbc, the values are 0 if the sbc has no stored retention
+func (d *db) GetCaptureRetention(sbcFqdn string) (types.CaptureRetention, error) {
+	var (
+		retention types.CaptureRetention
+		maxAge    int64
+	)
+
+	err := d.db.QueryRowContext(
+		context.Background(),
+		"SELECT max_size_mb, max_age FROM capture_retention WHERE fqdn = ?", sbcFqdn).
+		Scan(&retention.MaxSizeMB, &maxAge)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		d.log.Debug("No capture retention stored", "fqdn", sbcFqdn)
+	case err != nil:
+		return retention, fmt.Errorf("could not get capture retention: %w", err)
+	}
+
+	retention.MaxAge = time.Duration(maxAge) * time.Second
+
+	return retention, nil
+}
@@ -0,0 +1,161 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+)
+
+// GetLastCertSerial returns the serial of the last certificate seen for the fqdn,
+// or an empty string if the certificate was never recorded
+func (d *db) GetLastCertSerial(sbcFqdn string) (string, error) {
+	var serial = new(string)
+
+	err := d.db.QueryRowContext(
+		context.Background(),
+		"SELECT serial FROM cert_renewals WHERE fqdn = ? ORDER BY id DESC LIMIT 1", sbcFqdn).
+		Scan(serial)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		d.log.Debug("No certificate renewals recorded", "fqdn", sbcFqdn)
+	case err != nil:
+		return "", fmt.Errorf("could not get last certificate serial: %w", err)
+	}
+
+	return *serial, nil
+}
+
+func (d *db) SaveCertRenewal(renewal types.CertRenewal) error {
+	stmt, err := d.db.Prepare("INSERT INTO cert_renewals" +
+		"(fqdn, serial, not_after, detected, reload_method, reload_result) " +
+		"VALUES (?,?,?,?,?,?);")
+	if err != nil {
+		return fmt.Errorf("could not prepare insert statement err=%w", err)
+	}
+
+	if _, err = stmt.Exec(
+		renewal.Fqdn,
+		renewal.Serial,
+		renewal.NotAfter,
+		renewal.Detected,
+		renewal.ReloadMethod,
+		renewal.ReloadResult,
+	); err != nil {
+		return fmt.Errorf("could not execute insert statement err=%w", err)
+	}
+
+	d.log.Debug("Certificate renewal saved", "fqdn", renewal.Fqdn, "serial", renewal.Serial)
+
+	return nil
+}
+
+func (d *db) SaveWildcardDomain(domain, dnsPlugin string) error {
+	stmt, err := d.db.Prepare("INSERT OR REPLACE INTO wildcard_certs(domain, dns_plugin, created) " +
+		"VALUES (?,?,datetime());")
+	if err != nil {
+		return fmt.Errorf("could not prepare insert statement err=%w", err)
+	}
+
+	if _, err = stmt.Exec(domain, dnsPlugin); err != nil {
+		return fmt.Errorf("could not execute insert statement err=%w", err)
+	}
+
+	d.log.Debug("Wildcard domain saved", "domain", domain)
+
+	return nil
+}
+
+func (d *db) GetWildcardDomains() ([]string, error) {
+	rows, err := d.db.QueryContext(context.Background(), "SELECT domain FROM wildcard_certs")
+	if err != nil {
+		return nil, fmt.Errorf("could not get wildcard domains from database: %w", err)
+	}
+
+	defer rows.Close()
+
+	resp := make([]string, 0)
+	domain := ""
+
+	for rows.Next() {
+		if err = rows.Scan(&domain); err != nil {
+			d.log.Error("Could not scan wildcard domain from database", "err", err)
+		}
+
+		resp = append(resp, domain)
+	}
+
+	return resp, nil
+}
+
+func (d *db) SaveACMEIssuance(issuance types.ACMEIssuance) error {
+	var staging int
+
+	// translate bool to int
+	if issuance.Staging {
+		staging = 1
+	}
+
+	stmt, err := d.db.Prepare("INSERT INTO acme_issuances" +
+		"(cert_name, domains, registered_domain, staging, issued) " +
+		"VALUES (?,?,?,?,datetime());")
+	if err != nil {
+		return fmt.Errorf("could not prepare insert statement err=%w", err)
+	}
+
+	if _, err = stmt.Exec(
+		issuance.CertName,
+		joinDomains(issuance.Domains),
+		issuance.RegisteredDomain,
+		staging,
+	); err != nil {
+		return fmt.Errorf("could not execute insert statement err=%w", err)
+	}
+
+	d.log.Debug("ACME issuance saved", "cert_name", issuance.CertName, "staging", issuance.Staging)
+
+	return nil
+}
+
+// CountProductionIssuances returns the number of production certificates issued within the window
+// for exactly the same set of domains
+func (d *db) CountProductionIssuances(domains []string, window time.Duration) (int, error) {
+	return d.countProductionIssuances("domains", joinDomains(domains), window)
+}
+
+// CountProductionIssuancesForRegisteredDomain returns the number of production certificates issued
+// within the window under the registered domain
+func (d *db) CountProductionIssuancesForRegisteredDomain(registeredDomain string, window time.Duration) (int, error) {
+	return d.countProductionIssuances("registered_domain", registeredDomain, window)
+}
+
+func (d *db) countProductionIssuances(columnName, value string, window time.Duration) (int, error) {
+	var count int
+
+	if err := d.db.QueryRowContext(
+		context.Background(),
+		fmt.Sprintf("SELECT count(*) FROM acme_issuances "+
+			"WHERE %s = ? AND staging = 0 AND issued >= datetime('now', ?)", columnName),
+		value,
+		fmt.Sprintf("-%d seconds", int64(window.Seconds()))).
+		Scan(&count); err != nil {
+		return 0, fmt.Errorf("could not count acme issuances: %w", err)
+	}
+
+	return count, nil
+}
+
+// joinDomains returns the domains in a stable order, so the same set always matches
+func joinDomains(domains []string) string {
+	sorted := make([]string, len(domains))
+	copy(sorted, domains)
+	sort.Strings(sorted)
+
+	return strings.Join(sorted, ",")
+}
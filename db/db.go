@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
 	"github.com/ZeljkoBenovic/tsbc/sbc/types"
@@ -18,6 +19,9 @@ type IDB interface {
 	CreateFreshDB() error
 
 	SaveSBCInformation() (int64, error)
+	UpdateSBCInformation(sbcFqdn string, sbcData types.Sbc) error
+	NextSBCParameters() (types.Sbc, error)
+	SchemaExists() (bool, error)
 	SaveContainerID(rowID int64, tableName, id string) error
 
 	GetSBCParameters(sbcID int64) (types.Sbc, error)
@@ -28,6 +32,32 @@ type IDB interface {
 	GetAllFqdnNames() ([]string, error)
 	GetLetsEncryptNodeID() (string, error)
 
+	GetLastCertSerial(sbcFqdn string) (string, error)
+	SaveCertRenewal(renewal types.CertRenewal) error
+	SaveWildcardDomain(domain, dnsPlugin string) error
+	GetWildcardDomains() ([]string, error)
+	SaveACMEIssuance(issuance types.ACMEIssuance) error
+	CountProductionIssuances(domains []string, window time.Duration) (int, error)
+	CountProductionIssuancesForRegisteredDomain(registeredDomain string, window time.Duration) (int, error)
+
+	AddPbxTarget(target types.PbxTarget) error
+	RemovePbxTarget(target types.PbxTarget) error
+	GetPbxTargets(sbcFqdn string) ([]types.PbxTarget, error)
+	SavePbxCredentials(creds types.PbxCredentials) error
+	GetPbxCredentials(sbcFqdn string) (types.PbxCredentials, error)
+	RemovePbxCredentials(sbcFqdn string) error
+	SavePbxTransport(transport types.PbxTransport) error
+	GetPbxTransport(sbcFqdn string) (types.PbxTransport, error)
+	AddNumberRule(rule types.NumberRule) (int64, error)
+	RemoveNumberRule(sbcFqdn string, ruleID int64) error
+	GetNumberRules(sbcFqdn string) ([]types.NumberRule, error)
+	SaveCaptureRetention(sbcFqdn string, retention types.CaptureRetention) error
+	GetCaptureRetention(sbcFqdn string) (types.CaptureRetention, error)
+	GetTeamsPrefixes() ([]types.TeamsPrefix, error)
+	ReplaceTeamsPrefixes(prefixes []types.TeamsPrefix) error
+
+	Snapshot(fileName string) error
+
 	RevertLastInsert()
 	RemoveSbcInfo(sbcFqdn string) error
 	RemoveLetsEncryptInfo(nodeID string) error
@@ -199,11 +229,21 @@ func (d *db) RemoveSbcInfo(sbcFqdn string) error {
 	d.deleteRowWithID("kamailio", kamID)
 	d.deleteRowWithID("rtp_engine", rtpID)
 
+	for _, tableName := range []string{
+		"pbx_targets", "pbx_credentials", "pbx_transport", "number_rules", "capture_retention",
+	} {
+		if err = d.removeSbcRows(tableName, sbcFqdn); err != nil {
+			return err
+		}
+	}
+
 	d.log.Debug("Deleted sbc information from database", "sbc_fqdn", sbcFqdn)
 
 	return nil
 }
 
+// GetContainerIDsFromSbcFqdn returns the kamailio and the rtp engine container ids of the sbc, in this order,
+// or nil if the sbc is not found
 func (d *db) GetContainerIDsFromSbcFqdn(sbcFqdn string) []string {
 	stmt, err := d.db.Prepare(
 		"SELECT k.container_id, r.container_id " +
@@ -285,6 +325,74 @@ func (d *db) SaveSBCInformation() (int64, error) {
 	return d.insertID.sbcInstance, nil
 }
 
+// UpdateSBCInformation overwrites the stored configuration of an existing sbc
+func (d *db) UpdateSBCInformation(sbcFqdn string, sbcData types.Sbc) error {
+	var enableSIPDump int
+
+	if sbcData.EnableSIPDump {
+		enableSIPDump = 1
+	}
+
+	if _, err := d.db.Exec(
+		"UPDATE kamailio SET enable_sipdump = ?, pbx_ip = ?, pbx_port = ?, rtp_engine_port = ?, "+
+			"sbc_tls_port = ?, sbc_udp_port = ? "+
+			"WHERE id = (SELECT kamailio_id FROM sbc_info WHERE fqdn = ?)",
+		enableSIPDump,
+		sbcData.PbxIP,
+		sbcData.PbxPort,
+		sbcData.RTPEnginePort,
+		sbcData.SbcTLSPort,
+		sbcData.SbcUDPPort,
+		sbcFqdn,
+	); err != nil {
+		return fmt.Errorf("could not update kamailio data: %w", err)
+	}
+
+	if _, err := d.db.Exec(
+		"UPDATE rtp_engine SET rtp_max = ?, rtp_min = ?, media_public_ip = ?, ng_listen = ? "+
+			"WHERE id = (SELECT rtp_engine_id FROM sbc_info WHERE fqdn = ?)",
+		sbcData.RTPMaxPort,
+		sbcData.RTPMinPort,
+		sbcData.MediaPublicIP,
+		sbcData.NgListen,
+		sbcFqdn,
+	); err != nil {
+		return fmt.Errorf("could not update rtp engine data: %w", err)
+	}
+
+	d.log.Debug("SBC configuration updated", "fqdn", sbcFqdn)
+
+	return nil
+}
+
+// NextSBCParameters returns the sbc configuration that SaveSBCInformation would store, without storing it
+func (d *db) NextSBCParameters() (types.Sbc, error) {
+	if err := checkForRequiredFlags(); err != nil {
+		return types.Sbc{}, err
+	}
+
+	kamailioData, err := d.nextKamailioData()
+	if err != nil {
+		return types.Sbc{}, fmt.Errorf("could not get kamailio data: %w", err)
+	}
+
+	rtpEngineData, err := d.nextRTPEngineData()
+	if err != nil {
+		return types.Sbc{}, fmt.Errorf("could not get rtp engine data: %w", err)
+	}
+
+	return types.Sbc{
+		Fqdn:      viper.GetString(flagnames.SbcFqdn),
+		Kamailio:  kamailioData,
+		RTPEngine: rtpEngineData,
+	}, nil
+}
+
+// SchemaExists returns true if the database schema is already created
+func (d *db) SchemaExists() (bool, error) {
+	return d.checkIfTableExists("sbc_info")
+}
+
 func (d *db) storeSbcInfo() error {
 	stmt, err := d.db.Prepare("INSERT INTO sbc_info(fqdn, kamailio_id, rtp_engine_id, created) " +
 		"VALUES(?,?,?,datetime());")
@@ -330,7 +438,8 @@ func (d *db) GetSBCParameters(sbcID int64) (types.Sbc, error) {
 		"SELECT " +
 			"fqdn, sbc_name, sbc_tls_port, sbc_udp_port, " +
 			"pbx_ip, pbx_port, rtp_engine_port, rtp_max, " +
-			"rtp_min, media_public_ip, ng_listen, new_config, enable_sipdump " +
+			"rtp_min, media_public_ip, ng_listen, new_config, enable_sipdump, " +
+			"coalesce(k.container_id, ''), coalesce(re.container_id, ''), created " +
 			"FROM sbc_info " +
 			"JOIN kamailio k ON k.id = sbc_info.kamailio_id " +
 			"JOIN rtp_engine re ON re.id = sbc_info.rtp_engine_id " +
@@ -361,6 +470,9 @@ func (d *db) GetSBCParameters(sbcID int64) (types.Sbc, error) {
 			&sbcResult.NgListen,
 			&sbcResult.NewConfig,
 			&sbcResult.EnableSIPDump,
+			&sbcResult.KamailioContainerID,
+			&sbcResult.RTPEngineContainerID,
+			&sbcResult.Created,
 		); err != nil {
 			return types.Sbc{}, fmt.Errorf("could not scan data into struct err=%w", err)
 		}
@@ -372,32 +484,11 @@ func (d *db) GetSBCParameters(sbcID int64) (types.Sbc, error) {
 }
 
 func (d *db) storeRTPEngineData() error {
-	// select the last record in the table
-	rows, err := d.db.Query("SELECT * FROM rtp_engine ORDER BY id DESC LIMIT 1;")
+	rtpEngineData, err := d.nextRTPEngineData()
 	if err != nil {
 		return err
 	}
 
-	var (
-		rtpMaxPort, rtpMinPort, rtpSignalPort string
-		rtpPubIP                              = viper.GetString(flagnames.RTPPublicIP)
-	)
-
-	// check if the table is empty
-	if !rows.Next() {
-		d.log.Debug("Rtp engine table is empty, using default first data")
-
-		rtpMaxPort = viper.GetString(flagnames.RTPMaxPort)
-		rtpMinPort = viper.GetString(flagnames.RTPMinPort)
-		rtpSignalPort = viper.GetString(flagnames.RTPSignalPort)
-	} else {
-		rtpSignalPort = d.getSingleRecordAndIncreaseByValue(1, "ng_listen", "rtp_engine")
-		rtpMinPort = d.getSingleRecordAndIncreaseByValue(500, "rtp_min", "rtp_engine")
-		rtpMaxPort = d.getSingleRecordAndIncreaseByValue(500, "rtp_max", "rtp_engine")
-	}
-
-	_ = rows.Close()
-
 	stmt, err := d.db.Prepare("INSERT INTO rtp_engine(rtp_max, rtp_min, media_public_ip, ng_listen) " +
 		"VALUES (?,?,?,?);")
 	if err != nil {
@@ -405,10 +496,10 @@ func (d *db) storeRTPEngineData() error {
 	}
 
 	res, err := stmt.Exec(
-		rtpMaxPort,
-		rtpMinPort,
-		rtpPubIP,
-		rtpSignalPort,
+		rtpEngineData.RTPMaxPort,
+		rtpEngineData.RTPMinPort,
+		rtpEngineData.MediaPublicIP,
+		rtpEngineData.NgListen,
 	)
 	if err != nil {
 		return fmt.Errorf("could not execute prepared statement err=%w", err)
@@ -424,51 +515,42 @@ func (d *db) storeRTPEngineData() error {
 	return nil
 }
 
-func (d *db) storeKamailioData() error {
-	// get Kamailio values from flags
-	var (
-		newConfig                       int
-		enableSIPDump                   int
-		sbcPort, udpSIPPort, rtpEngPort string
-		// user must always set these values and/or they don't have to be unique
-		pbxIP   = viper.GetString(flagnames.KamailioPbxIP)
-		pbxPort = viper.GetString(flagnames.KamailioPbxPort)
-		sbcFqdn = viper.GetString(flagnames.SbcFqdn)
-	)
-
-	// translate bool to int
-	if viper.GetBool(flagnames.KamailioNewConfig) {
-		newConfig = 1
+// nextRTPEngineData returns the rtp engine values from flags, with the next free ports
+func (d *db) nextRTPEngineData() (types.RTPEngine, error) {
+	// check if the table is empty
+	tableEmpty, err := d.tableEmpty("rtp_engine")
+	if err != nil {
+		return types.RTPEngine{}, err
 	}
 
-	if viper.GetBool(flagnames.KamailioSIPDump) {
-		enableSIPDump = 1
+	if tableEmpty {
+		d.log.Debug("Rtp engine table is empty, using default first data")
 	}
 
-	// select the last record in the table
-	qRes, err := d.db.Query("SELECT * FROM kamailio ORDER BY sbc_name DESC LIMIT 1;")
+	return types.RTPEngine{
+		RTPMaxPort:    d.portValue(flagnames.RTPMaxPort, tableEmpty, 500, "rtp_max", "rtp_engine"),
+		RTPMinPort:    d.portValue(flagnames.RTPMinPort, tableEmpty, 500, "rtp_min", "rtp_engine"),
+		MediaPublicIP: viper.GetString(flagnames.RTPPublicIP),
+		NgListen:      d.portValue(flagnames.RTPSignalPort, tableEmpty, 1, "ng_listen", "rtp_engine"),
+	}, nil
+}
+
+func (d *db) storeKamailioData() error {
+	kamailioData, err := d.nextKamailioData()
 	if err != nil {
 		return err
 	}
 
-	// check if the table is empty
-	if !qRes.Next() {
-		d.log.Debug("Kamailio table is empty, using default first data")
+	var newConfig, enableSIPDump int
 
-		// if there are no records in the table, default values will be used
-		rtpEngPort = viper.GetString(flagnames.KamailioRTPEngPort)
-		sbcPort = viper.GetString(flagnames.KamailioSbcPort)
-		udpSIPPort = viper.GetString(flagnames.KamailioUDPSIPPort)
-	} else {
-		d.log.Debug("Existing Kamailio data found, calculating next values")
-
-		// if there are some records, next values will be calculated
-		rtpEngPort = d.getSingleRecordAndIncreaseByValue(1, "rtp_engine_port", "kamailio")
-		udpSIPPort = d.getSingleRecordAndIncreaseByValue(1, "sbc_udp_port", "kamailio")
-		sbcPort = d.getSingleRecordAndIncreaseByValue(1, "sbc_tls_port", "kamailio")
+	// translate bool to int
+	if kamailioData.NewConfig {
+		newConfig = 1
 	}
 
-	_ = qRes.Close()
+	if kamailioData.EnableSIPDump {
+		enableSIPDump = 1
+	}
 
 	// prepare statement
 	stmt, err := d.db.Prepare(
@@ -483,12 +565,12 @@ func (d *db) storeKamailioData() error {
 	res, err := stmt.Exec(
 		newConfig,
 		enableSIPDump,
-		pbxIP,
-		pbxPort,
-		rtpEngPort,
-		sbcFqdn,
-		sbcPort,
-		udpSIPPort,
+		kamailioData.PbxIP,
+		kamailioData.PbxPort,
+		kamailioData.RTPEnginePort,
+		kamailioData.SbcName,
+		kamailioData.SbcTLSPort,
+		kamailioData.SbcUDPPort,
 	)
 	if err != nil {
 		return fmt.Errorf("could not execute insert statement err=%w", err)
@@ -504,3 +586,31 @@ func (d *db) storeKamailioData() error {
 
 	return nil
 }
+
+// nextKamailioData returns the Kamailio values from flags, with the next free ports
+func (d *db) nextKamailioData() (types.Kamailio, error) {
+	// check if the table is empty
+	tableEmpty, err := d.tableEmpty("kamailio")
+	if err != nil {
+		return types.Kamailio{}, err
+	}
+
+	if tableEmpty {
+		d.log.Debug("Kamailio table is empty, using default first data")
+	} else {
+		d.log.Debug("Existing Kamailio data found, calculating next values")
+	}
+
+	// if there are no records in the table, default values will be used, otherwise next values will be calculated
+	return types.Kamailio{
+		NewConfig:     viper.GetBool(flagnames.KamailioNewConfig),
+		EnableSIPDump: viper.GetBool(flagnames.KamailioSIPDump),
+		// user must always set these values and/or they don't have to be unique
+		SbcName:       viper.GetString(flagnames.SbcFqdn),
+		PbxIP:         viper.GetString(flagnames.KamailioPbxIP),
+		PbxPort:       viper.GetString(flagnames.KamailioPbxPort),
+		SbcTLSPort:    d.portValue(flagnames.KamailioSbcPort, tableEmpty, 1, "sbc_tls_port", "kamailio"),
+		SbcUDPPort:    d.portValue(flagnames.KamailioUDPSIPPort, tableEmpty, 1, "sbc_udp_port", "kamailio"),
+		RTPEnginePort: d.portValue(flagnames.KamailioRTPEngPort, tableEmpty, 1, "rtp_engine_port", "kamailio"),
+	}, nil
+}
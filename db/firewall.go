@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+)
+
+// GetTeamsPrefixes returns the stored MS Teams prefixes, or an empty list if the built-in ones are used
+func (d *db) GetTeamsPrefixes() ([]types.TeamsPrefix, error) {
+	rows, err := d.db.QueryContext(
+		context.Background(), "SELECT prefix, kind FROM teams_prefixes ORDER BY kind DESC, id")
+	if err != nil {
+		return nil, fmt.Errorf("could not get teams prefixes from database: %w", err)
+	}
+
+	defer rows.Close()
+
+	resp := make([]types.TeamsPrefix, 0)
+
+	for rows.Next() {
+		prefix := types.TeamsPrefix{}
+
+		if err = rows.Scan(&prefix.Prefix, &prefix.Kind); err != nil {
+			return nil, fmt.Errorf("could not scan teams prefix: %w", err)
+		}
+
+		resp = append(resp, prefix)
+	}
+
+	return resp, rows.Err()
+}
+
+// ReplaceTeamsPrefixes replaces all the stored MS Teams prefixes in a single transaction
+func (d *db) ReplaceTeamsPrefixes(prefixes []types.TeamsPrefix) error {
+	tx, err := d.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	// the rollback is a no-op after the commit
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err = tx.Exec("DELETE FROM teams_prefixes"); err != nil {
+		return fmt.Errorf("could not delete teams prefixes: %w", err)
+	}
+
+	stmt, err := tx.Prepare("INSERT OR IGNORE INTO teams_prefixes(prefix, kind) VALUES (?,?);")
+	if err != nil {
+		return fmt.Errorf("could not prepare insert statement err=%w", err)
+	}
+
+	defer stmt.Close()
+
+	for _, prefix := range prefixes {
+		if _, err = stmt.Exec(prefix.Prefix, prefix.Kind); err != nil {
+			return fmt.Errorf("could not execute insert statement err=%w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit teams prefixes: %w", err)
+	}
+
+	d.log.Debug("Teams prefixes saved", "count", len(prefixes))
+
+	return nil
+}
@@ -48,6 +48,32 @@ func (d *db) getSingleRecordAndIncreaseByValue(increaseValue int, columnName, ta
 	return strconv.Itoa(colInt + increaseValue)
 }
 
+// portValue returns the explicitly set port, the default port for the first record,
+// or the last used port increased by the value
+func (d *db) portValue(flagName string, tableEmpty bool, increaseValue int, columnName, tableName string) string {
+	if tableEmpty || viper.IsSet(flagName) {
+		return viper.GetString(flagName)
+	}
+
+	return d.getSingleRecordAndIncreaseByValue(increaseValue, columnName, tableName)
+}
+
+// tableEmpty returns true if the table has no records, or does not exist yet
+func (d *db) tableEmpty(tableName string) (bool, error) {
+	tableExists, err := d.checkIfTableExists(tableName)
+	if err != nil || !tableExists {
+		return true, err
+	}
+
+	var rowCount int
+
+	if err = d.db.QueryRow(fmt.Sprintf("SELECT count(*) FROM %s", tableName)).Scan(&rowCount); err != nil {
+		return false, fmt.Errorf("could not count %s records: %w", tableName, err)
+	}
+
+	return rowCount == 0, nil
+}
+
 func checkForRequiredFlags() error {
 	// pbx ip can not be undefined
 	if viper.GetString(flagnames.KamailioPbxIP) == "" {
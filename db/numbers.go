@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+)
+
+var ErrNumberRuleNotFound = errors.New("number rule not found")
+
+func (d *db) AddNumberRule(rule types.NumberRule) (int64, error) {
+	stmt, err := d.db.Prepare("INSERT INTO number_rules" +
+		"(fqdn, direction, field, priority, match_exp, replace_exp) " +
+		"VALUES (?,?,?,?,?,?);")
+	if err != nil {
+		return 0, fmt.Errorf("could not prepare insert statement err=%w", err)
+	}
+
+	defer stmt.Close()
+
+	res, err := stmt.Exec(
+		rule.Fqdn,
+		rule.Direction,
+		rule.Field,
+		rule.Priority,
+		rule.Match,
+		rule.Replace,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("could not execute insert statement err=%w", err)
+	}
+
+	ruleID, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("could not get number rule id: %w", err)
+	}
+
+	d.log.Debug("Number rule saved", "fqdn", rule.Fqdn, "id", ruleID)
+
+	return ruleID, nil
+}
+
+func (d *db) RemoveNumberRule(sbcFqdn string, ruleID int64) error {
+	res, err := d.db.ExecContext(
+		context.Background(), "DELETE FROM number_rules WHERE fqdn = ? AND id = ?", sbcFqdn, ruleID)
+	if err != nil {
+		return fmt.Errorf("could not delete number rule: %w", err)
+	}
+
+	if afRows, _ := res.RowsAffected(); afRows == 0 {
+		return fmt.Errorf("%w: %d", ErrNumberRuleNotFound, ruleID)
+	}
+
+	d.log.Debug("Number rule deleted", "fqdn", sbcFqdn, "id", ruleID)
+
+	return nil
+}
+
+// GetNumberRules returns the number rules of the sbc in the evaluation order of every direction and field
+func (d *db) GetNumberRules(sbcFqdn string) ([]types.NumberRule, error) {
+	rows, err := d.db.QueryContext(
+		context.Background(),
+		"SELECT id, direction, field, priority, match_exp, replace_exp FROM number_rules "+
+			"WHERE fqdn = ? ORDER BY direction, field, priority, id", sbcFqdn)
+	if err != nil {
+		return nil, fmt.Errorf("could not get number rules from database: %w", err)
+	}
+
+	defer rows.Close()
+
+	resp := make([]types.NumberRule, 0)
+
+	for rows.Next() {
+		rule := types.NumberRule{Fqdn: sbcFqdn}
+
+		if err = rows.Scan(
+			&rule.ID,
+			&rule.Direction,
+			&rule.Field,
+			&rule.Priority,
+			&rule.Match,
+			&rule.Replace,
+		); err != nil {
+			return nil, fmt.Errorf("could not scan number rule: %w", err)
+		}
+
+		resp = append(resp, rule)
+	}
+
+	return resp, rows.Err()
+}
@@ -0,0 +1,242 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+)
+
+var (
+	ErrPbxTargetExists   = errors.New("pbx target already exists")
+	ErrPbxTargetNotFound = errors.New("pbx target not found")
+
+	ErrPbxCredentialsNotFound = errors.New("pbx credentials not found")
+)
+
+func (d *db) AddPbxTarget(target types.PbxTarget) error {
+	stmt, err := d.db.Prepare("INSERT INTO pbx_targets" +
+		"(fqdn, address, port, transport, priority, weight) " +
+		"VALUES (?,?,?,?,?,?);")
+	if err != nil {
+		return fmt.Errorf("could not prepare insert statement err=%w", err)
+	}
+
+	defer stmt.Close()
+
+	if _, err = stmt.Exec(
+		target.Fqdn,
+		target.Address,
+		target.Port,
+		target.Transport,
+		target.Priority,
+		target.Weight,
+	); err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return fmt.Errorf("%w: %s:%s/%s", ErrPbxTargetExists, target.Address, target.Port, target.Transport)
+		}
+
+		return fmt.Errorf("could not execute insert statement err=%w", err)
+	}
+
+	d.log.Debug("PBX target saved", "fqdn", target.Fqdn, "address", target.Address, "port", target.Port)
+
+	return nil
+}
+
+func (d *db) RemovePbxTarget(target types.PbxTarget) error {
+	res, err := d.db.ExecContext(
+		context.Background(),
+		"DELETE FROM pbx_targets WHERE fqdn = ? AND address = ? AND port = ? AND transport = ?",
+		target.Fqdn, target.Address, target.Port, target.Transport)
+	if err != nil {
+		return fmt.Errorf("could not delete pbx target: %w", err)
+	}
+
+	if afRows, _ := res.RowsAffected(); afRows == 0 {
+		return fmt.Errorf("%w: %s:%s/%s", ErrPbxTargetNotFound, target.Address, target.Port, target.Transport)
+	}
+
+	d.log.Debug("PBX target deleted", "fqdn", target.Fqdn, "address", target.Address, "port", target.Port)
+
+	return nil
+}
+
+// GetPbxTargets returns the additional pbx targets of the sbc, ordered by descending priority
+func (d *db) GetPbxTargets(sbcFqdn string) ([]types.PbxTarget, error) {
+	rows, err := d.db.QueryContext(
+		context.Background(),
+		"SELECT address, port, transport, priority, weight FROM pbx_targets "+
+			"WHERE fqdn = ? ORDER BY priority DESC, id", sbcFqdn)
+	if err != nil {
+		return nil, fmt.Errorf("could not get pbx targets from database: %w", err)
+	}
+
+	defer rows.Close()
+
+	resp := make([]types.PbxTarget, 0)
+
+	for rows.Next() {
+		target := types.PbxTarget{Fqdn: sbcFqdn}
+
+		if err = rows.Scan(
+			&target.Address,
+			&target.Port,
+			&target.Transport,
+			&target.Priority,
+			&target.Weight,
+		); err != nil {
+			return nil, fmt.Errorf("could not scan pbx target: %w", err)
+		}
+
+		resp = append(resp, target)
+	}
+
+	return resp, rows.Err()
+}
+
+func (d *db) SavePbxCredentials(creds types.PbxCredentials) error {
+	var register int
+
+	// translate bool to int
+	if creds.Register {
+		register = 1
+	}
+
+	stmt, err := d.db.Prepare("INSERT OR REPLACE INTO pbx_credentials" +
+		"(fqdn, username, password, realm, register, expires) " +
+		"VALUES (?,?,?,?,?,?);")
+	if err != nil {
+		return fmt.Errorf("could not prepare insert statement err=%w", err)
+	}
+
+	defer stmt.Close()
+
+	if _, err = stmt.Exec(
+		creds.Fqdn,
+		creds.Username,
+		creds.Password,
+		creds.Realm,
+		register,
+		creds.Expires,
+	); err != nil {
+		return fmt.Errorf("could not execute insert statement err=%w", err)
+	}
+
+	d.log.Debug("PBX credentials saved", "fqdn", creds.Fqdn, "username", creds.Username)
+
+	return nil
+}
+
+// GetPbxCredentials returns the pbx credentials of the sbc with the encrypted password,
+// the username is empty if the sbc has no credentials
+func (d *db) GetPbxCredentials(sbcFqdn string) (types.PbxCredentials, error) {
+	creds := types.PbxCredentials{Fqdn: sbcFqdn}
+
+	err := d.db.QueryRowContext(
+		context.Background(),
+		"SELECT username, password, realm, register, expires FROM pbx_credentials WHERE fqdn = ?", sbcFqdn).
+		Scan(&creds.Username, &creds.Password, &creds.Realm, &creds.Register, &creds.Expires)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		d.log.Debug("No pbx credentials stored", "fqdn", sbcFqdn)
+	case err != nil:
+		return creds, fmt.Errorf("could not get pbx credentials: %w", err)
+	}
+
+	return creds, nil
+}
+
+func (d *db) RemovePbxCredentials(sbcFqdn string) error {
+	res, err := d.db.ExecContext(
+		context.Background(), "DELETE FROM pbx_credentials WHERE fqdn = ?", sbcFqdn)
+	if err != nil {
+		return fmt.Errorf("could not delete pbx credentials: %w", err)
+	}
+
+	if afRows, _ := res.RowsAffected(); afRows == 0 {
+		return fmt.Errorf("%w: %s", ErrPbxCredentialsNotFound, sbcFqdn)
+	}
+
+	d.log.Debug("PBX credentials deleted", "fqdn", sbcFqdn)
+
+	return nil
+}
+
+func (d *db) SavePbxTransport(transport types.PbxTransport) error {
+	var clientCert, verifyCert int
+
+	// translate bool to int
+	if transport.ClientCertificate {
+		clientCert = 1
+	}
+
+	if transport.VerifyCertificate {
+		verifyCert = 1
+	}
+
+	stmt, err := d.db.Prepare("INSERT OR REPLACE INTO pbx_transport" +
+		"(fqdn, transport, client_cert, verify_cert, ca_cert) " +
+		"VALUES (?,?,?,?,?);")
+	if err != nil {
+		return fmt.Errorf("could not prepare insert statement err=%w", err)
+	}
+
+	defer stmt.Close()
+
+	if _, err = stmt.Exec(
+		transport.Fqdn,
+		transport.Transport,
+		clientCert,
+		verifyCert,
+		transport.CACertificate,
+	); err != nil {
+		return fmt.Errorf("could not execute insert statement err=%w", err)
+	}
+
+	d.log.Debug("PBX transport saved", "fqdn", transport.Fqdn, "transport", transport.Transport)
+
+	return nil
+}
+
+// GetPbxTransport returns the pbx transport of the sbc, the transport is empty if the sbc has no stored transport
+func (d *db) GetPbxTransport(sbcFqdn string) (types.PbxTransport, error) {
+	transport := types.PbxTransport{Fqdn: sbcFqdn}
+
+	err := d.db.QueryRowContext(
+		context.Background(),
+		"SELECT transport, client_cert, verify_cert, ca_cert FROM pbx_transport WHERE fqdn = ?", sbcFqdn).
+		Scan(&transport.Transport, &transport.ClientCertificate, &transport.VerifyCertificate, &transport.CACertificate)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		d.log.Debug("No pbx transport stored", "fqdn", sbcFqdn)
+	case err != nil:
+		return transport, fmt.Errorf("could not get pbx transport: %w", err)
+	}
+
+	return transport, nil
+}
+
+// removeSbcRows deletes all the rows of the sbc from the table, databases without the table have no rows to delete
+func (d *db) removeSbcRows(tableName, sbcFqdn string) error {
+	tableExists, err := d.checkIfTableExists(tableName)
+	if err != nil {
+		return fmt.Errorf("error while checking if table already exists err=%w", err)
+	}
+
+	if !tableExists {
+		return nil
+	}
+
+	if _, err = d.db.ExecContext(
+		context.Background(), fmt.Sprintf("DELETE FROM %s WHERE fqdn = ?", tableName), sbcFqdn); err != nil {
+		return fmt.Errorf("could not delete rows of %s: %w", tableName, err)
+	}
+
+	return nil
+}
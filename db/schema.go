@@ -2,6 +2,7 @@ package db
 
 import "fmt"
 
+// CreateFreshDB creates the schema of a new database, or applies the schema updates of an older one
 func (d *db) CreateFreshDB() error {
 	var err error
 
@@ -14,8 +15,8 @@ func (d *db) CreateFreshDB() error {
 	if tableExists {
 		d.log.Debug("Table already exists, skipping new schema generation", "table", "sbc_info")
 
-		// return nil as this is not an error, and we want the rest of the program to continue
-		return nil
+		// existing databases still need the tables that were added after the initial schema
+		return d.updateSchema()
 	}
 
 	// TODO: set appropriate types
@@ -95,5 +96,125 @@ create table sbc_info
 
 	d.log.Debug("New db schema created")
 
+	return d.updateSchema()
+}
+
+// schemaUpdates holds the statements added after the initial schema.
+// They run against both fresh and existing databases, so each one must be idempotent.
+var schemaUpdates = []string{
+	`create table if not exists cert_renewals
+(
+    id            INTEGER
+        primary key autoincrement,
+    fqdn          TEXT not null,
+    serial        TEXT not null,
+    not_after     DATE not null,
+    detected      DATE not null,
+    reload_method TEXT not null,
+    reload_result TEXT not null
+);`,
+	`create table if not exists wildcard_certs
+(
+    id         INTEGER
+        primary key autoincrement,
+    domain     TEXT not null
+        constraint wildcard_certs_domain_uindex
+            unique,
+    dns_plugin TEXT not null,
+    created    DATE not null
+);`,
+	`create table if not exists acme_issuances
+(
+    id                INTEGER
+        primary key autoincrement,
+    cert_name         TEXT not null,
+    domains           TEXT not null,
+    registered_domain TEXT not null,
+    staging           INTEGER default 0,
+    issued            DATE not null
+);`,
+	`create table if not exists pbx_targets
+(
+    id        INTEGER
+        primary key autoincrement,
+    fqdn      TEXT not null,
+    address   TEXT not null,
+    port      TEXT not null,
+    transport TEXT not null,
+    priority  INTEGER default 0,
+    weight    INTEGER default 1,
+    constraint pbx_targets_uindex
+        unique (fqdn, address, port, transport)
+);`,
+	`create table if not exists pbx_credentials
+(
+    id       INTEGER
+        primary key autoincrement,
+    fqdn     TEXT not null
+        constraint pbx_credentials_fqdn_uindex
+            unique,
+    username TEXT not null,
+    password TEXT not null,
+    realm    TEXT not null,
+    register INTEGER default 0,
+    expires  INTEGER default 3600
+);`,
+	`create table if not exists pbx_transport
+(
+    id          INTEGER
+        primary key autoincrement,
+    fqdn        TEXT not null
+        constraint pbx_transport_fqdn_uindex
+            unique,
+    transport   TEXT not null,
+    client_cert INTEGER default 0,
+    verify_cert INTEGER default 0,
+    ca_cert     TEXT default ''
+);`,
+	`create table if not exists capture_retention
+(
+    id          INTEGER
+        primary key autoincrement,
+    fqdn        TEXT not null
+        constraint capture_retention_fqdn_uindex
+            unique,
+    max_size_mb INTEGER not null,
+    max_age     INTEGER not null
+);`,
+	`create table if not exists number_rules
+(
+    id          INTEGER
+        primary key autoincrement,
+    fqdn        TEXT not null,
+    direction   TEXT not null,
+    field       TEXT not null,
+    priority    INTEGER default 0,
+    match_exp   TEXT not null,
+    replace_exp TEXT not null
+);`,
+	`create table if not exists teams_prefixes
+(
+    id     INTEGER
+        primary key autoincrement,
+    prefix TEXT not null,
+    kind   TEXT not null,
+    constraint teams_prefixes_uindex
+        unique (prefix, kind)
+);`,
+}
+
+func (d *db) updateSchema() error {
+	d.log.Debug("Applying db schema updates")
+
+	for _, stmt := range schemaUpdates {
+		if _, err := d.db.Exec(stmt); err != nil {
+			d.log.Error("Could not apply schema update", "err", err)
+
+			return err
+		}
+	}
+
+	d.log.Debug("Db schema updates applied")
+
 	return nil
 }
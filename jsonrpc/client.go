@@ -0,0 +1,145 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	version = "2.0"
+	// maxResponseSize limits the response body, the largest Kamailio results are the statistics and the TLS lists
+	maxResponseSize = 16 << 20
+)
+
+var (
+	ErrInvalidResponse = errors.New("invalid jsonrpc response")
+	ErrTransport       = errors.New("jsonrpc transport failed")
+)
+
+// Error is the error object of a JSONRPC response
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("rpc error code=%d: %s", e.Code, e.Message)
+}
+
+type request struct {
+	Version string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  []any  `json:"params,omitempty"`
+	ID      int64  `json:"id"`
+}
+
+type response struct {
+	Version string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result"`
+	Error   *Error          `json:"error"`
+	ID      int64           `json:"id"`
+}
+
+// Client calls the methods of a JSONRPC 2.0 endpoint over HTTP, e.g. the Kamailio jsonrpcs module
+type Client struct {
+	url        string
+	httpClient *http.Client
+	lastID     int64
+}
+
+// NewClient creates a client of the endpoint url, the timeout limits every call
+func NewClient(url string, timeout time.Duration) *Client {
+	return &Client{
+		url:        url,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// Call calls the method with the positional params and returns its raw result.
+// The error responses are returned as *Error.
+func (c *Client) Call(ctx context.Context, method string, params ...any) (json.RawMessage, error) {
+	id := atomic.AddInt64(&c.lastID, 1)
+
+	body, err := json.Marshal(request{Version: version, Method: method, Params: params, ID: id})
+	if err != nil {
+		return nil, fmt.Errorf("could not encode jsonrpc request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("could not create jsonrpc request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrTransport, err)
+	}
+
+	defer resp.Body.Close()
+
+	// one more byte is read to tell the oversized responses from the truncated ones
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not read response: %s", ErrTransport, err)
+	}
+
+	if len(respBody) > maxResponseSize {
+		return nil, fmt.Errorf("%w: response larger than %d bytes", ErrInvalidResponse, maxResponseSize)
+	}
+
+	var rpcResp response
+
+	// Kamailio answers the failed calls with a matching http status, but still with a jsonrpc body
+	if err = json.Unmarshal(respBody, &rpcResp); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%w: http status %s", ErrTransport, resp.Status)
+		}
+
+		return nil, fmt.Errorf("%w: %s", ErrInvalidResponse, err)
+	}
+
+	if rpcResp.Error != nil {
+		return nil, rpcResp.Error
+	}
+
+	if rpcResp.ID != id {
+		return nil, fmt.Errorf("%w: response id %d does not match request id %d", ErrInvalidResponse, rpcResp.ID, id)
+	}
+
+	return rpcResp.Result, nil
+}
+
+// ParseParams converts the command line arguments to the method params like kamcmd does,
+// the integers are sent as numbers and the s: prefix forces a string, e.g. s:5060
+func ParseParams(args []string) []any {
+	params := make([]any, 0, len(args))
+
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "s:") {
+			params = append(params, strings.TrimPrefix(arg, "s:"))
+
+			continue
+		}
+
+		if number, err := strconv.Atoi(arg); err == nil {
+			params = append(params, number)
+
+			continue
+		}
+
+		params = append(params, arg)
+	}
+
+	return params
+}
@@ -0,0 +1,165 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestServer decodes the request and answers it with the response of the handler
+func newTestServer(t *testing.T, handler func(w http.ResponseWriter, r *http.Request, req request)) string {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req request
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("could not decode request: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		handler(w, r, req)
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server.URL
+}
+
+func TestCallResult(t *testing.T) {
+	url := newTestServer(t, func(w http.ResponseWriter, r *http.Request, req request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("got %s request with content type %q", r.Method, r.Header.Get("Content-Type"))
+		}
+
+		if req.Version != version || req.Method != "dispatcher.set_state" {
+			t.Errorf("got version %q method %q", req.Version, req.Method)
+		}
+
+		// the json numbers are decoded as float64
+		if want := []any{"ip", float64(2), "5060"}; !reflect.DeepEqual(req.Params, want) {
+			t.Errorf("got params %#v, want %#v", req.Params, want)
+		}
+
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","result":{"state":"ok"},"id":%d}`, req.ID)
+	})
+
+	result, err := NewClient(url, time.Second).Call(context.Background(), "dispatcher.set_state", "ip", 2, "5060")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(result) != `{"state":"ok"}` {
+		t.Errorf("got result %s", result)
+	}
+}
+
+func TestCallErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(w http.ResponseWriter, r *http.Request, req request)
+		wantErr error
+		// wantMsg tells the errors of the same kind apart
+		wantMsg string
+	}{
+		{
+			name: "id mismatch",
+			handler: func(w http.ResponseWriter, _ *http.Request, req request) {
+				fmt.Fprintf(w, `{"jsonrpc":"2.0","result":"ok","id":%d}`, req.ID+1)
+			},
+			wantErr: ErrInvalidResponse,
+			wantMsg: "does not match",
+		},
+		{
+			name: "invalid json",
+			handler: func(w http.ResponseWriter, _ *http.Request, _ request) {
+				fmt.Fprint(w, `{"jsonrpc":"2.0","result":`)
+			},
+			wantErr: ErrInvalidResponse,
+			wantMsg: "unexpected end",
+		},
+		{
+			name: "oversized body",
+			handler: func(w http.ResponseWriter, _ *http.Request, req request) {
+				fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"%s"}`, req.ID, strings.Repeat("a", maxResponseSize))
+			},
+			wantErr: ErrInvalidResponse,
+			wantMsg: "larger than",
+		},
+		{
+			name: "http error without jsonrpc body",
+			handler: func(w http.ResponseWriter, _ *http.Request, _ request) {
+				http.Error(w, "forbidden", http.StatusForbidden)
+			},
+			wantErr: ErrTransport,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := newTestServer(t, tt.handler)
+
+			_, err := NewClient(url, time.Second).Call(context.Background(), "core.version")
+			if !errors.Is(err, tt.wantErr) || !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("got error %v, want %v: %s", err, tt.wantErr, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestCallErrorObject(t *testing.T) {
+	url := newTestServer(t, func(w http.ResponseWriter, _ *http.Request, req request) {
+		// Kamailio sets the http status of the failed calls
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","error":{"code":500,"message":"No Destination Sets"},"id":%d}`, req.ID)
+	})
+
+	_, err := NewClient(url, time.Second).Call(context.Background(), "dispatcher.list")
+
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("got error %v, want *Error", err)
+	}
+
+	if rpcErr.Code != 500 || rpcErr.Message != "No Destination Sets" {
+		t.Errorf("got error code %d message %q", rpcErr.Code, rpcErr.Message)
+	}
+}
+
+func TestCallTimeout(t *testing.T) {
+	url := newTestServer(t, func(_ http.ResponseWriter, r *http.Request, _ request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+
+	started := time.Now()
+
+	_, err := NewClient(url, 100*time.Millisecond).Call(context.Background(), "core.version")
+	if !errors.Is(err, ErrTransport) {
+		t.Fatalf("got error %v, want %v", err, ErrTransport)
+	}
+
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("gave up after %s, want the client timeout", elapsed)
+	}
+}
+
+func TestParseParams(t *testing.T) {
+	got := ParseParams([]string{"ip", "2", "s:5060", "-1", "sip:10.0.0.20:5060", "1.5"})
+	want := []any{"ip", 2, "5060", -1, "sip:10.0.0.20:5060", "1.5"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got params %#v, want %#v", got, want)
+	}
+}
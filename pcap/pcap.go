@@ -0,0 +1,130 @@
+package pcap
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+const (
+	magicMicroseconds = 0xa1b2c3d4
+	versionMajor      = 2
+	versionMinor      = 4
+	snapLen           = 65535
+	// linkTypeRaw packets start with the IPv4 or IPv6 header
+	linkTypeRaw = 101
+
+	ipv4HeaderLen = 20
+	ipv6HeaderLen = 40
+	udpHeaderLen  = 8
+	protocolUDP   = 17
+	defaultTTL    = 64
+)
+
+var ErrPayloadTooLarge = errors.New("payload too large for a udp packet")
+
+// Writer writes the messages as UDP packets of a libpcap file, which start with the raw IP header
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter writes the pcap file header and returns the packet writer
+func NewWriter(w io.Writer) (*Writer, error) {
+	header := make([]byte, 24)
+
+	binary.LittleEndian.PutUint32(header[0:4], magicMicroseconds)
+	binary.LittleEndian.PutUint16(header[4:6], versionMajor)
+	binary.LittleEndian.PutUint16(header[6:8], versionMinor)
+	// the time zone and the timestamp accuracy are always 0
+	binary.LittleEndian.PutUint32(header[16:20], snapLen)
+	binary.LittleEndian.PutUint32(header[20:24], linkTypeRaw)
+
+	if _, err := w.Write(header); err != nil {
+		return nil, fmt.Errorf("could not write pcap header: %w", err)
+	}
+
+	return &Writer{w: w}, nil
+}
+
+// WriteUDP writes the payload as a UDP packet between the addresses, which must be of the same family
+func (w *Writer) WriteUDP(ts time.Time, src, dst *net.UDPAddr, payload []byte) error {
+	packet, err := udpPacket(src, dst, payload)
+	if err != nil {
+		return err
+	}
+
+	record := make([]byte, 16, 16+len(packet))
+
+	binary.LittleEndian.PutUint32(record[0:4], uint32(ts.Unix()))
+	binary.LittleEndian.PutUint32(record[4:8], uint32(ts.Nanosecond()/int(time.Microsecond)))
+	binary.LittleEndian.PutUint32(record[8:12], uint32(len(packet)))
+	binary.LittleEndian.PutUint32(record[12:16], uint32(len(packet)))
+
+	if _, err = w.w.Write(append(record, packet...)); err != nil {
+		return fmt.Errorf("could not write pcap packet: %w", err)
+	}
+
+	return nil
+}
+
+func udpPacket(src, dst *net.UDPAddr, payload []byte) ([]byte, error) {
+	srcIP4, dstIP4 := src.IP.To4(), dst.IP.To4()
+	ipv4 := srcIP4 != nil && dstIP4 != nil
+
+	ipHeaderLen := ipv6HeaderLen
+	if ipv4 {
+		ipHeaderLen = ipv4HeaderLen
+	}
+
+	udpLen := udpHeaderLen + len(payload)
+	if ipHeaderLen+udpLen > snapLen {
+		return nil, fmt.Errorf("%w: %d bytes", ErrPayloadTooLarge, len(payload))
+	}
+
+	packet := make([]byte, ipHeaderLen+udpLen)
+
+	if ipv4 {
+		packet[0] = 0x45
+		binary.BigEndian.PutUint16(packet[2:4], uint16(len(packet)))
+		packet[8] = defaultTTL
+		packet[9] = protocolUDP
+		copy(packet[12:16], srcIP4)
+		copy(packet[16:20], dstIP4)
+		binary.BigEndian.PutUint16(packet[10:12], checksum(packet[:ipv4HeaderLen]))
+	} else {
+		packet[0] = 0x60
+		binary.BigEndian.PutUint16(packet[4:6], uint16(udpLen))
+		packet[6] = protocolUDP
+		packet[7] = defaultTTL
+		copy(packet[8:24], src.IP.To16())
+		copy(packet[24:40], dst.IP.To16())
+	}
+
+	udp := packet[ipHeaderLen:]
+
+	binary.BigEndian.PutUint16(udp[0:2], uint16(src.Port))
+	binary.BigEndian.PutUint16(udp[2:4], uint16(dst.Port))
+	binary.BigEndian.PutUint16(udp[4:6], uint16(udpLen))
+	// the udp checksum is left 0, which Wireshark does not verify
+	copy(udp[udpHeaderLen:], payload)
+
+	return packet, nil
+}
+
+// checksum is the internet checksum of the IPv4 header
+func checksum(header []byte) uint16 {
+	var sum uint32
+
+	for i := 0; i < len(header); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(header[i : i+2]))
+	}
+
+	for sum > 0xffff {
+		sum = sum>>16 + sum&0xffff
+	}
+
+	return ^uint16(sum)
+}
@@ -0,0 +1,301 @@
+package sbc
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/docker/docker/api/types"
+	"github.com/spf13/viper"
+	"golang.org/x/net/publicsuffix"
+)
+
+const letsEncryptProductionServer = "https://acme-v02.api.letsencrypt.org/directory"
+
+// LetsEncrypt production rate limits, as documented at https://letsencrypt.org/docs/rate-limits/
+const (
+	rateLimitWindow            = 7 * 24 * time.Hour
+	duplicateCertificateLimit  = 5
+	certificatesPerDomainLimit = 50
+)
+
+const (
+	// certbotRenewBefore is the remaining validity, below which certbot renews a certificate
+	certbotRenewBefore = 30 * 24 * time.Hour
+	// letsEncryptStartupTimeout limits the wait for the certificate issued by a new LetsEncrypt node
+	letsEncryptStartupTimeout = 2 * time.Minute
+	letsEncryptStartupPoll    = 5 * time.Second
+)
+
+var ErrRateLimitExceeded = errors.New("letsencrypt rate limit would be exceeded, use --ignore-rate-limit to override")
+
+// checkRateLimits refuses a production issuance that would exceed the known LetsEncrypt rate limits
+func (s *sbc) checkRateLimits(domains []string, staging bool) error {
+	if staging {
+		return nil
+	}
+
+	var limitErr error
+
+	duplicates, err := s.db.CountProductionIssuances(domains, rateLimitWindow)
+	if err != nil {
+		return err
+	}
+
+	regDomain := registeredDomain(domains[0])
+
+	perDomain, err := s.db.CountProductionIssuancesForRegisteredDomain(regDomain, rateLimitWindow)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case duplicates >= duplicateCertificateLimit:
+		limitErr = fmt.Errorf("%w: %d certificates already issued for %s in the last week",
+			ErrRateLimitExceeded, duplicates, strings.Join(domains, ","))
+	case perDomain >= certificatesPerDomainLimit:
+		limitErr = fmt.Errorf("%w: %d certificates already issued under %s in the last week",
+			ErrRateLimitExceeded, perDomain, regDomain)
+	}
+
+	if limitErr != nil && viper.GetBool(flagnames.IgnoreRateLimit) {
+		s.logger.Warn("Ignoring LetsEncrypt rate limit", "err", limitErr)
+
+		return nil
+	}
+
+	return limitErr
+}
+
+// registeredDomain returns the domain name LetsEncrypt uses for the certificates per registered domain limit
+func registeredDomain(domain string) string {
+	regDomain, err := publicsuffix.EffectiveTLDPlusOne(strings.TrimPrefix(domain, "*."))
+	if err != nil {
+		return domain
+	}
+
+	return regDomain
+}
+
+func isStagingCertificate(cert *x509.Certificate) bool {
+	return strings.Contains(cert.Issuer.CommonName, "STAGING") ||
+		strings.Contains(strings.Join(cert.Issuer.Organization, " "), "STAGING")
+}
+
+// recordStartupIssuance records the primary certificate issued by a new LetsEncrypt node on startup.
+// The node keeps a valid certificate of the certificates volume, so the issuance is recorded
+// only if the live serial changes, like runCertbotCertonly does.
+func (s *sbc) recordStartupIssuance(nodeID, certName string, staging bool) {
+	previous, _ := s.readCertificate(nodeID, certName)
+
+	// the node only requests a missing, expiring or staging certificate after the switch to production
+	if previous != nil && time.Until(previous.NotAfter) > certbotRenewBefore && isStagingCertificate(previous) == staging {
+		s.logger.Debug("LetsEncrypt node keeps the existing certificate",
+			"cert_name", certName, "not_after", previous.NotAfter)
+
+		return
+	}
+
+	s.logger.Info("Waiting for the LetsEncrypt node to issue the certificate", "cert_name", certName)
+
+	for deadline := time.Now().Add(letsEncryptStartupTimeout); time.Now().Before(deadline); {
+		time.Sleep(letsEncryptStartupPoll)
+
+		current, err := s.readCertificate(nodeID, certName)
+		if err != nil || (previous != nil && previous.SerialNumber.Cmp(current.SerialNumber) == 0) {
+			continue
+		}
+
+		s.recordIssuance(certName, []string{certName}, staging)
+
+		return
+	}
+
+	s.logger.Warn("The LetsEncrypt node did not issue the certificate in time, the issuance is not recorded",
+		"cert_name", certName, "timeout", letsEncryptStartupTimeout)
+}
+
+// PromoteCertificates re-issues every certificate that was issued by the LetsEncrypt staging environment
+// against the production environment, and switches the LetsEncrypt node to production
+func (s *sbc) PromoteCertificates() error {
+	if err := s.db.CreateFreshDB(); err != nil {
+		return fmt.Errorf("could not update database schema: %w", err)
+	}
+
+	nodeID, err := s.db.GetLetsEncryptNodeID()
+	if err != nil {
+		return fmt.Errorf("could not get letsencrypt node id: %w", err)
+	}
+
+	if nodeID == "" {
+		return ErrLetsEncryptNodeNotFound
+	}
+
+	nodeDetails, err := s.dockerCl.ContainerInspect(s.ctx, nodeID)
+	if err != nil {
+		return fmt.Errorf("could not inspect letsencrypt node: %w", err)
+	}
+
+	primaryDomain := letsEncryptPrimaryDomain(nodeDetails.Config.Env)
+
+	certNames, err := s.allCertNames()
+	if err != nil {
+		return err
+	}
+
+	// collect the staging certificates first, so the rate limits are checked before anything is changed
+	var (
+		stagingCertNames []string
+		promotePrimary   bool
+	)
+
+	for _, certName := range certNames {
+		cert, err := s.readCertificate(nodeID, certName)
+		if err != nil {
+			s.logger.Warn("Could not read certificate", "cert_name", certName, "err", err)
+
+			continue
+		}
+
+		if !isStagingCertificate(cert) {
+			s.logger.Debug("Certificate already issued by production", "cert_name", certName)
+
+			continue
+		}
+
+		if err = s.checkRateLimits(cert.DNSNames, false); err != nil {
+			return err
+		}
+
+		if certName == primaryDomain {
+			promotePrimary = true
+
+			continue
+		}
+
+		stagingCertNames = append(stagingCertNames, certName)
+	}
+
+	if !promotePrimary && len(stagingCertNames) == 0 {
+		s.logger.Info("No staging certificates found")
+
+		return nil
+	}
+
+	// the node issues its primary certificate by itself, so it has to be started without staging
+	if promotePrimary {
+		if nodeID, err = s.replaceLetsEncryptNode(nodeID, primaryDomain); err != nil {
+			return fmt.Errorf("could not replace letsencrypt node: %w", err)
+		}
+	}
+
+	for _, certName := range stagingCertNames {
+		if err = s.renewOnProduction(nodeID, certName); err != nil {
+			return fmt.Errorf("could not promote certificate %s: %w", certName, err)
+		}
+	}
+
+	// reload the promoted certificates in Kamailio
+	return s.checkCertificateRenewals()
+}
+
+// allCertNames returns the names of all the certificates used by the deployed sbcs
+func (s *sbc) allCertNames() ([]string, error) {
+	fqdnNames, err := s.db.GetAllFqdnNames()
+	if err != nil {
+		return nil, fmt.Errorf("could not get fqdn names: %w", err)
+	}
+
+	var (
+		certNames = make([]string, 0, len(fqdnNames))
+		seen      = make(map[string]bool)
+	)
+
+	for _, fqdn := range fqdnNames {
+		certName := s.certName(fqdn)
+		if seen[certName] {
+			continue
+		}
+
+		seen[certName] = true
+
+		certNames = append(certNames, certName)
+	}
+
+	return certNames, nil
+}
+
+// replaceLetsEncryptNode recreates the LetsEncrypt node against the production environment,
+// keeping the certificates volume and the DNS provider credentials, and returns the new node id
+func (s *sbc) replaceLetsEncryptNode(nodeID, primaryDomain string) (string, error) {
+	dnsConf, _, err := s.dockerCl.CopyFromContainer(s.ctx, nodeID, letsEncryptDNSConfDir)
+	if err != nil {
+		return "", fmt.Errorf("could not copy dns credentials from letsencrypt node: %w", err)
+	}
+
+	defer dnsConf.Close()
+
+	// the named certificates volume is not removed together with the container
+	if err = s.dockerCl.ContainerRemove(s.ctx, nodeID, types.ContainerRemoveOptions{
+		Force: true,
+	}); err != nil {
+		return "", fmt.Errorf("could not remove letsencrypt container: %w", err)
+	}
+
+	if err = s.db.RemoveLetsEncryptInfo(nodeID); err != nil {
+		return "", fmt.Errorf("could not remove letsencrypt database info: %w", err)
+	}
+
+	if err = s.createAndRunLetsEncrypt(primaryDomain, false); err != nil {
+		return "", err
+	}
+
+	if nodeID, err = s.db.GetLetsEncryptNodeID(); err != nil {
+		return "", fmt.Errorf("could not get letsencrypt node id: %w", err)
+	}
+
+	if err = s.dockerCl.CopyToContainer(
+		s.ctx, nodeID, "/config", dnsConf, types.CopyToContainerOptions{}); err != nil {
+		return "", fmt.Errorf("could not copy dns credentials to letsencrypt node: %w", err)
+	}
+
+	s.logger.Debug("Sleeping until the LetsEncrypt node starts serving http validation")
+	time.Sleep(30 * time.Second)
+
+	return nodeID, nil
+}
+
+// renewOnProduction forces the renewal of the certificate against the production environment,
+// using the validation method it was originally issued with
+func (s *sbc) renewOnProduction(nodeID, certName string) error {
+	s.logger.Info("Promoting certificate to production", "cert_name", certName)
+
+	out, exitCode, err := s.execInContainer(nodeID, []string{
+		"certbot", "renew",
+		"--non-interactive", "--force-renewal",
+		"--config-dir", letsEncryptConfigDir,
+		"--cert-name", certName,
+		"--server", letsEncryptProductionServer,
+	})
+	if err != nil {
+		return err
+	}
+
+	if exitCode != 0 {
+		return fmt.Errorf("%w: %s", ErrCertificateNotIssued, strings.TrimSpace(string(out)))
+	}
+
+	cert, err := s.readCertificate(nodeID, certName)
+	if err != nil {
+		return err
+	}
+
+	s.recordIssuance(certName, cert.DNSNames, false)
+
+	s.logger.Info("Certificate promoted", "cert_name", certName)
+
+	return nil
+}
@@ -0,0 +1,301 @@
+package sbc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/spf13/viper"
+)
+
+var (
+	ErrInvalidSpec  = errors.New("invalid sbc spec")
+	ErrApplyFailed  = errors.New("could not apply all the planned actions")
+	ErrHostIPNotSet = errors.New("host ip not set")
+)
+
+// Plan compares the desired sbcs with the database and the running containers
+// and returns the actions needed to converge them, the sbcs missing from the specs are destroyed only with prune
+func (s *sbc) Plan(specs []types.SbcSpec, prune bool) ([]types.ApplyAction, error) {
+	if err := validateSpecs(specs); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.CreateFreshDB(); err != nil {
+		return nil, fmt.Errorf("could not update database schema: %w", err)
+	}
+
+	deployedFqdns, err := s.db.GetAllFqdnNames()
+	if err != nil {
+		return nil, fmt.Errorf("could not get SBC names: %w", err)
+	}
+
+	desired := make(map[string]struct{}, len(specs))
+	for _, spec := range specs {
+		desired[spec.Fqdn] = struct{}{}
+	}
+
+	deployed := make(map[string]struct{}, len(deployedFqdns))
+	plan := make([]types.ApplyAction, 0, len(specs)+len(deployedFqdns))
+
+	// destroy first, to free the ports and certificates of removed sbcs
+	for _, fqdn := range deployedFqdns {
+		deployed[fqdn] = struct{}{}
+
+		if _, ok := desired[fqdn]; ok {
+			continue
+		}
+
+		if !prune {
+			plan = append(plan, types.ApplyAction{
+				Action:  types.ApplyActionNone,
+				Fqdn:    fqdn,
+				Reasons: []string{"not in the desired state, kept without --prune"},
+			})
+
+			continue
+		}
+
+		plan = append(plan, types.ApplyAction{
+			Action:  types.ApplyActionDestroy,
+			Fqdn:    fqdn,
+			Reasons: []string{"not in the desired state"},
+		})
+	}
+
+	for _, spec := range specs {
+		if _, ok := deployed[spec.Fqdn]; !ok {
+			plan = append(plan, types.ApplyAction{
+				Action:  types.ApplyActionCreate,
+				Fqdn:    spec.Fqdn,
+				Reasons: []string{"not deployed"},
+				Spec:    spec,
+			})
+
+			continue
+		}
+
+		reasons, err := s.specDrift(spec)
+		if err != nil {
+			return nil, err
+		}
+
+		action := types.ApplyActionNone
+		if len(reasons) > 0 {
+			action = types.ApplyActionRecreate
+		}
+
+		plan = append(plan, types.ApplyAction{
+			Action:  action,
+			Fqdn:    spec.Fqdn,
+			Reasons: reasons,
+			Spec:    spec,
+		})
+	}
+
+	return plan, nil
+}
+
+// Apply runs, recreates or destroys the sbcs from the plan, it continues with the next action if one fails
+func (s *sbc) Apply(plan []types.ApplyAction) error {
+	failed := 0
+
+	for _, action := range plan {
+		if action.Action == types.ApplyActionNone {
+			continue
+		}
+
+		s.logger.Info("Applying action", "action", action.Action, "fqdn", action.Fqdn)
+
+		if err := s.applyAction(action); err != nil {
+			s.logger.Error("Could not apply action", "action", action.Action, "fqdn", action.Fqdn, "err", err)
+
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%w: %d failed", ErrApplyFailed, failed)
+	}
+
+	return nil
+}
+
+func (s *sbc) applyAction(action types.ApplyAction) error {
+	switch action.Action {
+	case types.ApplyActionCreate:
+		if err := setSpecFlags(action.Spec); err != nil {
+			return err
+		}
+
+		return s.deploy()
+	case types.ApplyActionRecreate:
+		if err := setSpecFlags(action.Spec); err != nil {
+			return err
+		}
+
+		current, err := s.db.GetSBCParameters(s.db.GetSBCIdFromFqdn(action.Fqdn))
+		if err != nil {
+			return fmt.Errorf("could not get sbc parameters: %w", err)
+		}
+
+		if err = s.db.UpdateSBCInformation(action.Fqdn, mergeSpec(current, action.Spec)); err != nil {
+			return fmt.Errorf("could not update sbc information: %w", err)
+		}
+
+		return s.Recreate(action.Fqdn)
+	case types.ApplyActionDestroy:
+		return s.Destroy(action.Fqdn)
+	default:
+		return nil
+	}
+}
+
+// specDrift returns the differences between the spec and the deployed sbc
+func (s *sbc) specDrift(spec types.SbcSpec) ([]string, error) {
+	current, err := s.db.GetSBCParameters(s.db.GetSBCIdFromFqdn(spec.Fqdn))
+	if err != nil {
+		return nil, fmt.Errorf("could not get sbc parameters of %s: %w", spec.Fqdn, err)
+	}
+
+	reasons := make([]string, 0)
+
+	// optional values are compared only if they are set in the spec
+	for _, field := range []struct {
+		name, current, desired string
+		optional               bool
+	}{
+		{"pbx_ip", current.PbxIP, spec.PbxIP, false},
+		{"public_ip", current.MediaPublicIP, spec.PublicIP, false},
+		{"enable_sip_dump", fmt.Sprint(current.EnableSIPDump), fmt.Sprint(spec.EnableSIPDump), false},
+		{"pbx_port", current.PbxPort, spec.PbxPort, true},
+		{"sbc_tls_port", current.SbcTLSPort, spec.SbcTLSPort, true},
+		{"sbc_udp_port", current.SbcUDPPort, spec.SbcUDPPort, true},
+		{"rtp_engine_port", current.RTPEnginePort, spec.RTPEnginePort, true},
+		{"rtp_min_port", current.RTPMinPort, spec.RTPMinPort, true},
+		{"rtp_max_port", current.RTPMaxPort, spec.RTPMaxPort, true},
+	} {
+		if field.optional && field.desired == "" {
+			continue
+		}
+
+		if field.current != field.desired {
+			reasons = append(reasons, fmt.Sprintf("%s %s -> %s", field.name, field.current, field.desired))
+		}
+	}
+
+	ids := s.db.GetContainerIDsFromSbcFqdn(spec.Fqdn)
+	if ids == nil {
+		return nil, fmt.Errorf("%w: %s", ErrCouldNotGetContainerIDs, spec.Fqdn)
+	}
+
+	for _, container := range []struct {
+		component, id, image string
+	}{
+		{componentKamailio, ids[0], spec.KamailioImage},
+		{componentRTPEngine, ids[1], spec.RTPEngineImage},
+	} {
+		status := s.containerStatus(spec.Fqdn, container.component, container.id)
+
+		if !status.Healthy {
+			reasons = append(reasons, fmt.Sprintf("%s container %s", container.component, status.State))
+
+			continue
+		}
+
+		if container.image != "" && status.Image != container.image {
+			reasons = append(reasons,
+				fmt.Sprintf("%s image %s -> %s", container.component, status.Image, container.image))
+		}
+	}
+
+	return reasons, nil
+}
+
+func validateSpecs(specs []types.SbcSpec) error {
+	fqdns := make(map[string]struct{}, len(specs))
+
+	for i, spec := range specs {
+		switch {
+		case spec.Fqdn == "":
+			return fmt.Errorf("%w: sbc #%d has no fqdn", ErrInvalidSpec, i+1)
+		case spec.PbxIP == "":
+			return fmt.Errorf("%w: %s has no pbx_ip", ErrInvalidSpec, spec.Fqdn)
+		case spec.PublicIP == "":
+			return fmt.Errorf("%w: %s has no public_ip", ErrInvalidSpec, spec.Fqdn)
+		case spec.HostIP == "" && viper.GetString(flagnames.HostIP) == "":
+			return fmt.Errorf("%w: %s: %s", ErrInvalidSpec, spec.Fqdn, ErrHostIPNotSet)
+		}
+
+		if _, ok := fqdns[spec.Fqdn]; ok {
+			return fmt.Errorf("%w: %s is defined more than once", ErrInvalidSpec, spec.Fqdn)
+		}
+
+		fqdns[spec.Fqdn] = struct{}{}
+	}
+
+	return nil
+}
+
+// setSpecFlags overrides the flag values read when the sbc is deployed
+func setSpecFlags(spec types.SbcSpec) error {
+	viper.Set(flagnames.SbcFqdn, spec.Fqdn)
+	viper.Set(flagnames.KamailioPbxIP, spec.PbxIP)
+	viper.Set(flagnames.RTPPublicIP, spec.PublicIP)
+	viper.Set(flagnames.KamailioSIPDump, spec.EnableSIPDump)
+
+	// a nil override falls back to the flag value, so optional values of the previous spec are not reused
+	for key, value := range map[string]string{
+		flagnames.HostIP:             spec.HostIP,
+		flagnames.KamailioPbxPort:    spec.PbxPort,
+		flagnames.KamailioSbcPort:    spec.SbcTLSPort,
+		flagnames.KamailioUDPSIPPort: spec.SbcUDPPort,
+		flagnames.KamailioRTPEngPort: spec.RTPEnginePort,
+		flagnames.RTPSignalPort:      spec.RTPEnginePort,
+		flagnames.RTPMinPort:         spec.RTPMinPort,
+		flagnames.RTPMaxPort:         spec.RTPMaxPort,
+		flagnames.KamailioImage:      spec.KamailioImage,
+		flagnames.RTPImage:           spec.RTPEngineImage,
+	} {
+		if value == "" {
+			viper.Set(key, nil)
+
+			continue
+		}
+
+		viper.Set(key, value)
+	}
+
+	if viper.GetString(flagnames.HostIP) == "" {
+		return ErrHostIPNotSet
+	}
+
+	return nil
+}
+
+// mergeSpec returns the deployed sbc configuration with the values set in the spec
+func mergeSpec(current types.Sbc, spec types.SbcSpec) types.Sbc {
+	current.PbxIP = spec.PbxIP
+	current.MediaPublicIP = spec.PublicIP
+	current.EnableSIPDump = spec.EnableSIPDump
+
+	for _, field := range []struct {
+		current *string
+		desired string
+	}{
+		{&current.PbxPort, spec.PbxPort},
+		{&current.SbcTLSPort, spec.SbcTLSPort},
+		{&current.SbcUDPPort, spec.SbcUDPPort},
+		{&current.RTPEnginePort, spec.RTPEnginePort},
+		{&current.NgListen, spec.RTPEnginePort},
+		{&current.RTPMinPort, spec.RTPMinPort},
+		{&current.RTPMaxPort, spec.RTPMaxPort},
+	} {
+		if field.desired != "" {
+			*field.current = field.desired
+		}
+	}
+
+	return current
+}
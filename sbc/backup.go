@@ -0,0 +1,421 @@
+package sbc
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+)
+
+const (
+	backupVersion      = 1
+	backupManifestFile = "manifest.json"
+	backupDBFile       = "sbc.db"
+	backupSecretKey    = secretKeyFileName
+	backupVolumesDir   = "volumes"
+
+	// certificatesVolume is shared by the LetsEncrypt node and all Kamailio containers
+	certificatesVolume = "certificates"
+)
+
+var (
+	ErrBackupVersionNotSupported = errors.New("backup version not supported")
+	ErrBackupChecksumMismatch    = errors.New("backup checksum mismatch")
+	ErrBackupInvalidEntry        = errors.New("invalid backup archive entry")
+)
+
+// Backup writes a consistent database snapshot, the certificates and Kamailio configuration volumes
+// and the image digests of the running containers to a gzip compressed tar archive
+func (s *sbc) Backup(outFile string) error {
+	stageDir, err := os.MkdirTemp("", "tsbc-backup-")
+	if err != nil {
+		return fmt.Errorf("could not create staging directory: %w", err)
+	}
+
+	defer os.RemoveAll(stageDir)
+
+	manifest := types.BackupManifest{
+		Version: backupVersion,
+		Created: time.Now().UTC(),
+		Files:   make(map[string]string),
+	}
+
+	// the snapshot is taken first, so the archived containers and volumes match the database
+	if err = s.db.Snapshot(filepath.Join(stageDir, backupDBFile)); err != nil {
+		return err
+	}
+
+	// the encrypted database secrets can only be restored together with their key
+	if err = s.backupSecretKey(stageDir); err != nil {
+		return err
+	}
+
+	manifest.Sbcs, err = s.db.GetAllFqdnNames()
+	if err != nil {
+		return fmt.Errorf("could not get SBC names: %w", err)
+	}
+
+	nodeID, err := s.db.GetLetsEncryptNodeID()
+	if err != nil {
+		return fmt.Errorf("could not get letsencrypt node id: %w", err)
+	}
+
+	if nodeID != "" {
+		if err = s.backupContainer(&manifest, stageDir, letsEncryptStatusName, componentLetsEncrypt, nodeID,
+			certificatesVolume, letsEncryptConfigDir); err != nil {
+			return err
+		}
+	}
+
+	for _, fqdn := range manifest.Sbcs {
+		ids := s.db.GetContainerIDsFromSbcFqdn(fqdn)
+		if ids == nil {
+			return fmt.Errorf("%w: %s", ErrCouldNotGetContainerIDs, fqdn)
+		}
+
+		if err = s.backupContainer(&manifest, stageDir, fqdn, componentKamailio, ids[0],
+			fqdn+"-kamcfg", kamailioConfigDir); err != nil {
+			return err
+		}
+
+		if err = s.backupContainer(&manifest, stageDir, fqdn, componentRTPEngine, ids[1], "", ""); err != nil {
+			return err
+		}
+	}
+
+	if err = writeBackupArchive(outFile, stageDir, manifest); err != nil {
+		return err
+	}
+
+	s.logger.Info("Backup written", "file", outFile, "sbcs", len(manifest.Sbcs), "volumes", len(manifest.Volumes))
+
+	return nil
+}
+
+// backupSecretKey stages the secret key file, if the secrets were ever encrypted
+func (s *sbc) backupSecretKey(stageDir string) error {
+	key, err := os.Open(s.secretKeyFile())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not open secret key: %w", err)
+	}
+
+	defer key.Close()
+
+	return writeStageFile(stageDir, backupSecretKey, key)
+}
+
+// backupContainer adds the container image and environment to the manifest
+// and archives the volume mounted to the target directory, if the volume is set
+func (s *sbc) backupContainer(manifest *types.BackupManifest, stageDir, fqdn, component, containerID,
+	volumeName, target string,
+) error {
+	cDetails, err := s.dockerCl.ContainerInspect(s.ctx, containerID)
+	if err != nil {
+		return fmt.Errorf("could not inspect %s container of %s: %w", component, fqdn, err)
+	}
+
+	backupCont := types.BackupContainer{
+		Fqdn:      fqdn,
+		Component: component,
+		Name:      strings.TrimPrefix(cDetails.Name, "/"),
+		Image:     cDetails.Config.Image,
+		Env:       cDetails.Config.Env,
+	}
+
+	// the image environment is left out, so only the variables set by tsbc are restored
+	if imageDetails, _, err := s.dockerCl.ImageInspectWithRaw(s.ctx, cDetails.Image); err == nil {
+		if len(imageDetails.RepoDigests) > 0 {
+			backupCont.ImageDigest = imageDetails.RepoDigests[0]
+		}
+
+		if imageDetails.Config != nil {
+			backupCont.Env = withoutEnvVars(cDetails.Config.Env, imageDetails.Config.Env)
+		}
+	}
+
+	manifest.Containers = append(manifest.Containers, backupCont)
+
+	if volumeName == "" {
+		return nil
+	}
+
+	// volume content is copied from the container, which works for stopped containers as well
+	content, _, err := s.dockerCl.CopyFromContainer(s.ctx, containerID, target)
+	if err != nil {
+		return fmt.Errorf("could not copy %s volume: %w", volumeName, err)
+	}
+
+	defer content.Close()
+
+	fileName := path.Join(backupVolumesDir, volumeName+".tar")
+	if err = writeStageFile(stageDir, fileName, content); err != nil {
+		return err
+	}
+
+	manifest.Volumes = append(manifest.Volumes, types.BackupVolume{
+		Name:      volumeName,
+		Fqdn:      fqdn,
+		Component: component,
+		Target:    target,
+		File:      fileName,
+	})
+
+	s.logger.Debug("Volume archived", "volume", volumeName)
+
+	return nil
+}
+
+// withoutEnvVars returns the environment variables, that are not in the excluded list
+func withoutEnvVars(envVars, excluded []string) []string {
+	excludedSet := make(map[string]struct{}, len(excluded))
+	for _, envVar := range excluded {
+		excludedSet[envVar] = struct{}{}
+	}
+
+	resp := make([]string, 0, len(envVars))
+
+	for _, envVar := range envVars {
+		if _, ok := excludedSet[envVar]; !ok {
+			resp = append(resp, envVar)
+		}
+	}
+
+	return resp
+}
+
+func writeStageFile(stageDir, fileName string, content io.Reader) error {
+	filePath := filepath.Join(stageDir, filepath.FromSlash(fileName))
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0700); err != nil {
+		return fmt.Errorf("could not create staging directory: %w", err)
+	}
+
+	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("could not create %s: %w", fileName, err)
+	}
+
+	defer file.Close()
+
+	if _, err = io.Copy(file, content); err != nil {
+		return fmt.Errorf("could not write %s: %w", fileName, err)
+	}
+
+	return nil
+}
+
+// writeBackupArchive checksums the staged files and writes them, with the manifest first, to the archive
+func writeBackupArchive(outFile, stageDir string, manifest types.BackupManifest) error {
+	fileNames := append([]string{backupDBFile}, volumeFiles(manifest.Volumes)...)
+
+	if _, err := os.Stat(filepath.Join(stageDir, backupSecretKey)); err == nil {
+		fileNames = append(fileNames, backupSecretKey)
+	}
+
+	for _, fileName := range fileNames {
+		checksum, err := fileChecksum(filepath.Join(stageDir, filepath.FromSlash(fileName)))
+		if err != nil {
+			return err
+		}
+
+		manifest.Files[fileName] = checksum
+	}
+
+	manifestContent, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode backup manifest: %w", err)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(outFile), 0700); err != nil {
+		return fmt.Errorf("could not create output directory: %w", err)
+	}
+
+	// the archive holds private keys, so only the owner can read it
+	file, err := os.OpenFile(outFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("could not create backup file: %w", err)
+	}
+
+	defer file.Close()
+
+	gzw := gzip.NewWriter(file)
+	tw := tar.NewWriter(gzw)
+
+	err = writeTarFile(tw, backupManifestFile, int64(len(manifestContent)), bytes.NewReader(manifestContent))
+	if err != nil {
+		return err
+	}
+
+	for _, fileName := range fileNames {
+		if err = addStageFileToTar(tw, stageDir, fileName); err != nil {
+			return err
+		}
+	}
+
+	if err = tw.Close(); err != nil {
+		return fmt.Errorf("could not close backup archive: %w", err)
+	}
+
+	if err = gzw.Close(); err != nil {
+		return fmt.Errorf("could not close backup archive: %w", err)
+	}
+
+	return nil
+}
+
+func addStageFileToTar(tw *tar.Writer, stageDir, fileName string) error {
+	file, err := os.Open(filepath.Join(stageDir, filepath.FromSlash(fileName)))
+	if err != nil {
+		return fmt.Errorf("could not open %s: %w", fileName, err)
+	}
+
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("could not stat %s: %w", fileName, err)
+	}
+
+	return writeTarFile(tw, fileName, fileInfo.Size(), file)
+}
+
+func writeTarFile(tw *tar.Writer, name string, size int64, content io.Reader) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    size,
+		ModTime: time.Now(),
+	}); err != nil {
+		return fmt.Errorf("could not write tar header of %s: %w", name, err)
+	}
+
+	if _, err := io.Copy(tw, content); err != nil {
+		return fmt.Errorf("could not write %s to backup archive: %w", name, err)
+	}
+
+	return nil
+}
+
+// readBackupArchive extracts the archive to the staging directory and verifies the manifest version and checksums
+func readBackupArchive(archiveFile, stageDir string) (types.BackupManifest, error) {
+	manifest := types.BackupManifest{}
+
+	file, err := os.Open(archiveFile)
+	if err != nil {
+		return manifest, fmt.Errorf("could not open backup file: %w", err)
+	}
+
+	defer file.Close()
+
+	gzr, err := gzip.NewReader(file)
+	if err != nil {
+		return manifest, fmt.Errorf("could not read backup archive: %w", err)
+	}
+
+	defer gzr.Close()
+
+	tr := tar.NewReader(gzr)
+
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return manifest, fmt.Errorf("could not read backup archive: %w", err)
+		}
+
+		// only plain relative files are written by Backup
+		if header.Typeflag != tar.TypeReg || path.IsAbs(header.Name) ||
+			strings.HasPrefix(path.Clean(header.Name), "..") {
+			return manifest, fmt.Errorf("%w: %s", ErrBackupInvalidEntry, header.Name)
+		}
+
+		if err = writeStageFile(stageDir, path.Clean(header.Name), tr); err != nil {
+			return manifest, err
+		}
+	}
+
+	manifestContent, err := os.ReadFile(filepath.Join(stageDir, backupManifestFile))
+	if err != nil {
+		return manifest, fmt.Errorf("could not read backup manifest: %w", err)
+	}
+
+	if err = json.Unmarshal(manifestContent, &manifest); err != nil {
+		return manifest, fmt.Errorf("could not decode backup manifest: %w", err)
+	}
+
+	if manifest.Version != backupVersion {
+		return manifest, fmt.Errorf("%w: %d", ErrBackupVersionNotSupported, manifest.Version)
+	}
+
+	fileNames := make([]string, 0, len(manifest.Files))
+	for fileName := range manifest.Files {
+		fileNames = append(fileNames, fileName)
+	}
+
+	sort.Strings(fileNames)
+
+	for _, fileName := range fileNames {
+		checksum, err := fileChecksum(filepath.Join(stageDir, filepath.FromSlash(fileName)))
+		if err != nil {
+			return manifest, err
+		}
+
+		if checksum != manifest.Files[fileName] {
+			return manifest, fmt.Errorf("%w: %s", ErrBackupChecksumMismatch, fileName)
+		}
+	}
+
+	// the database and every listed volume must be covered by a checksum
+	for _, fileName := range append([]string{backupDBFile}, volumeFiles(manifest.Volumes)...) {
+		if _, ok := manifest.Files[fileName]; !ok {
+			return manifest, fmt.Errorf("%w: %s has no checksum", ErrBackupChecksumMismatch, fileName)
+		}
+	}
+
+	return manifest, nil
+}
+
+func volumeFiles(volumes []types.BackupVolume) []string {
+	resp := make([]string, 0, len(volumes))
+	for _, volume := range volumes {
+		resp = append(resp, volume.File)
+	}
+
+	return resp
+}
+
+func fileChecksum(fileName string) (string, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return "", fmt.Errorf("could not open %s: %w", filepath.Base(fileName), err)
+	}
+
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("could not checksum %s: %w", filepath.Base(fileName), err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,234 @@
+package sbc
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/ZeljkoBenovic/tsbc/sip"
+)
+
+// results of the calls
+const (
+	CallResultAnswered   = "answered"
+	CallResultCompleted  = "completed"
+	CallResultCanceled   = "canceled"
+	CallResultFailed     = "failed"
+	CallResultIncomplete = "incomplete"
+)
+
+const (
+	methodInvite = "INVITE"
+	methodCancel = "CANCEL"
+	methodBye    = "BYE"
+
+	statusRequestTerminated = 487
+)
+
+// capturedMessage is a parsed message of the sip capture
+type capturedMessage struct {
+	record  *sip.DumpRecord
+	message *sip.Message
+}
+
+// Calls returns the calls captured between from and to, grouped by Call-ID and ordered by their first message.
+// Only the INVITE dialogs are returned, unless all is set.
+func (s *sbc) Calls(fqdnName string, from, to time.Time, all bool) ([]types.Call, error) {
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: the analysis window must start before it ends", ErrCaptureInvalid)
+	}
+
+	if err := s.loadDeployedSbc(fqdnName); err != nil {
+		return nil, err
+	}
+
+	captureDir, err := s.captureDir()
+	if err != nil {
+		return nil, err
+	}
+
+	callIDs := make([]string, 0)
+	messages := make(map[string][]capturedMessage)
+
+	err = readCaptureRecords(captureDir, from, to, func(record *sip.DumpRecord) error {
+		message, err := sip.ParseMessage(record.Data)
+		if err != nil {
+			s.logger.Debug("Skipping captured message", "time", record.Time, "err", err)
+
+			return nil
+		}
+
+		callID := message.Header("Call-ID")
+		if callID == "" {
+			return nil
+		}
+
+		if _, found := messages[callID]; !found {
+			callIDs = append(callIDs, callID)
+		}
+
+		messages[callID] = append(messages[callID], capturedMessage{record: record, message: message})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	calls := make([]types.Call, 0, len(callIDs))
+
+	for _, callID := range callIDs {
+		callMessages := messages[callID]
+
+		// the capture files are read in order, but the records of a file are not strictly sorted
+		sort.SliceStable(callMessages, func(i, j int) bool {
+			return callMessages[i].record.Time.Before(callMessages[j].record.Time)
+		})
+
+		summary := summarizeCall(callID, callMessages)
+		if !all && summary.Method != methodInvite {
+			continue
+		}
+
+		calls = append(calls, types.Call{Summary: summary, Messages: callFlow(callMessages)})
+	}
+
+	sort.SliceStable(calls, func(i, j int) bool {
+		return calls[i].Summary.Start.Before(calls[j].Summary.Start)
+	})
+
+	return calls, nil
+}
+
+// summarizeCall finds the outcome of the initial request, the request without a To tag.
+// The final response sent by the sbc takes precedence over the one it received, as that is what the caller got.
+func summarizeCall(callID string, messages []capturedMessage) types.CallSummary {
+	summary := types.CallSummary{
+		CallID:   callID,
+		Start:    messages[0].record.Time,
+		Messages: len(messages),
+	}
+
+	initialCSeqs := make(map[int]bool)
+	canceled := false
+
+	var (
+		final     *capturedMessage
+		finalSent bool
+		byeTime   time.Time
+	)
+
+	for i := range messages {
+		message := messages[i].message
+
+		if message.IsRequest() {
+			initial := sip.HeaderParam(message.Header("To"), "tag") == ""
+
+			if summary.Method == "" && initial {
+				summary.Method = message.Method
+				summary.Caller = sip.URIUser(sip.AddressURI(message.Header("From")))
+				summary.Callee = sip.URIUser(message.RequestURI)
+			}
+
+			if initial && message.Method == summary.Method {
+				initialCSeqs[message.CSeqNumber()] = true
+			}
+
+			switch message.Method {
+			case methodCancel:
+				canceled = true
+			case methodBye:
+				if byeTime.IsZero() {
+					byeTime = messages[i].record.Time
+				}
+			}
+
+			continue
+		}
+
+		if message.StatusCode < 200 || message.CSeqMethod() != summary.Method || !initialCSeqs[message.CSeqNumber()] {
+			continue
+		}
+
+		sent := messages[i].record.Tag == sip.DumpTagSent
+		if final == nil || sent || !finalSent {
+			final = &messages[i]
+			finalSent = sent
+		}
+	}
+
+	// only the responses are captured, e.g. the request was sent before the window
+	if summary.Method == "" {
+		summary.Method = messages[0].message.CSeqMethod()
+	}
+
+	if final == nil {
+		summary.Result = CallResultIncomplete
+		summary.FailureReason = "no final response"
+
+		return summary
+	}
+
+	summary.Status = final.message.StatusCode
+	summary.Reason = final.message.Reason
+
+	switch {
+	case summary.Status < 300 && summary.Method == methodInvite:
+		summary.Result = CallResultAnswered
+
+		if byeTime.After(final.record.Time) {
+			summary.Duration = byeTime.Sub(final.record.Time).Round(time.Millisecond).String()
+		}
+	case summary.Status < 300:
+		summary.Result = CallResultCompleted
+	case summary.Status == statusRequestTerminated && canceled:
+		summary.Result = CallResultCanceled
+	default:
+		summary.Result = CallResultFailed
+		summary.FailureReason = failureReason(final.message)
+	}
+
+	return summary
+}
+
+// failureReason is the status of the response and the text of its Reason header, e.g. the Q.850 cause
+func failureReason(response *sip.Message) string {
+	reason := strconv.Itoa(response.StatusCode) + " " + response.Reason
+
+	header := response.Header("Reason")
+	if header == "" {
+		return reason
+	}
+
+	if text := sip.HeaderParam(header, "text"); text != "" {
+		return reason + " (" + text + ")"
+	}
+
+	return reason + " (" + header + ")"
+}
+
+// callFlow returns the messages of the call for the ladder diagram
+func callFlow(messages []capturedMessage) []types.CallMessage {
+	flow := make([]types.CallMessage, 0, len(messages))
+
+	for _, captured := range messages {
+		label := captured.message.Method
+		if !captured.message.IsRequest() {
+			label = strconv.Itoa(captured.message.StatusCode) + " " + captured.message.Reason
+		}
+
+		flow = append(flow, types.CallMessage{
+			Time:      captured.record.Time,
+			Sent:      captured.record.Tag == sip.DumpTagSent,
+			Transport: captured.record.Transport,
+			Src:       net.JoinHostPort(captured.record.SrcIP.String(), strconv.Itoa(captured.record.SrcPort)),
+			Dst:       net.JoinHostPort(captured.record.DstIP.String(), strconv.Itoa(captured.record.DstPort)),
+			Label:     label,
+		})
+	}
+
+	return flow
+}
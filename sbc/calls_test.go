@@ -0,0 +1,195 @@
+package sbc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ZeljkoBenovic/tsbc/sip"
+)
+
+const testCallID = "a84b4c76e66710@sbc.test.com"
+
+var testCallStart = time.Unix(1697011200, 0)
+
+// testMessage is a captured message of the test call, at the offset from the call start
+type testMessage struct {
+	tag    string
+	offset time.Duration
+	// startLine is the request or status line, to is the To header and extra are the other headers
+	startLine string
+	cseq      string
+	to        string
+	extra     string
+}
+
+// sipdumpSample writes the messages in the Kamailio sipdump text format, the last record is cut after its
+// fields if truncate is set, like the record that Kamailio is still writing
+func sipdumpSample(messages []testMessage, truncate bool) string {
+	var dump strings.Builder
+
+	for i, msg := range messages {
+		at := testCallStart.Add(msg.offset)
+
+		fmt.Fprintf(&dump, "====================\ntag: %s\npid: 91\nprocess: 4\ntime: %d.%06d\n"+
+			"proto: udp ipv4\nsrcip: 192.168.1.10\nsrcport: 5060\ndstip: 192.168.10.2\ndstport: 5070\n",
+			msg.tag, at.Unix(), at.Nanosecond()/int(time.Microsecond))
+
+		if truncate && i == len(messages)-1 {
+			break
+		}
+
+		fmt.Fprintf(&dump, "~~~~~~~~~~~~~~~~~~~~\n%s\r\n"+
+			"Via: SIP/2.0/UDP 192.168.1.10:5060;branch=z9hG4bK%d\r\n"+
+			"From: <sip:1000@192.168.1.10>;tag=1928301774\r\n"+
+			"To: %s\r\n"+
+			"Call-ID: %s\r\n"+
+			"CSeq: %s\r\n"+
+			"%s"+
+			"Content-Length: 0\r\n\r\n"+
+			"||||||||||||||||||||\n",
+			msg.startLine, i, msg.to, testCallID, msg.cseq, msg.extra)
+	}
+
+	return dump.String()
+}
+
+// readSipdumpSample parses the records of the sample like the calls command does
+func readSipdumpSample(t *testing.T, dump string) []capturedMessage {
+	t.Helper()
+
+	reader := sip.NewDumpReader(strings.NewReader(dump))
+	messages := make([]capturedMessage, 0)
+
+	for {
+		record, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return messages
+		}
+
+		if err != nil {
+			t.Fatalf("could not read the sipdump sample: %v", err)
+		}
+
+		message, err := sip.ParseMessage(record.Data)
+		if err != nil {
+			t.Fatalf("could not parse the captured message: %v", err)
+		}
+
+		messages = append(messages, capturedMessage{record: record, message: message})
+	}
+}
+
+func TestSummarizeCall(t *testing.T) {
+	const (
+		callee   = "<sip:+381111234@sbc.test.com>"
+		answered = callee + ";tag=as6151ad25"
+	)
+
+	invite := testMessage{
+		tag: sip.DumpTagReceived, startLine: "INVITE sip:+381111234@sbc.test.com SIP/2.0", cseq: "1 INVITE", to: callee,
+	}
+	trying := testMessage{
+		tag: sip.DumpTagSent, offset: 10 * time.Millisecond, startLine: "SIP/2.0 100 Trying", cseq: "1 INVITE", to: callee,
+	}
+
+	tests := []struct {
+		name     string
+		messages []testMessage
+		truncate bool
+		result   string
+		status   int
+		duration string
+		failure  string
+	}{
+		{
+			name: "answered",
+			messages: []testMessage{
+				invite,
+				trying,
+				{tag: sip.DumpTagReceived, offset: 2 * time.Second, startLine: "SIP/2.0 200 OK", cseq: "1 INVITE",
+					to: answered},
+				{tag: sip.DumpTagSent, offset: 2*time.Second + 5*time.Millisecond, startLine: "SIP/2.0 200 OK",
+					cseq: "1 INVITE", to: answered},
+				{tag: sip.DumpTagReceived, offset: 2*time.Second + 50*time.Millisecond,
+					startLine: "ACK sip:+381111234@192.168.1.20 SIP/2.0", cseq: "1 ACK", to: answered},
+				{tag: sip.DumpTagReceived, offset: 62 * time.Second, startLine: "BYE sip:+381111234@192.168.1.20 SIP/2.0",
+					cseq: "2 BYE", to: answered},
+				{tag: sip.DumpTagSent, offset: 62*time.Second + 20*time.Millisecond, startLine: "SIP/2.0 200 OK",
+					cseq: "2 BYE", to: answered},
+			},
+			result:   CallResultAnswered,
+			status:   200,
+			duration: "59.995s",
+		},
+		{
+			name: "canceled",
+			messages: []testMessage{
+				invite,
+				trying,
+				{tag: sip.DumpTagReceived, offset: 3 * time.Second,
+					startLine: "CANCEL sip:+381111234@sbc.test.com SIP/2.0", cseq: "1 CANCEL", to: callee},
+				{tag: sip.DumpTagSent, offset: 3*time.Second + 5*time.Millisecond, startLine: "SIP/2.0 200 OK",
+					cseq: "1 CANCEL", to: callee},
+				{tag: sip.DumpTagSent, offset: 3*time.Second + 10*time.Millisecond,
+					startLine: "SIP/2.0 487 Request Terminated", cseq: "1 INVITE", to: answered},
+			},
+			result: CallResultCanceled,
+			status: 487,
+		},
+		{
+			name: "failed with a reason header",
+			messages: []testMessage{
+				invite,
+				trying,
+				// the response received from the pbx is replaced by the one sent to the caller
+				{tag: sip.DumpTagReceived, offset: time.Second, startLine: "SIP/2.0 404 Not Found", cseq: "1 INVITE",
+					to: answered},
+				{tag: sip.DumpTagSent, offset: time.Second + 5*time.Millisecond, startLine: "SIP/2.0 480 Unavailable",
+					cseq: "1 INVITE", to: answered, extra: "Reason: Q.850;cause=18;text=\"No user responding\"\r\n"},
+			},
+			result:  CallResultFailed,
+			status:  480,
+			failure: "480 Unavailable (No user responding)",
+		},
+		{
+			name: "incomplete last record",
+			messages: []testMessage{
+				invite,
+				trying,
+				{tag: sip.DumpTagSent, offset: time.Second, startLine: "SIP/2.0 200 OK", cseq: "1 INVITE", to: answered},
+			},
+			truncate: true,
+			result:   CallResultIncomplete,
+			failure:  "no final response",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages := readSipdumpSample(t, sipdumpSample(tt.messages, tt.truncate))
+
+			wantMessages := len(tt.messages)
+			if tt.truncate {
+				wantMessages--
+			}
+
+			summary := summarizeCall(testCallID, messages)
+
+			if summary.Result != tt.result || summary.Status != tt.status || summary.Duration != tt.duration ||
+				summary.FailureReason != tt.failure {
+				t.Errorf("got result %q status %d duration %q failure %q, want %q %d %q %q", summary.Result,
+					summary.Status, summary.Duration, summary.FailureReason, tt.result, tt.status, tt.duration, tt.failure)
+			}
+
+			if summary.Method != methodInvite || summary.Caller != "1000" || summary.Callee != "+381111234" ||
+				!summary.Start.Equal(testCallStart) || summary.Messages != wantMessages {
+				t.Errorf("got method %q caller %q callee %q start %s messages %d", summary.Method, summary.Caller,
+					summary.Callee, summary.Start, summary.Messages)
+			}
+		})
+	}
+}
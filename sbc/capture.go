@@ -0,0 +1,463 @@
+package sbc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ZeljkoBenovic/tsbc/jsonrpc"
+	"github.com/ZeljkoBenovic/tsbc/pcap"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/ZeljkoBenovic/tsbc/sip"
+)
+
+const (
+	// kamailioCaptureDir is the sipdump folder inside the Kamailio container
+	kamailioCaptureDir = "/tmp"
+	// captureDirName is the directory next to the database, that holds the capture directories of the sbcs
+	captureDirName = "captures"
+	// captureFilePrefix is the default file prefix of the sipdump module
+	captureFilePrefix = "kamailio-sipdump-"
+	captureFileExt    = ".data"
+
+	defaultCaptureMaxSizeMB = 1024
+	defaultCaptureMaxAge    = 7 * 24 * time.Hour
+)
+
+var (
+	ErrCaptureInvalid     = errors.New("invalid capture")
+	ErrCaptureDirNotFound = errors.New("capture directory not found")
+	ErrCaptureRPC         = errors.New("could not control the sip capture, the sipdump module must be loaded by Kamailio")
+)
+
+// sipdumpNewValue is the capture state in the sipdump.enable result, the JSON object or the kamcmd output
+var sipdumpNewValue = regexp.MustCompile(`"?newval"?:\s*(\d)`)
+
+// captureFile is a sipdump file of the capture directory
+type captureFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// StartCapture enables the sip capture of the running sbc and stores its retention,
+// the zero retention values keep the stored ones
+func (s *sbc) StartCapture(fqdnName string, retention types.CaptureRetention) error {
+	if err := s.loadDeployedSbc(fqdnName); err != nil {
+		return err
+	}
+
+	stored, err := s.captureRetention()
+	if err != nil {
+		return err
+	}
+
+	if retention.MaxSizeMB == 0 {
+		retention.MaxSizeMB = stored.MaxSizeMB
+	}
+
+	if retention.MaxAge == 0 {
+		retention.MaxAge = stored.MaxAge
+	}
+
+	if retention.MaxSizeMB < 1 || retention.MaxAge < time.Hour {
+		return fmt.Errorf("%w: the size limit must be positive and the age limit at least 1h", ErrCaptureInvalid)
+	}
+
+	if err = s.db.SaveCaptureRetention(fqdnName, retention); err != nil {
+		return err
+	}
+
+	captureDir, err := s.captureDir()
+	if err != nil {
+		return err
+	}
+
+	if _, err = s.sipdumpRPC(1); err != nil {
+		return err
+	}
+
+	s.logger.Info("SIP capture started", "fqdn", fqdnName, "dir", captureDir,
+		"max_size_mb", retention.MaxSizeMB, "max_age", retention.MaxAge)
+
+	_, err = s.pruneCaptureFiles(captureDir, retention)
+
+	return err
+}
+
+// StopCapture disables the sip capture of the running sbc, the capture files are kept
+func (s *sbc) StopCapture(fqdnName string) error {
+	if err := s.loadDeployedSbc(fqdnName); err != nil {
+		return err
+	}
+
+	retention, err := s.captureRetention()
+	if err != nil {
+		return err
+	}
+
+	captureDir, err := s.captureDir()
+	if err != nil {
+		return err
+	}
+
+	if _, err = s.sipdumpRPC(0); err != nil {
+		return err
+	}
+
+	s.logger.Info("SIP capture stopped", "fqdn", fqdnName, "dir", captureDir)
+
+	if s.sbcData.EnableSIPDump {
+		s.logger.Warn("The capture is enabled with --kamailio-sip-dump and starts again with Kamailio", "fqdn", fqdnName)
+	}
+
+	_, err = s.pruneCaptureFiles(captureDir, retention)
+
+	return err
+}
+
+// CaptureStatus returns the sip capture state of the running sbc and the usage of its capture directory
+func (s *sbc) CaptureStatus(fqdnName string) (types.CaptureStatus, error) {
+	if err := s.loadDeployedSbc(fqdnName); err != nil {
+		return types.CaptureStatus{}, err
+	}
+
+	retention, err := s.captureRetention()
+	if err != nil {
+		return types.CaptureStatus{}, err
+	}
+
+	captureDir, err := s.captureDir()
+	if err != nil {
+		return types.CaptureStatus{}, err
+	}
+
+	enabled, err := s.sipdumpRPC()
+	if err != nil {
+		return types.CaptureStatus{}, err
+	}
+
+	files, err := listCaptureFiles(captureDir)
+	if err != nil {
+		return types.CaptureStatus{}, err
+	}
+
+	status := types.CaptureStatus{
+		Fqdn:           fqdnName,
+		Enabled:        enabled,
+		EnabledOnStart: s.sbcData.EnableSIPDump,
+		Directory:      captureDir,
+		Files:          len(files),
+		MaxSizeMB:      retention.MaxSizeMB,
+		MaxAge:         retention.MaxAge.String(),
+	}
+
+	for _, file := range files {
+		status.SizeBytes += file.size
+	}
+
+	if len(files) > 0 {
+		status.Newest = files[0].modTime
+		status.Oldest = files[len(files)-1].modTime
+	}
+
+	return status, nil
+}
+
+// RotateCaptures removes the capture files of the sbc over its retention limits and returns the removed files
+func (s *sbc) RotateCaptures(fqdnName string) ([]string, error) {
+	if err := s.loadDeployedSbc(fqdnName); err != nil {
+		return nil, err
+	}
+
+	retention, err := s.captureRetention()
+	if err != nil {
+		return nil, err
+	}
+
+	captureDir, err := s.captureDir()
+	if err != nil {
+		return nil, err
+	}
+
+	removed, err := s.pruneCaptureFiles(captureDir, retention)
+	if err != nil {
+		return removed, err
+	}
+
+	s.logger.Info("Capture files rotated", "fqdn", fqdnName, "removed", len(removed))
+
+	return removed, nil
+}
+
+// ExportCapture writes the messages captured between from and to into a pcap file and returns their count.
+// All the messages are written as UDP packets, so that Wireshark decodes the decrypted TLS messages as well.
+func (s *sbc) ExportCapture(fqdnName string, from, to time.Time, fileName string) (int, error) {
+	if !from.Before(to) {
+		return 0, fmt.Errorf("%w: the export window must start before it ends", ErrCaptureInvalid)
+	}
+
+	if err := s.loadDeployedSbc(fqdnName); err != nil {
+		return 0, err
+	}
+
+	captureDir, err := s.captureDir()
+	if err != nil {
+		return 0, err
+	}
+
+	pcapFile, err := os.OpenFile(filepath.Clean(fileName), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return 0, fmt.Errorf("could not create pcap file: %w", err)
+	}
+
+	defer pcapFile.Close()
+
+	writer, err := pcap.NewWriter(pcapFile)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+
+	err = readCaptureRecords(captureDir, from, to, func(record *sip.DumpRecord) error {
+		if err := writer.WriteUDP(record.Time,
+			&net.UDPAddr{IP: record.SrcIP, Port: record.SrcPort},
+			&net.UDPAddr{IP: record.DstIP, Port: record.DstPort},
+			record.Data,
+		); err != nil {
+			return err
+		}
+
+		count++
+
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+
+	if err = pcapFile.Close(); err != nil {
+		return count, fmt.Errorf("could not write pcap file: %w", err)
+	}
+
+	s.logger.Info("Capture exported", "fqdn", fqdnName, "file", fileName, "messages", count)
+
+	return count, nil
+}
+
+// managedCaptureDir is the managed capture directory of the sbc, next to the database
+func (s *sbc) managedCaptureDir() string {
+	captureDir := filepath.Join(filepath.Dir(s.sbcData.SQLiteFileLocation), captureDirName, s.sbcData.SbcName)
+
+	// the bind mount source must be an absolute path
+	if absDir, err := filepath.Abs(captureDir); err == nil {
+		return absDir
+	}
+
+	return captureDir
+}
+
+// captureDir returns the host directory mounted as the capture directory of the Kamailio container,
+// the containers created before the managed directory use a volume or a directory of the working directory
+func (s *sbc) captureDir() (string, error) {
+	cDetails, err := s.dockerCl.ContainerInspect(s.ctx, s.sbcData.KamailioContainerID)
+	if err != nil {
+		return "", fmt.Errorf("could not inspect kamailio container: %w", err)
+	}
+
+	for _, mnt := range cDetails.Mounts {
+		if mnt.Destination != kamailioCaptureDir {
+			continue
+		}
+
+		if mnt.Source != s.managedCaptureDir() {
+			s.logger.Warn("The capture directory is not the managed one, recreate the sbc to use it",
+				"dir", mnt.Source, "managed_dir", s.managedCaptureDir())
+		}
+
+		return mnt.Source, nil
+	}
+
+	return "", fmt.Errorf("%w: kamailio container of %s has no %s mount",
+		ErrCaptureDirNotFound, s.sbcData.SbcName, kamailioCaptureDir)
+}
+
+// captureRetention returns the stored capture retention of the current sbc, or the default one
+func (s *sbc) captureRetention() (types.CaptureRetention, error) {
+	retention, err := s.db.GetCaptureRetention(s.sbcData.SbcName)
+	if err != nil {
+		return types.CaptureRetention{}, err
+	}
+
+	if retention.MaxSizeMB == 0 {
+		retention.MaxSizeMB = defaultCaptureMaxSizeMB
+	}
+
+	if retention.MaxAge == 0 {
+		retention.MaxAge = defaultCaptureMaxAge
+	}
+
+	return retention, nil
+}
+
+// sipdumpRPC sets the capture state of the running Kamailio to the value if set, and returns the current state
+func (s *sbc) sipdumpRPC(value ...int) (bool, error) {
+	params := make([]any, 0, len(value))
+	for _, v := range value {
+		params = append(params, v)
+	}
+
+	result, err := s.kamailioCommand("sipdump.enable", params...)
+
+	// the method is only missing if the sipdump module is not loaded
+	var rpcErr *jsonrpc.Error
+	if errors.As(err, &rpcErr) || errors.Is(err, ErrKamcmdFailed) {
+		return false, fmt.Errorf("%w: %s", ErrCaptureRPC, err)
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	match := sipdumpNewValue.FindStringSubmatch(result)
+	if match == nil {
+		return false, fmt.Errorf("%w: sipdump.enable: %s", ErrKamailioRPCResult, result)
+	}
+
+	return match[1] == "1", nil
+}
+
+// pruneCaptureFiles removes the capture files over the retention limits and returns the removed files
+func (s *sbc) pruneCaptureFiles(captureDir string, retention types.CaptureRetention) ([]string, error) {
+	files, err := listCaptureFiles(captureDir)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := make([]string, 0)
+
+	for _, file := range expiredCaptureFiles(files, retention, time.Now()) {
+		if err = os.Remove(file.path); err != nil {
+			return removed, fmt.Errorf("could not remove capture file: %w", err)
+		}
+
+		s.logger.Debug("Capture file removed", "file", file.path)
+
+		removed = append(removed, file.path)
+	}
+
+	return removed, nil
+}
+
+// listCaptureFiles returns the sipdump files of the directory, the newest first
+func listCaptureFiles(captureDir string) ([]captureFile, error) {
+	entries, err := os.ReadDir(captureDir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read capture directory: %w", err)
+	}
+
+	files := make([]captureFile, 0, len(entries))
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), captureFilePrefix) ||
+			!strings.HasSuffix(entry.Name(), captureFileExt) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			// the file was removed since the directory was read
+			continue
+		}
+
+		files = append(files, captureFile{
+			path:    filepath.Join(captureDir, entry.Name()),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.After(files[j].modTime)
+	})
+
+	return files, nil
+}
+
+// expiredCaptureFiles returns the files older than the age limit and the oldest files over the size limit.
+// The newest file is always kept, as Kamailio may still write to it.
+func expiredCaptureFiles(files []captureFile, retention types.CaptureRetention, now time.Time) []captureFile {
+	expired := make([]captureFile, 0)
+	maxSize := int64(retention.MaxSizeMB) * 1024 * 1024
+
+	var totalSize int64
+
+	for i, file := range files {
+		totalSize += file.size
+
+		if i > 0 && (now.Sub(file.modTime) > retention.MaxAge || totalSize > maxSize) {
+			expired = append(expired, file)
+		}
+	}
+
+	return expired
+}
+
+// readCaptureRecords calls fn with the records captured between from and to, in the order they were written
+func readCaptureRecords(captureDir string, from, to time.Time, fn func(record *sip.DumpRecord) error) error {
+	files, err := listCaptureFiles(captureDir)
+	if err != nil {
+		return err
+	}
+
+	for i := len(files) - 1; i >= 0; i-- {
+		// the files are written until their modification time, so the older ones hold no records of the window
+		if files[i].modTime.Before(from) {
+			continue
+		}
+
+		if err = readCaptureFile(files[i].path, from, to, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readCaptureFile(fileName string, from, to time.Time, fn func(record *sip.DumpRecord) error) error {
+	dumpFile, err := os.Open(filepath.Clean(fileName))
+	if err != nil {
+		return fmt.Errorf("could not open capture file: %w", err)
+	}
+
+	defer dumpFile.Close()
+
+	reader := sip.NewDumpReader(dumpFile)
+
+	for {
+		record, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(fileName), err)
+		}
+
+		if record.Time.Before(from) || record.Time.After(to) {
+			continue
+		}
+
+		if err = fn(record); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,358 @@
+package sbc
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/spf13/viper"
+)
+
+const (
+	// letsEncryptConfigDir is the certbot configuration directory inside the LetsEncrypt container,
+	// backed by the certificates volume
+	letsEncryptConfigDir = "/config/etc/letsencrypt"
+	// letsEncryptWebRoot is served by the LetsEncrypt node nginx and used for http-01 validation
+	letsEncryptWebRoot = "/config/www"
+)
+
+const (
+	reloadMethodNone    = "none"
+	reloadMethodRPC     = "rpc"
+	reloadMethodRestart = "restart"
+)
+
+var (
+	ErrCertificateNotFound  = errors.New("certificate not found")
+	ErrCertificateNotIssued = errors.New("certificate not issued")
+	ErrCertificateCheck     = errors.New("certificate check failed")
+)
+
+func (s *sbc) WatchCertificates(interval time.Duration) error {
+	// a zero interval runs a single check, which is handy for cron jobs
+	if interval == 0 {
+		return s.checkCertificateRenewals()
+	}
+
+	ctx, stop := signal.NotifyContext(s.ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	s.logger.Info("Watching certificates for renewal", "interval", interval)
+
+	for {
+		if err := s.checkCertificateRenewals(); err != nil {
+			s.logger.Error("Could not check certificate renewals", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Certificate watcher stopped")
+
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *sbc) checkCertificateRenewals() error {
+	if err := s.db.CreateFreshDB(); err != nil {
+		return fmt.Errorf("could not update database schema: %w", err)
+	}
+
+	fqdnNames, err := s.db.GetAllFqdnNames()
+	if err != nil {
+		return fmt.Errorf("could not get fqdn names: %w", err)
+	}
+
+	failed := 0
+
+	for _, fqdn := range fqdnNames {
+		if err = s.checkCertificateRenewal(fqdn); err != nil {
+			s.logger.Error("Could not check certificate", "fqdn", fqdn, "err", err)
+
+			failed++
+		}
+	}
+
+	// the other sbcs are still checked, but the single check run must report the failure
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d sbcs", ErrCertificateCheck, failed, len(fqdnNames))
+	}
+
+	return nil
+}
+
+func (s *sbc) checkCertificateRenewal(fqdn string) error {
+	cert, err := s.readLeafCertificate(fqdn)
+	if err != nil {
+		return err
+	}
+
+	serial := cert.SerialNumber.Text(16)
+
+	lastSerial, err := s.db.GetLastCertSerial(fqdn)
+	if err != nil {
+		return err
+	}
+
+	if serial == lastSerial {
+		s.logger.Debug("Certificate unchanged", "fqdn", fqdn, "serial", serial)
+
+		return nil
+	}
+
+	renewal := types.CertRenewal{
+		Fqdn:         fqdn,
+		Serial:       serial,
+		NotAfter:     cert.NotAfter,
+		Detected:     time.Now(),
+		ReloadMethod: reloadMethodNone,
+		ReloadResult: "initial certificate recorded",
+	}
+
+	var reloadErr error
+
+	// the first certificate seen is the one Kamailio was started with, so there is nothing to reload
+	if lastSerial != "" {
+		s.logger.Info("Certificate renewal detected", "fqdn", fqdn, "serial", serial, "not_after", cert.NotAfter)
+		reloadErr = s.reloadKamailioTLS(&renewal)
+	}
+
+	// the failed reload is recorded as well, so that the status shows its result
+	if err = s.db.SaveCertRenewal(renewal); err != nil {
+		return err
+	}
+
+	return reloadErr
+}
+
+// certLiveDir returns the folder, inside the LetsEncrypt container, that holds the live certificate
+func certLiveDir(certName string) string {
+	return path.Join(letsEncryptConfigDir, "live", certName)
+}
+
+// keepCertificateReason returns why the certificate of a destroyed sbc must be kept,
+// or empty string if it can be deleted
+func (s *sbc) keepCertificateReason(fqdn string, nodeEnv []string) string {
+	if s.certName(fqdn) != fqdn {
+		return "shared wildcard certificate"
+	}
+
+	if letsEncryptPrimaryDomain(nodeEnv) == fqdn {
+		return "LetsEncrypt node primary certificate"
+	}
+
+	return ""
+}
+
+// issueCertificate requests a separate certificate for the fqdn from the running LetsEncrypt node.
+// The node renews it together with its own certificate.
+func (s *sbc) issueCertificate(nodeID, fqdn string) error {
+	s.logger.Info("Issuing certificate", "fqdn", fqdn)
+
+	if err := s.runCertbotCertonly(
+		nodeID,
+		fqdn,
+		[]string{"--webroot", "--webroot-path", letsEncryptWebRoot},
+		fqdn,
+	); err != nil {
+		return err
+	}
+
+	s.logger.Info("Certificate issued", "fqdn", fqdn)
+
+	return nil
+}
+
+// runCertbotCertonly requests a certificate with the given name and domains from the LetsEncrypt node,
+// validating the domains with the given challenge arguments
+func (s *sbc) runCertbotCertonly(nodeID, certName string, challengeArgs []string, domains ...string) error {
+	staging := viper.GetBool(flagnames.Staging)
+
+	if err := s.checkRateLimits(domains, staging); err != nil {
+		return err
+	}
+
+	// the certificate is read before and after, as certbot keeps the existing one if it is still valid
+	previous, _ := s.readCertificate(nodeID, certName)
+
+	cmd := []string{
+		"certbot", "certonly",
+		"--non-interactive", "--agree-tos", "--register-unsafely-without-email",
+		// don't issue a new certificate if the existing one is still valid
+		"--keep-until-expiring",
+		"--config-dir", letsEncryptConfigDir,
+		"--cert-name", certName,
+	}
+
+	cmd = append(cmd, challengeArgs...)
+
+	for _, domain := range domains {
+		cmd = append(cmd, "--domain", domain)
+	}
+
+	if staging {
+		cmd = append(cmd, "--staging")
+	}
+
+	out, exitCode, err := s.execInContainer(nodeID, cmd)
+	if err != nil {
+		return err
+	}
+
+	if exitCode != 0 {
+		return fmt.Errorf("%w: %s", ErrCertificateNotIssued, strings.TrimSpace(string(out)))
+	}
+
+	current, err := s.readCertificate(nodeID, certName)
+	if err != nil {
+		return err
+	}
+
+	if previous == nil || previous.SerialNumber.Cmp(current.SerialNumber) != 0 {
+		s.recordIssuance(certName, domains, staging)
+	}
+
+	return nil
+}
+
+// deleteCertificate removes the fqdn certificate from the LetsEncrypt node, so it is no longer renewed.
+// The certificate of the node primary domain is kept, as the node would request it again on restart.
+func (s *sbc) deleteCertificate(fqdn string) error {
+	nodeID, err := s.db.GetLetsEncryptNodeID()
+	if err != nil {
+		return fmt.Errorf("could not get letsencrypt node id: %w", err)
+	}
+
+	if nodeID == "" {
+		return nil
+	}
+
+	nodeDetails, err := s.dockerCl.ContainerInspect(s.ctx, nodeID)
+	if err != nil {
+		return fmt.Errorf("could not inspect letsencrypt node: %w", err)
+	}
+
+	if reason := s.keepCertificateReason(fqdn, nodeDetails.Config.Env); reason != "" {
+		s.logger.Debug("Keeping the certificate", "fqdn", fqdn, "reason", reason)
+
+		return nil
+	}
+
+	out, exitCode, err := s.execInContainer(nodeID, []string{
+		"certbot", "delete", "--non-interactive",
+		"--config-dir", letsEncryptConfigDir,
+		"--cert-name", fqdn,
+	})
+	if err != nil {
+		return err
+	}
+
+	if exitCode != 0 {
+		return fmt.Errorf("could not delete certificate: %s", strings.TrimSpace(string(out)))
+	}
+
+	s.logger.Info("Certificate deleted", "fqdn", fqdn)
+
+	return nil
+}
+
+// letsEncryptPrimaryDomain returns the domain the LetsEncrypt node was started with
+func letsEncryptPrimaryDomain(envVars []string) string {
+	var url, subdomains string
+
+	for _, env := range envVars {
+		switch {
+		case strings.HasPrefix(env, "URL="):
+			url = strings.TrimPrefix(env, "URL=")
+		case strings.HasPrefix(env, "SUBDOMAINS="):
+			subdomains = strings.TrimPrefix(env, "SUBDOMAINS=")
+		}
+	}
+
+	return subdomains + "." + url
+}
+
+func (s *sbc) recordIssuance(certName string, domains []string, staging bool) {
+	if err := s.db.SaveACMEIssuance(types.ACMEIssuance{
+		CertName:         certName,
+		Domains:          domains,
+		RegisteredDomain: registeredDomain(domains[0]),
+		Staging:          staging,
+	}); err != nil {
+		s.logger.Error("Could not record ACME issuance", "cert_name", certName, "err", err)
+	}
+}
+
+// readLeafCertificate reads and parses the live sbc leaf certificate from the LetsEncrypt container
+func (s *sbc) readLeafCertificate(fqdn string) (*x509.Certificate, error) {
+	nodeID, err := s.db.GetLetsEncryptNodeID()
+	if err != nil {
+		return nil, fmt.Errorf("could not get letsencrypt node id: %w", err)
+	}
+
+	return s.readCertificate(nodeID, s.certName(fqdn))
+}
+
+// readCertificate reads and parses the live leaf certificate with the given name from the LetsEncrypt container
+func (s *sbc) readCertificate(nodeID, certName string) (*x509.Certificate, error) {
+	certPEM, exitCode, err := s.execInContainer(nodeID, []string{
+		"cat", path.Join(certLiveDir(certName), "cert.pem"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not read certificate: %w", err)
+	}
+
+	block, _ := pem.Decode(certPEM)
+	if exitCode != 0 || block == nil {
+		return nil, ErrCertificateNotFound
+	}
+
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// reloadKamailioTLS asks Kamailio to reload its certificates over RPC,
+// and restarts the Kamailio container if the reload fails, the error is returned if the restart fails as well
+func (s *sbc) reloadKamailioTLS(renewal *types.CertRenewal) error {
+	if err := s.loadDeployedSbc(renewal.Fqdn); err != nil {
+		renewal.ReloadResult = err.Error()
+
+		return err
+	}
+
+	methods, err := s.reloadKamailio([]string{"tls.reload"})
+	if err == nil {
+		s.logger.Info("Kamailio TLS reloaded", "fqdn", renewal.Fqdn)
+
+		renewal.ReloadMethod = reloadMethodRPC
+		renewal.ReloadResult = strings.Join(methods, ", ") + " succeeded"
+
+		return nil
+	}
+
+	s.logger.Warn("Could not reload Kamailio TLS, restarting container", "fqdn", renewal.Fqdn, "err", err)
+
+	renewal.ReloadMethod = reloadMethodRestart
+	renewal.ReloadResult = "container restarted"
+
+	timeOut := time.Second * 30
+	if err = s.dockerCl.ContainerRestart(s.ctx, s.sbcData.KamailioContainerID, &timeOut); err != nil {
+		renewal.ReloadResult = err.Error()
+
+		return fmt.Errorf("could not restart kamailio container: %w", err)
+	}
+
+	return nil
+}
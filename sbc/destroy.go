@@ -32,7 +32,12 @@ func (s *sbc) Destroy(fqdnName string) error {
 
 	s.logger.Info("Containers destroyed successfully")
 
-	if err := s.db.RemoveSbcInfo(viper.GetString("destroy.fqdn")); err != nil {
+	// stop renewing the certificate of the destroyed sbc
+	if err := s.deleteCertificate(fqdnName); err != nil {
+		s.logger.Error("Could not delete sbc certificate", "fqdn", fqdnName, "err", err)
+	}
+
+	if err := s.db.RemoveSbcInfo(fqdnName); err != nil {
 		return fmt.Errorf("should not remove sbc info from database: %w", err)
 	}
 
@@ -0,0 +1,44 @@
+package sbc
+
+import (
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// diffContextLines is the number of unchanged lines shown around every change
+const diffContextLines = 3
+
+// unifiedDiff returns the unified diff of the two texts, or an empty string if they are equal
+func unifiedDiff(fileName, oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(oldText),
+		B:        splitLines(newText),
+		FromFile: fileName + " (deployed)",
+		ToFile:   fileName + " (rendered)",
+		Context:  diffContextLines,
+	})
+	if err != nil {
+		// the diff is written to a strings.Builder, which does not fail
+		return ""
+	}
+
+	return diff
+}
+
+// splitLines splits the text into newline terminated lines,
+// difflib.SplitLines would add an empty line to the texts ending with a newline
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(strings.TrimSuffix(text, "\n"), "\n")
+	lines[len(lines)-1] += "\n"
+
+	return lines
+}
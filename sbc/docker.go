@@ -1,6 +1,8 @@
 package sbc
 
 import (
+	"archive/tar"
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -13,188 +15,131 @@ import (
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/strslice"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/spf13/viper"
 )
 
 type ContainerName int
 
+// kamailioConfigDir is the Kamailio configuration directory, backed by the <fqdn>-kamcfg volume
+const kamailioConfigDir = "/etc/kamailio"
+
 const (
 	KamailioContainer ContainerName = iota
 	RTPEngineContainer
 	LetsEncryptContainer
 )
 
-var ErrContainerNameNotSupported = errors.New("selected container type not supported")
+var (
+	ErrContainerNameNotSupported = errors.New("selected container type not supported")
+	ErrInvalidFqdn               = errors.New("invalid fqdn")
+)
 
 func (s *sbc) handleTLSCertificates() error {
 	s.logger.Debug("Checking if SBC TLS certificate is already created")
 
-	fqdnNames, err := s.db.GetAllFqdnNames()
-	if err != nil {
-		return fmt.Errorf("could not get fqdn names: %w", err)
-	}
-
-	if err = s.createAndRunLetsEncrypt(fqdnNames); err != nil {
-		return fmt.Errorf("could not create and run lets encrypt node: %w", err)
-	}
-
-	return nil
-}
-
-func (s *sbc) stopAllContainersAndRemoveOldTLSCertificate() error {
-	allContainers, err := s.dockerCl.ContainerList(s.ctx, types.ContainerListOptions{All: true})
-	if err != nil {
-		s.logger.Error("Could not list all containers", "err", err)
-
-		return err
-	}
-
-	le, err := s.db.GetLetsEncryptNodeID()
+	nodeID, err := s.db.GetLetsEncryptNodeID()
 	if err != nil {
-		s.logger.Error("Could not get letsencrypt node id", "err", err)
-
-		return err
+		return fmt.Errorf("could not get letsencrypt container_id: %w", err)
 	}
 
-	execID, err := s.dockerCl.ContainerExecCreate(s.ctx, le, types.ExecConfig{
-		AttachStderr: true,
-		AttachStdout: true,
-		Cmd:          []string{"/bin/bash", "-c", "rm -rf /config"},
-	})
-	if err != nil {
-		s.logger.Error("Could not create exec command", "err", err)
+	// the LetsEncrypt node issues the certificate of the first sbc by itself on startup
+	if nodeID == "" {
+		if err = s.createAndRunLetsEncrypt(s.sbcData.Fqdn, viper.GetBool(flagnames.Staging)); err != nil {
+			return fmt.Errorf("could not create and run lets encrypt node: %w", err)
+		}
 
-		return err
+		return nil
 	}
 
-	s.logger.Info("Removing existing certificates...")
+	// tenant sbcs in hosting mode use the wildcard certificate, so no new certificate is needed
+	if certName := s.certName(s.sbcData.Fqdn); certName != s.sbcData.Fqdn {
+		s.logger.Info("Using wildcard certificate", "fqdn", s.sbcData.Fqdn, "cert_name", certName)
 
-	resp, err := s.dockerCl.ContainerExecAttach(s.ctx, execID.ID, types.ExecStartCheck{})
-	if err != nil {
-		s.logger.Error("Could not get exec response", "err", err)
-
-		return err
+		return nil
 	}
 
-	defer resp.Close()
-
-	data, _ := io.ReadAll(resp.Reader)
-	s.logger.Debug("Docker exec result", "result", string(data))
-
-	for _, id := range allContainers {
-		if err := s.dockerCl.ContainerStop(s.ctx, id.ID, nil); err != nil {
-			s.logger.Error("Could not stop container", "id", id)
-
-			return err
-		}
+	// every other sbc gets its own certificate issued by the running LetsEncrypt node
+	if err = s.issueCertificate(nodeID, s.sbcData.Fqdn); err != nil {
+		return fmt.Errorf("could not issue certificate: %w", err)
 	}
 
 	return nil
 }
 
-func (s *sbc) startAllContainers() {
-	allContainers, err := s.dockerCl.ContainerList(s.ctx, types.ContainerListOptions{All: true})
-	if err != nil {
-		s.logger.Error("Could not list all containers", "err", err)
+func (s *sbc) createAndRunLetsEncrypt(fqdn string, staging bool) error {
+	fqdnSplitByDot := strings.SplitN(fqdn, ".", 2)
+	if len(fqdnSplitByDot) != 2 {
+		return fmt.Errorf("%w: %s", ErrInvalidFqdn, fqdn)
 	}
 
-	for _, id := range allContainers {
-		if err = s.dockerCl.ContainerStart(s.ctx, id.ID, types.ContainerStartOptions{}); err != nil {
-			s.logger.Error("Could not start container", "id", id)
-		}
+	if err := s.checkRateLimits([]string{fqdn}, staging); err != nil {
+		return err
+	}
+
+	if err := s.createAndRunContainer(LetsEncryptContainer, letsEncryptEnvVars(fqdnSplitByDot, staging)); err != nil {
+		return err
 	}
-}
 
-func (s *sbc) removeLetsEncryptNode() error {
 	nodeID, err := s.db.GetLetsEncryptNodeID()
 	if err != nil {
-		return fmt.Errorf("could not get letsencrypt container_id: %w", err)
+		return fmt.Errorf("could not get letsencrypt node id: %w", err)
 	}
 
-	if nodeID != "" {
-		// stop all containers
-		if err = s.stopAllContainersAndRemoveOldTLSCertificate(); err != nil {
-			return err
-		}
-
-		// remove container
-		if err = s.dockerCl.ContainerRemove(s.ctx, nodeID, types.ContainerRemoveOptions{
-			Force:         true,
-			RemoveVolumes: true,
-		}); err != nil {
-			return fmt.Errorf("could not remove letsencrypt container: %w", err)
-		}
-
-		// remove database entry
-		if err = s.db.RemoveLetsEncryptInfo(nodeID); err != nil {
-			return fmt.Errorf("could not remove letsencrypt database info: %w", err)
-		}
-	}
+	// the node issues its primary certificate on startup, unless the certificates volume holds a valid one
+	s.recordStartupIssuance(nodeID, fqdn, staging)
 
 	return nil
 }
 
-func (s *sbc) createAndRunLetsEncrypt(fqdnNames []string) error {
-	// remove the current letsencrypt container
-	if err := s.removeLetsEncryptNode(); err != nil {
-		return err
-	}
-
-	firstFqdnSplitByDot := strings.Split(fqdnNames[0], ".")
-
-	extraDomains := ""
-	if len(fqdnNames) > 1 {
-		extraDomains = strings.Join(fqdnNames[1:], ",")
-	}
-
-	envVars := []string{
+// letsEncryptEnvVars returns the environment variables for the LetsEncrypt container,
+// the first fqdn label is the subdomain and the rest is the domain
+func letsEncryptEnvVars(fqdnSplitByDot []string, staging bool) []string {
+	return []string{
 		fmt.Sprintf("PUID=1000"),
 		fmt.Sprintf("PGID=1000"),
 		fmt.Sprintf(fmt.Sprintf("TZ=%s", viper.GetString(flagnames.Timezone))),
 		fmt.Sprintf("VALIDATION=http"),
-		fmt.Sprintf("URL=%s", firstFqdnSplitByDot[1]+"."+firstFqdnSplitByDot[2]),
-		fmt.Sprintf("SUBDOMAINS=%s", firstFqdnSplitByDot[0]),
+		fmt.Sprintf("URL=%s", fqdnSplitByDot[1]),
+		fmt.Sprintf("SUBDOMAINS=%s", fqdnSplitByDot[0]),
 		fmt.Sprintf("ONLY_SUBDOMAINS=true"),
-		fmt.Sprintf("EXTRA_DOMAINS=%s", extraDomains),
-		fmt.Sprintf(fmt.Sprintf("STAGING=%s", viper.GetString(flagnames.Staging))),
+		fmt.Sprintf("STAGING=%t", staging),
 	}
+}
 
-	if err := s.createAndRunContainer(LetsEncryptContainer, envVars); err != nil {
-		return err
+func (s *sbc) createAndRunSbcInfra() error {
+	if err := s.createAndRunContainer(RTPEngineContainer, s.rtpEngineEnvVars()); err != nil {
+		return fmt.Errorf("could not run rtp-engine container err=%w", err)
 	}
 
-	s.startAllContainers()
+	if err := s.createAndRunContainer(KamailioContainer, s.kamailioEnvVars()); err != nil {
+		return fmt.Errorf("could not run kamailio container err=%w", err)
+	}
 
 	return nil
 }
 
-func (s *sbc) createAndRunSbcInfra() error {
-	// environment variables for RTP Engine container
-	rtpEngEnvVars := []string{
+// rtpEngineEnvVars returns the environment variables for RTP Engine container
+func (s *sbc) rtpEngineEnvVars() []string {
+	return []string{
 		fmt.Sprintf("RTP_MAX=%s", s.sbcData.RTPMaxPort),
 		fmt.Sprintf("RTP_MIN=%s", s.sbcData.RTPMinPort),
 		fmt.Sprintf("MEDIA_PUB_IP=%s", s.sbcData.MediaPublicIP),
 		fmt.Sprintf("NG_LISTEN=%s", s.sbcData.NgListen),
 	}
+}
 
-	fqdnNames, err := s.db.GetAllFqdnNames()
-	if err != nil {
-		s.logger.Error("Could not get the list of fqdn names")
-
-		return err
-	}
-
-	// the first fqdn name will always be the folder for all certificates
-	certFolderName := fqdnNames[0]
-
-	// environment variables for Kamailio container
-	kamailioEnvVars := []string{
-		fmt.Sprintf("NEW_CONFIG=%t", s.sbcData.NewConfig),
+// kamailioEnvVars returns the environment variables for Kamailio container
+func (s *sbc) kamailioEnvVars() []string {
+	return []string{
+		// the configuration is rendered by tsbc, so the container must not generate its own
+		"NEW_CONFIG=false",
 		fmt.Sprintf("EN_SIPDUMP=%t", s.sbcData.EnableSIPDump),
 		fmt.Sprintf("ADVERTISE_IP=%s", s.sbcData.SbcName),
 		fmt.Sprintf("ALIAS=%s", s.sbcData.SbcName),
 		fmt.Sprintf("SBC_NAME=%s", s.sbcData.SbcName),
-		fmt.Sprintf("CERT_FOLDER_NAME=%s", certFolderName),
+		// every sbc has its own certificate folder, except for tenants sharing a wildcard certificate
+		fmt.Sprintf("CERT_FOLDER_NAME=%s", s.certName(s.sbcData.SbcName)),
 		fmt.Sprintf("SBC_PORT=%s", s.sbcData.SbcTLSPort),
 		// TODO: store this in the DB and fetch it from there
 		fmt.Sprintf("HOST_IP=%s", viper.GetString(flagnames.HostIP)),
@@ -205,27 +150,87 @@ func (s *sbc) createAndRunSbcInfra() error {
 		fmt.Sprintf("RTP_ENG_IP=%s", viper.GetString(flagnames.HostIP)),
 		fmt.Sprintf("RTP_ENG_PORT=%s", s.sbcData.RTPEnginePort),
 	}
+}
 
-	if err = s.createAndRunContainer(RTPEngineContainer, rtpEngEnvVars); err != nil {
-		return fmt.Errorf("could not run rtp-engine container err=%w", err)
+// execInContainer runs the command inside a running container and returns its stdout and exit code
+func (s *sbc) execInContainer(containerID string, cmd []string) ([]byte, int, error) {
+	return s.execInContainerWithEnv(containerID, cmd, nil)
+}
+
+// execInContainerWithEnv runs the command with additional environment variables,
+// which keeps secrets out of the command arguments
+func (s *sbc) execInContainerWithEnv(containerID string, cmd, envVars []string) ([]byte, int, error) {
+	execID, err := s.dockerCl.ContainerExecCreate(s.ctx, containerID, types.ExecConfig{
+		AttachStderr: true,
+		AttachStdout: true,
+		Env:          envVars,
+		Cmd:          cmd,
+	})
+	if err != nil {
+		return nil, -1, fmt.Errorf("could not create exec command: %w", err)
 	}
 
-	if err = s.createAndRunContainer(KamailioContainer, kamailioEnvVars); err != nil {
-		return fmt.Errorf("could not run kamailio container err=%w", err)
+	resp, err := s.dockerCl.ContainerExecAttach(s.ctx, execID.ID, types.ExecStartCheck{})
+	if err != nil {
+		return nil, -1, fmt.Errorf("could not attach to exec command: %w", err)
 	}
 
-	return nil
+	defer resp.Close()
+
+	var stdout, stderr bytes.Buffer
+
+	if _, err = stdcopy.StdCopy(&stdout, &stderr, resp.Reader); err != nil {
+		return nil, -1, fmt.Errorf("could not read exec output: %w", err)
+	}
+
+	s.logger.Debug("Docker exec result", "cmd", cmd, "stderr", stderr.String())
+
+	execDetails, err := s.dockerCl.ContainerExecInspect(s.ctx, execID.ID)
+	if err != nil {
+		return nil, -1, fmt.Errorf("could not inspect exec command: %w", err)
+	}
+
+	return stdout.Bytes(), execDetails.ExitCode, nil
 }
 
-func (s *sbc) createAndRunContainer(contName ContainerName, envVars []string) error {
-	// define container custom parameters
-	containerParams := struct {
-		imageName               string
-		containerName           string
-		dbTableName             string
-		rowID                   int64
-		dockerDefaultHostConfig *container.HostConfig
-	}{
+// copyFileToContainer writes the content as a file with the given name and mode into a container directory
+func (s *sbc) copyFileToContainer(containerID, dstDir, name string, content []byte, mode int64) error {
+	var buff bytes.Buffer
+
+	tw := tar.NewWriter(&buff)
+
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    mode,
+		Size:    int64(len(content)),
+		ModTime: time.Now(),
+	}); err != nil {
+		return fmt.Errorf("could not write tar header: %w", err)
+	}
+
+	if _, err := tw.Write(content); err != nil {
+		return fmt.Errorf("could not write tar content: %w", err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("could not close tar writer: %w", err)
+	}
+
+	return s.dockerCl.CopyToContainer(s.ctx, containerID, dstDir, &buff, types.CopyToContainerOptions{})
+}
+
+// containerConfig holds the custom parameters of a container
+type containerConfig struct {
+	imageName               string
+	containerName           string
+	dbTableName             string
+	dockerDefaultHostConfig *container.HostConfig
+}
+
+// newContainerConfig returns the image, name and host config of the container,
+// host directories for bind mounts are created only if createDirs is set
+func (s *sbc) newContainerConfig(contName ContainerName, createDirs bool) (containerConfig, error) {
+	containerParams := containerConfig{
 		dockerDefaultHostConfig: &container.HostConfig{
 			NetworkMode: "host",
 			AutoRemove:  false,
@@ -241,12 +246,11 @@ func (s *sbc) createAndRunContainer(contName ContainerName, envVars []string) er
 		containerParams.imageName = viper.GetString(flagnames.KamailioImage)
 		containerParams.containerName = s.sbcData.SbcName + "-kamailio"
 		containerParams.dbTableName = "kamailio"
-		containerParams.rowID = s.db.GetKamailioInsertID(s.sbcData.SbcName)
 		containerParams.dockerDefaultHostConfig.Mounts = []mount.Mount{
 			{
 				Type:   mount.TypeVolume,
 				Source: s.sbcData.SbcName + "-kamcfg",
-				Target: "/etc/kamailio",
+				Target: kamailioConfigDir,
 			},
 			{
 				Type:   mount.TypeVolume,
@@ -256,13 +260,12 @@ func (s *sbc) createAndRunContainer(contName ContainerName, envVars []string) er
 		}
 		containerParams.dockerDefaultHostConfig.Mounts = append(
 			containerParams.dockerDefaultHostConfig.Mounts,
-			s.handleSIPDumpVolume())
+			s.handleSIPDumpVolume(createDirs))
 
 	case RTPEngineContainer:
 		containerParams.imageName = viper.GetString(flagnames.RTPImage)
 		containerParams.containerName = s.sbcData.SbcName + "-rtp-engine"
 		containerParams.dbTableName = "rtp_engine"
-		containerParams.rowID = s.db.GetRTPEngineInsertID(s.sbcData.SbcName)
 		containerParams.dockerDefaultHostConfig.Mounts = []mount.Mount{
 			{
 				Type:   mount.TypeVolume,
@@ -275,27 +278,80 @@ func (s *sbc) createAndRunContainer(contName ContainerName, envVars []string) er
 		containerParams.imageName = "linuxserver/swag"
 		containerParams.containerName = "certificates-handler"
 		containerParams.dbTableName = "letsencrypt"
-		// table index will always be the same, as we have only one instance,
-		// and it gets replaced everytime
-		containerParams.rowID = 1
 		containerParams.dockerDefaultHostConfig.Mounts = []mount.Mount{
 			{
 				Type:   mount.TypeVolume,
 				Source: "certificates",
-				Target: "/config/etc/letsencrypt",
+				Target: letsEncryptConfigDir,
 			},
 		}
 		containerParams.dockerDefaultHostConfig.CapAdd = strslice.StrSlice{"NET_ADMIN"}
 
 	default:
-		return ErrContainerNameNotSupported
+		return containerConfig{}, ErrContainerNameNotSupported
+	}
+
+	return containerParams, nil
+}
+
+func (s *sbc) createAndRunContainer(contName ContainerName, envVars []string) error {
+	containerID, containerName, err := s.createContainer(contName, envVars)
+	if err != nil {
+		return err
+	}
+
+	if contName == KamailioContainer && s.sbcData.NewConfig {
+		if err = s.renderAndWriteKamailioConfig(containerID); err != nil {
+			return err
+		}
+	}
+
+	if contName == KamailioContainer {
+		s.logger.Info("Starting kamailio...")
+		s.logger.Debug("Sleeping kamailio container deployment due to the certificate generation")
+		time.Sleep(30 * time.Second)
+	}
+
+	if err = s.dockerCl.ContainerStart(s.ctx, containerID, types.ContainerStartOptions{}); err != nil {
+		s.logger.Error("Could not start container",
+			"id", containerID,
+			"image", containerName,
+			"err", err.Error())
+	}
+
+	s.logger.Info("Container started",
+		"image_name", containerName,
+		"container_id", containerID)
+
+	return nil
+}
+
+// createContainer pulls the image, creates the container without starting it and saves its id,
+// it returns the id and the name of the created container
+func (s *sbc) createContainer(contName ContainerName, envVars []string) (string, string, error) {
+	containerParams, err := s.newContainerConfig(contName, true)
+	if err != nil {
+		return "", "", err
+	}
+
+	var rowID int64
+
+	switch contName {
+	case KamailioContainer:
+		rowID = s.db.GetKamailioInsertID(s.sbcData.SbcName)
+	case RTPEngineContainer:
+		rowID = s.db.GetRTPEngineInsertID(s.sbcData.SbcName)
+	case LetsEncryptContainer:
+		// table index will always be the same, as we have only one instance,
+		// and it gets replaced everytime
+		rowID = 1
 	}
 
 	reader, err := s.dockerCl.ImagePull(s.ctx, containerParams.imageName, types.ImagePullOptions{})
 	if err != nil {
 		s.logger.Error("Could not pull docker image", "image", containerParams.imageName, "err", err)
 
-		return err
+		return "", "", err
 	}
 
 	defer reader.Close()
@@ -310,66 +366,41 @@ func (s *sbc) createAndRunContainer(contName ContainerName, envVars []string) er
 	if err != nil {
 		s.logger.Error("Could not create new container", "image", containerParams.containerName, "err", err)
 
-		return err
+		return "", "", err
 	}
 
-	if err = s.db.SaveContainerID(containerParams.rowID, containerParams.dbTableName, resp.ID); err != nil {
+	if err = s.db.SaveContainerID(rowID, containerParams.dbTableName, resp.ID); err != nil {
 		s.logger.Error("Could not save container ID", "err", err)
 
-		return err
-	}
-
-	if contName == KamailioContainer {
-		s.logger.Info("Starting kamailio...")
-		s.logger.Debug("Sleeping kamailio container deployment due to the certificate generation")
-		time.Sleep(30 * time.Second)
-	}
-
-	if err = s.dockerCl.ContainerStart(s.ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
-		s.logger.Error("Could not start container",
-			"id", resp.ID,
-			"image", containerParams.containerName,
-			"err", err.Error())
+		return "", "", err
 	}
 
-	s.logger.Info("Container started",
-		"image_name", containerParams.containerName,
-		"container_id", resp.ID)
-
-	return nil
+	return resp.ID, containerParams.containerName, nil
 }
 
-// handleSIPDumpVolume returns a bind mount or regular docker volume based on sip-dump flag
-func (s *sbc) handleSIPDumpVolume() mount.Mount {
-	sipDumpMount := mount.Mount{
-		Type:   mount.TypeVolume,
-		Source: s.sbcData.SbcName + "-sipdump",
-		Target: "/tmp",
+// handleSIPDumpVolume returns the bind mount of the managed capture directory,
+// or a regular docker volume if the directory can not be created
+func (s *sbc) handleSIPDumpVolume(createDir bool) mount.Mount {
+	captureMount := mount.Mount{
+		Type:   mount.TypeBind,
+		Source: s.managedCaptureDir(),
+		Target: kamailioCaptureDir,
 	}
 
-	if s.sbcData.EnableSIPDump {
-		currentDir, err := os.Getwd()
-		if err != nil {
-			s.logger.Debug("Could not get current working directory, setting path to /tmp")
-
-			currentDir = "/tmp"
-		}
-
-		bindPath := currentDir + "/sipdump/" + s.sbcData.SbcName
-		if err = os.MkdirAll(bindPath, 755); err != nil {
-			s.logger.Error("Could not create directory using regular docker volume",
-				"dir", bindPath, "err", err)
+	if !createDir {
+		return captureMount
+	}
 
-			// if the folder creation fails create just the regular volume mount
-			return sipDumpMount
-		}
+	if err := os.MkdirAll(captureMount.Source, 0750); err != nil {
+		s.logger.Error("Could not create capture directory using regular docker volume",
+			"dir", captureMount.Source, "err", err)
 
-		sipDumpMount = mount.Mount{
-			Type:   mount.TypeBind,
-			Source: bindPath,
-			Target: "/tmp",
+		return mount.Mount{
+			Type:   mount.TypeVolume,
+			Source: s.sbcData.SbcName + "-sipdump",
+			Target: kamailioCaptureDir,
 		}
 	}
 
-	return sipDumpMount
+	return captureMount
 }
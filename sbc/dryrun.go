@@ -0,0 +1,318 @@
+package sbc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/docker/docker/client"
+	"github.com/spf13/viper"
+)
+
+// PlanRun returns the changes Run would make with the current flags
+func (s *sbc) PlanRun() (types.DryRunPlan, error) {
+	plan := newDryRunPlan("run")
+
+	schemaExists, err := s.db.SchemaExists()
+	if err != nil {
+		return plan, fmt.Errorf("could not check database schema: %w", err)
+	}
+
+	if !schemaExists {
+		plan.Database = append(plan.Database, "create the database schema")
+	}
+
+	sbcData, err := s.db.NextSBCParameters()
+	if err != nil {
+		return plan, fmt.Errorf("could not get SBC parameters: %w", err)
+	}
+
+	s.sbcData = sbcData
+	plan.Fqdn = sbcData.Fqdn
+	plan.Sbc = &sbcData
+	plan.Database = append(plan.Database, "insert kamailio, rtp_engine and sbc_info records")
+
+	if schemaExists {
+		deployedFqdns, err := s.db.GetAllFqdnNames()
+		if err != nil {
+			return plan, fmt.Errorf("could not get SBC names: %w", err)
+		}
+
+		for _, fqdn := range deployedFqdns {
+			if fqdn == sbcData.Fqdn {
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("sbc %s is already deployed", fqdn))
+			}
+		}
+	}
+
+	if err = s.planTLSCertificates(&plan, schemaExists); err != nil {
+		return plan, err
+	}
+
+	if err = s.planSbcInfra(&plan); err != nil {
+		return plan, err
+	}
+
+	return plan, nil
+}
+
+// PlanRecreate returns the changes Recreate would make
+func (s *sbc) PlanRecreate(fqdnName string) (types.DryRunPlan, error) {
+	plan := newDryRunPlan("recreate")
+	plan.Fqdn = fqdnName
+
+	sbcData, err := s.deployedSbc(fqdnName)
+	if err != nil {
+		return plan, err
+	}
+
+	s.sbcData = sbcData
+	plan.Sbc = &sbcData
+
+	for _, containerID := range s.db.GetContainerIDsFromSbcFqdn(fqdnName) {
+		plannedContainer, _ := s.plannedContainerFromID(containerID, &plan)
+		plan.RemoveContainers = append(plan.RemoveContainers, plannedContainer)
+	}
+
+	if err = s.planTLSCertificates(&plan, true); err != nil {
+		return plan, err
+	}
+
+	if err = s.planSbcInfra(&plan); err != nil {
+		return plan, err
+	}
+
+	plan.Database = append(plan.Database, "update the container ids in kamailio and rtp_engine records")
+
+	return plan, nil
+}
+
+// PlanDestroy returns the changes Destroy would make
+func (s *sbc) PlanDestroy(fqdnName string) (types.DryRunPlan, error) {
+	plan := newDryRunPlan("destroy")
+	plan.Fqdn = fqdnName
+
+	sbcData, err := s.deployedSbc(fqdnName)
+	if err != nil {
+		return plan, err
+	}
+
+	plan.Sbc = &sbcData
+
+	for _, containerID := range s.db.GetContainerIDsFromSbcFqdn(fqdnName) {
+		plannedContainer, volumes := s.plannedContainerFromID(containerID, &plan)
+		plan.RemoveContainers = append(plan.RemoveContainers, plannedContainer)
+
+		for _, volume := range volumes {
+			// mirror destroyContainerWithVolumes, which keeps the certificates volume
+			if volume == "certificates" && !viper.GetBool("destroy.tls-node") {
+				continue
+			}
+
+			plan.RemoveVolumes = append(plan.RemoveVolumes, volume)
+		}
+	}
+
+	nodeID, err := s.db.GetLetsEncryptNodeID()
+	if err != nil {
+		return plan, fmt.Errorf("could not get letsencrypt node id: %w", err)
+	}
+
+	if nodeID == "" {
+		plan.Certificate = "no LetsEncrypt node, nothing to delete"
+	} else if nodeDetails, err := s.dockerCl.ContainerInspect(s.ctx, nodeID); err != nil {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("could not inspect LetsEncrypt node: %s", err))
+	} else {
+		plan.Certificate = fmt.Sprintf("delete certificate %s from the LetsEncrypt node", fqdnName)
+
+		if reason := s.keepCertificateReason(fqdnName, nodeDetails.Config.Env); reason != "" {
+			plan.Certificate = fmt.Sprintf("keep certificate %s: %s", s.certName(fqdnName), reason)
+		}
+	}
+
+	plan.Database = append(plan.Database, "delete sbc_info, kamailio and rtp_engine records")
+
+	return plan, nil
+}
+
+// PlanDestroyLetsEncryptNode returns the changes DestroyLetsEncryptNode would make
+func (s *sbc) PlanDestroyLetsEncryptNode() (types.DryRunPlan, error) {
+	plan := newDryRunPlan("destroy")
+	plan.Fqdn = letsEncryptStatusName
+
+	nodeID, err := s.db.GetLetsEncryptNodeID()
+	if err != nil {
+		return plan, fmt.Errorf("could not get LetsEncrypt node id: %w", err)
+	}
+
+	if nodeID == "" {
+		plan.Warnings = append(plan.Warnings, "no LetsEncrypt node deployed")
+
+		return plan, nil
+	}
+
+	plannedContainer, volumes := s.plannedContainerFromID(nodeID, &plan)
+	plan.RemoveContainers = append(plan.RemoveContainers, plannedContainer)
+	plan.RemoveVolumes = append(plan.RemoveVolumes, volumes...)
+	plan.Certificate = "all certificates are deleted with the certificates volume"
+	plan.Database = append(plan.Database, "delete letsencrypt record")
+
+	return plan, nil
+}
+
+func newDryRunPlan(command string) types.DryRunPlan {
+	return types.DryRunPlan{
+		Command:          command,
+		RemoveContainers: make([]types.PlannedContainer, 0),
+		CreateContainers: make([]types.PlannedContainer, 0),
+		RemoveVolumes:    make([]string, 0),
+		Database:         make([]string, 0),
+		Warnings:         make([]string, 0),
+	}
+}
+
+// deployedSbc returns the stored sbc configuration, or an error if the sbc is not deployed
+func (s *sbc) deployedSbc(fqdnName string) (types.Sbc, error) {
+	schemaExists, err := s.db.SchemaExists()
+	if err != nil {
+		return types.Sbc{}, fmt.Errorf("could not check database schema: %w", err)
+	}
+
+	if !schemaExists {
+		return types.Sbc{}, fmt.Errorf("%w: %s", ErrSbcNotFound, fqdnName)
+	}
+
+	sbcData, err := s.db.GetSBCParameters(s.db.GetSBCIdFromFqdn(fqdnName))
+	if err != nil {
+		return types.Sbc{}, fmt.Errorf("could not get sbc parameters: %w", err)
+	}
+
+	if sbcData.Fqdn == "" {
+		return types.Sbc{}, fmt.Errorf("%w: %s", ErrSbcNotFound, fqdnName)
+	}
+
+	return sbcData, nil
+}
+
+// planTLSCertificates mirrors handleTLSCertificates
+func (s *sbc) planTLSCertificates(plan *types.DryRunPlan, schemaExists bool) error {
+	var (
+		nodeID  string
+		err     error
+		staging = viper.GetBool(flagnames.Staging)
+		fqdn    = s.sbcData.Fqdn
+	)
+
+	if schemaExists {
+		nodeID, err = s.db.GetLetsEncryptNodeID()
+		if err != nil {
+			return fmt.Errorf("could not get letsencrypt container_id: %w", err)
+		}
+	}
+
+	switch certName := s.certName(fqdn); {
+	case nodeID == "":
+		fqdnSplitByDot := strings.SplitN(fqdn, ".", 2)
+		if len(fqdnSplitByDot) != 2 {
+			return fmt.Errorf("%w: %s", ErrInvalidFqdn, fqdn)
+		}
+
+		if err = s.planContainer(plan, LetsEncryptContainer, letsEncryptEnvVars(fqdnSplitByDot, staging)); err != nil {
+			return err
+		}
+
+		plan.Certificate = fmt.Sprintf("issued for %s by the new LetsEncrypt node on startup", fqdn)
+	case certName != fqdn:
+		plan.Certificate = fmt.Sprintf("shared wildcard certificate %s", certName)
+
+		return nil
+	default:
+		plan.Certificate = fmt.Sprintf("issued for %s with certbot in the LetsEncrypt node", fqdn)
+	}
+
+	if staging {
+		plan.Certificate += " (staging)"
+	}
+
+	// the rate limit counters are stored in the database
+	if schemaExists {
+		if err = s.checkRateLimits([]string{fqdn}, staging); err != nil {
+			plan.Warnings = append(plan.Warnings, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// planSbcInfra mirrors createAndRunSbcInfra
+func (s *sbc) planSbcInfra(plan *types.DryRunPlan) error {
+	if viper.GetString(flagnames.HostIP) == "" {
+		plan.Warnings = append(plan.Warnings, "host ip not set, Kamailio HOST_IP and RTP_ENG_IP will be empty")
+	}
+
+	if err := s.planContainer(plan, RTPEngineContainer, s.rtpEngineEnvVars()); err != nil {
+		return err
+	}
+
+	return s.planContainer(plan, KamailioContainer, s.kamailioEnvVars())
+}
+
+func (s *sbc) planContainer(plan *types.DryRunPlan, contName ContainerName, envVars []string) error {
+	containerParams, err := s.newContainerConfig(contName, false)
+	if err != nil {
+		return err
+	}
+
+	plannedContainer := types.PlannedContainer{
+		Name:   containerParams.containerName,
+		Image:  containerParams.imageName,
+		Env:    envVars,
+		Mounts: make([]string, 0, len(containerParams.dockerDefaultHostConfig.Mounts)),
+	}
+
+	for _, m := range containerParams.dockerDefaultHostConfig.Mounts {
+		plannedContainer.Mounts = append(plannedContainer.Mounts, fmt.Sprintf("%s %s:%s", m.Type, m.Source, m.Target))
+	}
+
+	plan.CreateContainers = append(plan.CreateContainers, plannedContainer)
+
+	return nil
+}
+
+// plannedContainerFromID returns the existing container and the names of its volumes
+func (s *sbc) plannedContainerFromID(containerID string, plan *types.DryRunPlan) (types.PlannedContainer, []string) {
+	plannedContainer := types.PlannedContainer{
+		ID:     containerID,
+		Mounts: make([]string, 0),
+	}
+
+	cDetails, err := s.dockerCl.ContainerInspect(s.ctx, containerID)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			plannedContainer.Name = containerStateMissing
+		} else {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("could not inspect container %s: %s", containerID, err))
+		}
+
+		return plannedContainer, nil
+	}
+
+	plannedContainer.Name = strings.TrimPrefix(cDetails.Name, "/")
+	plannedContainer.Image = cDetails.Config.Image
+
+	volumes := make([]string, 0)
+
+	for _, m := range cDetails.Mounts {
+		if m.Name != "" {
+			plannedContainer.Mounts = append(plannedContainer.Mounts, fmt.Sprintf("%s %s:%s", m.Type, m.Name, m.Destination))
+			volumes = append(volumes, m.Name)
+
+			continue
+		}
+
+		plannedContainer.Mounts = append(plannedContainer.Mounts, fmt.Sprintf("%s %s:%s", m.Type, m.Source, m.Destination))
+	}
+
+	return plannedContainer, volumes
+}
@@ -0,0 +1,107 @@
+package sbc
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// certificateFiles are exported from the live certificate folder, together with their file modes
+var certificateFiles = []struct {
+	name string
+	mode os.FileMode
+}{
+	{name: "fullchain.pem", mode: 0644},
+	{name: "cert.pem", mode: 0644},
+	{name: "chain.pem", mode: 0644},
+	{name: "privkey.pem", mode: 0600},
+}
+
+// ExportCertificate copies the live sbc certificate, chain and private key to the output directory.
+// If the PKCS#12 password is set, a password protected PKCS#12 bundle is exported as well.
+func (s *sbc) ExportCertificate(fqdnName, outDir, pkcs12Password string) error {
+	nodeID, err := s.db.GetLetsEncryptNodeID()
+	if err != nil {
+		return fmt.Errorf("could not get letsencrypt node id: %w", err)
+	}
+
+	if nodeID == "" {
+		return ErrLetsEncryptNodeNotFound
+	}
+
+	liveDir := certLiveDir(s.certName(fqdnName))
+
+	// the output directory holds a private key, so only the owner can access it
+	if err = os.MkdirAll(outDir, 0700); err != nil {
+		return fmt.Errorf("could not create output directory: %w", err)
+	}
+
+	for _, certFile := range certificateFiles {
+		content, exitCode, err := s.execInContainer(nodeID, []string{"cat", path.Join(liveDir, certFile.name)})
+		if err != nil {
+			return fmt.Errorf("could not read %s: %w", certFile.name, err)
+		}
+
+		if exitCode != 0 {
+			return fmt.Errorf("%w: %s", ErrCertificateNotFound, path.Join(liveDir, certFile.name))
+		}
+
+		if err = writeFileWithMode(filepath.Join(outDir, certFile.name), content, certFile.mode); err != nil {
+			return err
+		}
+
+		s.logger.Debug("Certificate file exported", "file", certFile.name)
+	}
+
+	if pkcs12Password != "" {
+		if err = s.exportPKCS12(nodeID, liveDir, fqdnName, outDir, pkcs12Password); err != nil {
+			return err
+		}
+	}
+
+	s.logger.Info("Certificate exported", "fqdn", fqdnName, "dir", outDir)
+
+	return nil
+}
+
+// exportPKCS12 bundles the certificate, chain and private key with openssl inside the LetsEncrypt container
+func (s *sbc) exportPKCS12(nodeID, liveDir, fqdnName, outDir, password string) error {
+	bundle, exitCode, err := s.execInContainerWithEnv(nodeID, []string{
+		"openssl", "pkcs12", "-export",
+		"-in", path.Join(liveDir, "cert.pem"),
+		"-inkey", path.Join(liveDir, "privkey.pem"),
+		"-certfile", path.Join(liveDir, "chain.pem"),
+		"-name", fqdnName,
+		"-passout", "env:PKCS12_PASSWORD",
+	}, []string{"PKCS12_PASSWORD=" + password})
+	if err != nil {
+		return fmt.Errorf("could not create pkcs12 bundle: %w", err)
+	}
+
+	if exitCode != 0 {
+		return fmt.Errorf("could not create pkcs12 bundle: openssl exited with code %d", exitCode)
+	}
+
+	bundleName := fqdnName + ".p12"
+	if err = writeFileWithMode(filepath.Join(outDir, bundleName), bundle, 0600); err != nil {
+		return err
+	}
+
+	s.logger.Debug("PKCS#12 bundle exported", "file", bundleName)
+
+	return nil
+}
+
+// writeFileWithMode writes the file and sets its mode, even if the file already existed
+func writeFileWithMode(fileName string, content []byte, mode os.FileMode) error {
+	if err := os.WriteFile(fileName, content, mode); err != nil {
+		return fmt.Errorf("could not write %s: %w", fileName, err)
+	}
+
+	if err := os.Chmod(fileName, mode); err != nil {
+		return fmt.Errorf("could not set %s permissions: %w", fileName, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,326 @@
+package sbc
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+)
+
+// MS Teams prefix kinds, the signaling prefixes reach the tls ports and the media prefixes the rtp ports
+const (
+	TeamsPrefixSignaling = "signaling"
+	TeamsPrefixMedia     = "media"
+)
+
+// firewallFileName is the nftables rules file, written next to the database
+const firewallFileName = "firewall.nft"
+
+// defaultTeamsPrefixes are the Microsoft 365 direct routing signaling and media ranges,
+// used until a prefix list is loaded from a file
+var defaultTeamsPrefixes = []string{"52.112.0.0/14", "52.122.0.0/15", "2603:1063::/38"}
+
+var (
+	ErrTeamsPrefixInvalid = errors.New("invalid teams prefix")
+	ErrNftNotFound        = errors.New("nft command not found, install nftables")
+)
+
+//go:embed templates/nftables/tsbc.nft.tmpl
+var firewallTemplate string
+
+// firewallSbc holds the ports of a single sbc and the addresses of its PBXs
+type firewallSbc struct {
+	Fqdn       string
+	SbcTLSPort string
+	SbcUDPPort string
+	NgPort     string
+	RTPMinPort string
+	RTPMaxPort string
+	PbxIPv4    []string
+	PbxIPv6    []string
+}
+
+// firewallData is passed to the nftables template
+type firewallData struct {
+	TeamsSignalingIPv4 []string
+	TeamsSignalingIPv6 []string
+	TeamsMediaIPv4     []string
+	TeamsMediaIPv6     []string
+	Sbcs               []firewallSbc
+}
+
+// RenderFirewall renders the nftables rules of all the deployed sbcs
+func (s *sbc) RenderFirewall() (string, error) {
+	if err := s.db.CreateFreshDB(); err != nil {
+		return "", fmt.Errorf("could not update database schema: %w", err)
+	}
+
+	prefixes, err := s.TeamsPrefixes()
+	if err != nil {
+		return "", err
+	}
+
+	data := firewallData{}
+
+	for _, prefix := range prefixes {
+		ipv4 := !strings.Contains(prefix.Prefix, ":")
+
+		switch {
+		case prefix.Kind == TeamsPrefixSignaling && ipv4:
+			data.TeamsSignalingIPv4 = append(data.TeamsSignalingIPv4, prefix.Prefix)
+		case prefix.Kind == TeamsPrefixSignaling:
+			data.TeamsSignalingIPv6 = append(data.TeamsSignalingIPv6, prefix.Prefix)
+		case ipv4:
+			data.TeamsMediaIPv4 = append(data.TeamsMediaIPv4, prefix.Prefix)
+		default:
+			data.TeamsMediaIPv6 = append(data.TeamsMediaIPv6, prefix.Prefix)
+		}
+	}
+
+	fqdnNames, err := s.db.GetAllFqdnNames()
+	if err != nil {
+		return "", fmt.Errorf("could not get SBC names: %w", err)
+	}
+
+	sort.Strings(fqdnNames)
+
+	for _, fqdn := range fqdnNames {
+		fwSbc, err := s.firewallSbc(fqdn)
+		if err != nil {
+			return "", err
+		}
+
+		data.Sbcs = append(data.Sbcs, fwSbc)
+	}
+
+	tmpl, err := template.New(firewallFileName).Funcs(template.FuncMap{"join": strings.Join}).
+		Option("missingkey=error").Parse(firewallTemplate)
+	if err != nil {
+		return "", fmt.Errorf("could not parse firewall template: %w", err)
+	}
+
+	var buff bytes.Buffer
+
+	if err = tmpl.Execute(&buff, data); err != nil {
+		return "", fmt.Errorf("could not render firewall rules: %w", err)
+	}
+
+	return buff.String(), nil
+}
+
+// ApplyFirewall renders the nftables rules, writes them next to the database and loads them,
+// after nft has checked them. The written rules file is returned.
+func (s *sbc) ApplyFirewall() (string, error) {
+	nftPath, err := exec.LookPath("nft")
+	if err != nil {
+		return "", ErrNftNotFound
+	}
+
+	rules, err := s.RenderFirewall()
+	if err != nil {
+		return "", err
+	}
+
+	rulesFile := filepath.Join(filepath.Dir(s.sbcData.SQLiteFileLocation), firewallFileName)
+
+	if err = os.WriteFile(rulesFile, []byte(rules), 0600); err != nil {
+		return "", fmt.Errorf("could not write firewall rules: %w", err)
+	}
+
+	// the check catches syntax errors and missing kernel support, before the current rules are replaced
+	for _, args := range [][]string{{"-c", "-f", rulesFile}, {"-f", rulesFile}} {
+		if out, err := exec.CommandContext(s.ctx, nftPath, args...).CombinedOutput(); err != nil {
+			return rulesFile, fmt.Errorf("nft %s failed: %w: %s",
+				strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+		}
+	}
+
+	s.logger.Info("Firewall rules applied", "file", rulesFile)
+
+	return rulesFile, nil
+}
+
+// TeamsPrefixes returns the MS Teams prefixes loaded from a file, or the built-in ones
+func (s *sbc) TeamsPrefixes() ([]types.TeamsPrefix, error) {
+	if err := s.db.CreateFreshDB(); err != nil {
+		return nil, fmt.Errorf("could not update database schema: %w", err)
+	}
+
+	prefixes, err := s.db.GetTeamsPrefixes()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(prefixes) > 0 {
+		return prefixes, nil
+	}
+
+	for _, kind := range []string{TeamsPrefixSignaling, TeamsPrefixMedia} {
+		for _, prefix := range defaultTeamsPrefixes {
+			prefixes = append(prefixes, types.TeamsPrefix{Prefix: prefix, Kind: kind, Default: true})
+		}
+	}
+
+	return prefixes, nil
+}
+
+// UpdateTeamsPrefixes replaces the MS Teams prefixes with the ones read from the file.
+// Every line holds a prefix, optionally followed by signaling or media, the prefix is used for both if not set.
+// Empty lines and lines starting with # are ignored.
+func (s *sbc) UpdateTeamsPrefixes(fileName string) error {
+	prefixFile, err := os.Open(fileName)
+	if err != nil {
+		return fmt.Errorf("could not open prefix file: %w", err)
+	}
+
+	defer prefixFile.Close()
+
+	prefixes, err := parseTeamsPrefixes(prefixFile)
+	if err != nil {
+		return err
+	}
+
+	if err = s.db.CreateFreshDB(); err != nil {
+		return fmt.Errorf("could not update database schema: %w", err)
+	}
+
+	if err = s.db.ReplaceTeamsPrefixes(prefixes); err != nil {
+		return err
+	}
+
+	s.logger.Info("Teams prefixes updated, apply the firewall rules to use them", "count", len(prefixes))
+
+	return nil
+}
+
+func parseTeamsPrefixes(r io.Reader) ([]types.TeamsPrefix, error) {
+	prefixes := make([]types.TeamsPrefix, 0)
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("%w: line %d: expected a prefix and an optional kind",
+				ErrTeamsPrefixInvalid, lineNum)
+		}
+
+		_, ipNet, err := net.ParseCIDR(fields[0])
+		if err != nil {
+			// single addresses are accepted as host prefixes
+			ip := net.ParseIP(fields[0])
+			if ip == nil {
+				return nil, fmt.Errorf("%w: line %d: %s", ErrTeamsPrefixInvalid, lineNum, fields[0])
+			}
+
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+
+			ipNet = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+		}
+
+		kinds := []string{TeamsPrefixSignaling, TeamsPrefixMedia}
+
+		if len(fields) == 2 {
+			if fields[1] != TeamsPrefixSignaling && fields[1] != TeamsPrefixMedia {
+				return nil, fmt.Errorf("%w: line %d: kind %s (use %s or %s)",
+					ErrTeamsPrefixInvalid, lineNum, fields[1], TeamsPrefixSignaling, TeamsPrefixMedia)
+			}
+
+			kinds = fields[1:]
+		}
+
+		// the network address is stored, as nft rejects prefixes with host bits set
+		for _, kind := range kinds {
+			prefixes = append(prefixes, types.TeamsPrefix{Prefix: ipNet.String(), Kind: kind})
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read prefix file: %w", err)
+	}
+
+	if len(prefixes) == 0 {
+		return nil, fmt.Errorf("%w: the file has no prefixes", ErrTeamsPrefixInvalid)
+	}
+
+	return prefixes, nil
+}
+
+// firewallSbc returns the ports of the sbc and the resolved addresses of all its PBXs
+func (s *sbc) firewallSbc(fqdnName string) (firewallSbc, error) {
+	sbcData, err := s.db.GetSBCParameters(s.db.GetSBCIdFromFqdn(fqdnName))
+	if err != nil {
+		return firewallSbc{}, fmt.Errorf("could not get sbc parameters: %w", err)
+	}
+
+	targets, err := s.db.GetPbxTargets(fqdnName)
+	if err != nil {
+		return firewallSbc{}, err
+	}
+
+	// only the address of the primary pbx is used, the transports share the sbc udp port
+	primary := primaryPbxTarget(sbcData, PbxTransportUDP)
+
+	fwSbc := firewallSbc{
+		Fqdn:       fqdnName,
+		SbcTLSPort: sbcData.SbcTLSPort,
+		SbcUDPPort: sbcData.SbcUDPPort,
+		NgPort:     sbcData.NgListen,
+		RTPMinPort: sbcData.RTPMinPort,
+		RTPMaxPort: sbcData.RTPMaxPort,
+	}
+
+	seen := make(map[string]bool)
+
+	for _, target := range append([]types.PbxTarget{primary}, targets...) {
+		ips, err := s.resolvePbxAddress(target.Address)
+		if err != nil {
+			return firewallSbc{}, fmt.Errorf("could not resolve pbx %s of %s: %w", target.Address, fqdnName, err)
+		}
+
+		for _, ip := range ips {
+			if seen[ip.String()] {
+				continue
+			}
+
+			seen[ip.String()] = true
+
+			if ip.To4() != nil {
+				fwSbc.PbxIPv4 = append(fwSbc.PbxIPv4, ip.String())
+			} else {
+				fwSbc.PbxIPv6 = append(fwSbc.PbxIPv6, ip.String())
+			}
+		}
+	}
+
+	return fwSbc, nil
+}
+
+// resolvePbxAddress returns the ip addresses of the pbx, the host names are resolved when the rules are rendered
+func (s *sbc) resolvePbxAddress(address string) ([]net.IP, error) {
+	if ip := net.ParseIP(address); ip != nil {
+		return []net.IP{ip}, nil
+	}
+
+	return net.DefaultResolver.LookupIP(s.ctx, "ip", address)
+}
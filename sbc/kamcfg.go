@@ -0,0 +1,402 @@
+package sbc
+
+import (
+	"archive/tar"
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/docker/docker/client"
+	"github.com/spf13/viper"
+)
+
+//go:embed templates/*.tmpl templates/dbtext/*.tmpl
+var kamailioTemplates embed.FS
+
+// kamailioConfigFiles are rendered from the templates with the same name and the .tmpl extension,
+// the dbtext tables hold the pbx registration and the number rules, pbx-ca.pem the CA certificates of the pbx
+var kamailioConfigFiles = []string{
+	"kamailio.cfg", "tls.cfg", "pbx-ca.pem", "dispatcher.list", "dbtext/version", "dbtext/uacreg", "dbtext/dialplan",
+}
+
+// kamailioReloadCommands are the RPC commands, that reload the configuration files without a Kamailio restart
+var kamailioReloadCommands = map[string]string{
+	"dispatcher.list": "dispatcher.reload",
+	"dbtext/dialplan": "dialplan.reload",
+	"dbtext/uacreg":   "uac.reg_reload",
+	"tls.cfg":         "tls.reload",
+	"pbx-ca.pem":      "tls.reload",
+}
+
+// kamailioTemplateFuncs escape the values written to the Kamailio configuration
+var kamailioTemplateFuncs = template.FuncMap{
+	"kamailioString": kamailioString,
+	"dbtextValue":    dbtextValue,
+}
+
+// kamailioCertDir is the certificates volume mount point inside the Kamailio container
+const kamailioCertDir = "/cert"
+
+// kamailioConfigData is passed to the Kamailio configuration templates
+type kamailioConfigData struct {
+	types.Sbc
+	HostIP       string
+	CertDir      string
+	PbxTargets   []types.PbxTarget
+	PbxAlgorithm string
+	PbxAuth      *types.PbxCredentials
+	PbxTransport types.PbxTransport
+	// PbxTLSAddresses are the ip:port of the tls pbx targets, which get their own TLS client profile
+	PbxTLSAddresses []string
+
+	NumberRuleSets []numberRuleSet
+}
+
+// UsesPbxTransport reports if any pbx target is reached over the transport
+func (d kamailioConfigData) UsesPbxTransport(transport string) bool {
+	for _, target := range d.PbxTargets {
+		if target.Transport == transport {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NumberRuleSetsFor returns the number rule sets of the direction
+func (d kamailioConfigData) NumberRuleSetsFor(direction string) []numberRuleSet {
+	resp := make([]numberRuleSet, 0, len(NumberFields))
+
+	for _, set := range d.NumberRuleSets {
+		if set.Direction == direction {
+			resp = append(resp, set)
+		}
+	}
+
+	return resp
+}
+
+// UsesDBText reports if any Kamailio module reads the dbtext tables
+func (d kamailioConfigData) UsesDBText() bool {
+	return d.PbxAuth != nil && d.PbxAuth.Register || len(d.NumberRuleSets) > 0
+}
+
+// DiffKamailioConfig renders the Kamailio configuration of the sbc and compares it with the deployed one
+func (s *sbc) DiffKamailioConfig(fqdnName string) ([]types.KamailioConfigDiff, error) {
+	containerID, rendered, err := s.renderDeployedSbcConfig(fqdnName)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := make([]types.KamailioConfigDiff, 0, len(kamailioConfigFiles))
+
+	for _, fileName := range kamailioConfigFiles {
+		deployed, err := s.readContainerFile(containerID, path.Join(kamailioConfigDir, fileName))
+		if err != nil {
+			return nil, err
+		}
+
+		diffs = append(diffs, types.KamailioConfigDiff{
+			File:     fileName,
+			Template: s.kamailioTemplateSource(fqdnName, fileName),
+			Diff:     unifiedDiff(fileName, string(deployed), string(rendered[fileName])),
+		})
+	}
+
+	return diffs, nil
+}
+
+// ApplyKamailioConfig renders the Kamailio configuration of the sbc into its configuration volume
+// and restarts Kamailio, so that the new configuration is loaded
+func (s *sbc) ApplyKamailioConfig(fqdnName string) error {
+	containerID, rendered, err := s.renderDeployedSbcConfig(fqdnName)
+	if err != nil {
+		return err
+	}
+
+	if err = s.writeKamailioConfig(containerID, rendered); err != nil {
+		return err
+	}
+
+	timeOut := time.Second * 30
+
+	if err = s.dockerCl.ContainerRestart(s.ctx, containerID, &timeOut); err != nil {
+		return fmt.Errorf("could not restart kamailio container: %w", err)
+	}
+
+	s.logger.Info("Kamailio configuration applied", "fqdn", fqdnName)
+
+	return nil
+}
+
+// reloadKamailioConfig renders the Kamailio configuration of the sbc and applies the changed files,
+// which are reloaded over RPC if possible, otherwise Kamailio is restarted
+func (s *sbc) reloadKamailioConfig(fqdnName string) error {
+	if !s.sbcData.NewConfig {
+		s.logger.Warn("Kamailio configuration is not rendered by tsbc, the changes are not applied", "fqdn", fqdnName)
+
+		return nil
+	}
+
+	containerID, rendered, err := s.renderDeployedSbcConfig(fqdnName)
+	if err != nil {
+		return err
+	}
+
+	changed := make([]string, 0, len(kamailioConfigFiles))
+	restart := false
+
+	for _, fileName := range kamailioConfigFiles {
+		deployed, err := s.readContainerFile(containerID, path.Join(kamailioConfigDir, fileName))
+		if err != nil {
+			return err
+		}
+
+		if string(deployed) == string(rendered[fileName]) {
+			continue
+		}
+
+		changed = append(changed, fileName)
+
+		if _, ok := kamailioReloadCommands[fileName]; !ok {
+			restart = true
+		}
+	}
+
+	if len(changed) == 0 {
+		s.logger.Info("Kamailio configuration is up to date", "fqdn", fqdnName)
+
+		return nil
+	}
+
+	if err = s.writeKamailioConfig(containerID, rendered); err != nil {
+		return err
+	}
+
+	if !restart {
+		restart = !s.reloadKamailioFiles(changed)
+	}
+
+	if !restart {
+		s.logger.Info("Kamailio configuration reloaded", "fqdn", fqdnName, "files", changed)
+
+		return nil
+	}
+
+	timeOut := time.Second * 30
+
+	if err = s.dockerCl.ContainerRestart(s.ctx, containerID, &timeOut); err != nil {
+		return fmt.Errorf("could not restart kamailio container: %w", err)
+	}
+
+	s.logger.Info("Kamailio restarted with the new configuration", "fqdn", fqdnName, "files", changed)
+
+	return nil
+}
+
+// reloadKamailioFiles reloads the files over RPC and reports if all of them were reloaded
+func (s *sbc) reloadKamailioFiles(fileNames []string) bool {
+	if _, err := s.reloadKamailio(kamailioReloadMethods(fileNames)); err != nil {
+		s.logger.Warn("Could not reload Kamailio configuration, restarting container",
+			"fqdn", s.sbcData.SbcName, "files", fileNames, "err", err)
+
+		return false
+	}
+
+	return true
+}
+
+// renderDeployedSbcConfig renders the configuration of a deployed sbc and returns its Kamailio container id
+func (s *sbc) renderDeployedSbcConfig(fqdnName string) (string, map[string][]byte, error) {
+	if err := s.loadDeployedSbc(fqdnName); err != nil {
+		return "", nil, err
+	}
+
+	// the host ip is not stored in the database, so it is taken from the running container if not set
+	if err := s.inheritContainerConfig(s.sbcData.KamailioContainerID, flagnames.KamailioImage); err != nil {
+		return "", nil, err
+	}
+
+	if viper.GetString(flagnames.HostIP) == "" {
+		return "", nil, ErrHostIPNotSet
+	}
+
+	rendered, err := s.renderKamailioConfig()
+	if err != nil {
+		return "", nil, err
+	}
+
+	return s.sbcData.KamailioContainerID, rendered, nil
+}
+
+// renderKamailioConfig renders all the Kamailio configuration files of the current sbc,
+// the per sbc override templates take precedence over the embedded ones
+func (s *sbc) renderKamailioConfig() (map[string][]byte, error) {
+	pbxTargets, err := s.pbxTargets()
+	if err != nil {
+		return nil, err
+	}
+
+	// databases created before the stream transport check may still hold both
+	if err = validatePbxStreamTransports(pbxTargets); err != nil {
+		return nil, err
+	}
+
+	pbxTransport, err := s.pbxTransport()
+	if err != nil {
+		return nil, err
+	}
+
+	pbxAuth, err := s.pbxCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	numberRules, err := s.db.GetNumberRules(s.sbcData.SbcName)
+	if err != nil {
+		return nil, err
+	}
+
+	data := kamailioConfigData{
+		Sbc:          s.sbcData,
+		HostIP:       viper.GetString(flagnames.HostIP),
+		CertDir:      path.Join(kamailioCertDir, "live", s.certName(s.sbcData.SbcName)),
+		PbxTargets:   pbxTargets,
+		PbxAlgorithm: pbxDispatcherAlgorithm(pbxTargets),
+		PbxAuth:      pbxAuth,
+		PbxTransport: pbxTransport,
+
+		PbxTLSAddresses: pbxTLSAddresses(pbxTargets),
+		NumberRuleSets:  numberRuleSets(numberRules),
+	}
+
+	rendered := make(map[string][]byte, len(kamailioConfigFiles))
+
+	for _, fileName := range kamailioConfigFiles {
+		tmpl, err := s.kamailioTemplate(s.sbcData.SbcName, fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		var buff bytes.Buffer
+
+		if err = tmpl.Execute(&buff, data); err != nil {
+			return nil, fmt.Errorf("could not render %s: %w", fileName, err)
+		}
+
+		rendered[fileName] = buff.Bytes()
+	}
+
+	return rendered, nil
+}
+
+// kamailioTemplate parses the override template of the sbc if it exists, or the embedded one
+func (s *sbc) kamailioTemplate(fqdnName, fileName string) (*template.Template, error) {
+	overrideFile := filepath.Join(s.kamailioTemplatesDir(fqdnName), fileName+".tmpl")
+
+	content, err := os.ReadFile(overrideFile)
+
+	switch {
+	case err == nil:
+		s.logger.Debug("Using override template", "file", overrideFile)
+	case errors.Is(err, os.ErrNotExist):
+		content, err = kamailioTemplates.ReadFile(path.Join("templates", fileName+".tmpl"))
+		if err != nil {
+			return nil, fmt.Errorf("could not read embedded %s template: %w", fileName, err)
+		}
+	default:
+		return nil, fmt.Errorf("could not read override template: %w", err)
+	}
+
+	tmpl, err := template.New(fileName).Funcs(kamailioTemplateFuncs).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s template: %w", fileName, err)
+	}
+
+	return tmpl, nil
+}
+
+// kamailioTemplatesDir is the directory with the override templates of the sbc, next to the database
+func (s *sbc) kamailioTemplatesDir(fqdnName string) string {
+	return filepath.Join(filepath.Dir(s.sbcData.SQLiteFileLocation), "templates", fqdnName)
+}
+
+// kamailioTemplateSource returns the override template file, or "embedded" if the sbc has no override
+func (s *sbc) kamailioTemplateSource(fqdnName, fileName string) string {
+	overrideFile := filepath.Join(s.kamailioTemplatesDir(fqdnName), fileName+".tmpl")
+	if _, err := os.Stat(overrideFile); err == nil {
+		return overrideFile
+	}
+
+	return "embedded"
+}
+
+// renderAndWriteKamailioConfig renders the configuration of the current sbc into a newly created Kamailio container
+func (s *sbc) renderAndWriteKamailioConfig(containerID string) error {
+	rendered, err := s.renderKamailioConfig()
+	if err != nil {
+		return err
+	}
+
+	return s.writeKamailioConfig(containerID, rendered)
+}
+
+// writeKamailioConfig copies the rendered files to the container, which writes them to the configuration volume
+func (s *sbc) writeKamailioConfig(containerID string, rendered map[string][]byte) error {
+	for _, fileName := range kamailioConfigFiles {
+		if err := s.copyFileToContainer(containerID, kamailioConfigDir, fileName, rendered[fileName], 0644); err != nil {
+			return fmt.Errorf("could not write %s: %w", fileName, err)
+		}
+
+		s.logger.Debug("Kamailio configuration file written", "file", fileName)
+	}
+
+	return nil
+}
+
+// readContainerFile returns the content of a file in the container, or nil if the file does not exist.
+// The file is copied from the container, which works for stopped containers as well.
+func (s *sbc) readContainerFile(containerID, filePath string) ([]byte, error) {
+	content, _, err := s.dockerCl.CopyFromContainer(s.ctx, containerID, filePath)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("could not copy %s from container: %w", filePath, err)
+	}
+
+	defer content.Close()
+
+	tr := tar.NewReader(content)
+	if _, err = tr.Next(); err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", filePath, err)
+	}
+
+	fileContent, err := io.ReadAll(tr)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", filePath, err)
+	}
+
+	return fileContent, nil
+}
+
+// kamailioString quotes the value as a Kamailio configuration string
+func kamailioString(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// dbtextValue escapes the value as a db_text column
+func dbtextValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ":", `\:`).Replace(value)
+}
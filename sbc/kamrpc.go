@@ -0,0 +1,241 @@
+package sbc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/jsonrpc"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/spf13/viper"
+)
+
+const (
+	// kamailioRPCAddress is the loopback listener of the JSONRPC endpoint, on the tcp variant of the sbc udp port
+	kamailioRPCAddress = "127.0.0.1"
+	kamailioRPCPath    = "/RPC"
+	kamailioRPCTimeout = 10 * time.Second
+)
+
+var (
+	ErrKamailioRPCNotAvailable = errors.New("kamailio rpc endpoint not available")
+	ErrKamailioRPCResult       = errors.New("unexpected kamailio rpc result")
+	ErrKamcmdFailed            = errors.New("kamcmd failed")
+)
+
+// KamailioRPC calls the Kamailio RPC method of the sbc and returns its raw JSON result
+func (s *sbc) KamailioRPC(fqdnName, method string, params ...any) (json.RawMessage, error) {
+	if err := s.loadDeployedSbc(fqdnName); err != nil {
+		return nil, err
+	}
+
+	return s.kamailioRPC(method, params...)
+}
+
+// KamailioStats returns the Kamailio statistics of the group, or all of them if the group is empty
+func (s *sbc) KamailioStats(fqdnName, group string) ([]types.KamailioStat, error) {
+	statsGroup := "all"
+	if group != "" {
+		statsGroup = strings.TrimSuffix(group, ":") + ":"
+	}
+
+	result, err := s.KamailioRPC(fqdnName, "stats.get_statistics", statsGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseKamailioStats(result)
+}
+
+// KamailioTLSConnections returns the TLS connections of Kamailio, to MS Teams and to the tls pbx targets
+func (s *sbc) KamailioTLSConnections(fqdnName string) ([]types.KamailioTLSConnection, error) {
+	result, err := s.KamailioRPC(fqdnName, "tls.list")
+	if err != nil {
+		return nil, err
+	}
+
+	connections := make([]types.KamailioTLSConnection, 0)
+
+	// an empty list is returned as null, or as an empty object by the older versions
+	if trimmed := strings.TrimSpace(string(result)); trimmed == "null" || trimmed == "{}" {
+		return connections, nil
+	}
+
+	if err = json.Unmarshal(result, &connections); err != nil {
+		return nil, fmt.Errorf("%w: tls.list: %s", ErrKamailioRPCResult, err)
+	}
+
+	return connections, nil
+}
+
+// ReloadKamailio reloads the dispatcher list, the TLS configuration and the dbtext tables in use
+// from the deployed configuration files and returns the called RPC methods
+func (s *sbc) ReloadKamailio(fqdnName string) ([]string, error) {
+	if err := s.loadDeployedSbc(fqdnName); err != nil {
+		return nil, err
+	}
+
+	fileNames := []string{"dispatcher.list", "tls.cfg"}
+
+	pbxAuth, err := s.pbxCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	if pbxAuth != nil && pbxAuth.Register {
+		fileNames = append(fileNames, "dbtext/uacreg")
+	}
+
+	numberRules, err := s.db.GetNumberRules(fqdnName)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(numberRules) > 0 {
+		fileNames = append(fileNames, "dbtext/dialplan")
+	}
+
+	return s.reloadKamailio(kamailioReloadMethods(fileNames))
+}
+
+// reloadKamailio calls the reload RPC methods of the current sbc in order and returns the methods already called
+func (s *sbc) reloadKamailio(methods []string) ([]string, error) {
+	for i, method := range methods {
+		result, err := s.kamailioCommand(method)
+		if err != nil {
+			return methods[:i], err
+		}
+
+		s.logger.Debug("Kamailio reloaded", "fqdn", s.sbcData.SbcName, "method", method, "result", result)
+	}
+
+	return methods, nil
+}
+
+// kamailioReloadMethods returns the RPC methods, that reload the configuration files, without duplicates
+func kamailioReloadMethods(fileNames []string) []string {
+	methods := make([]string, 0, len(fileNames))
+
+	for _, fileName := range fileNames {
+		method := kamailioReloadCommands[fileName]
+		if method != "" && indexOf(methods, method) < 0 {
+			methods = append(methods, method)
+		}
+	}
+
+	return methods
+}
+
+// kamailioRPC calls the JSONRPC endpoint of the current sbc, the --rpc-url flag overrides the endpoint
+func (s *sbc) kamailioRPC(method string, params ...any) (json.RawMessage, error) {
+	rpcURL := viper.GetString(flagnames.KamailioRPCURL)
+
+	if rpcURL == "" {
+		// only the tsbc rendered configuration serves the JSONRPC endpoint
+		if !s.sbcData.NewConfig {
+			return nil, fmt.Errorf("%w: the Kamailio configuration of %s is not rendered by tsbc",
+				ErrKamailioRPCNotAvailable, s.sbcData.SbcName)
+		}
+
+		rpcURL = "http://" + net.JoinHostPort(kamailioRPCAddress, s.sbcData.SbcUDPPort) + kamailioRPCPath
+	}
+
+	s.logger.Debug("Calling Kamailio RPC", "url", rpcURL, "method", method, "params", params)
+
+	result, err := jsonrpc.NewClient(rpcURL, kamailioRPCTimeout).Call(s.ctx, method, params...)
+	if err != nil {
+		return nil, fmt.Errorf("kamailio rpc %s: %w", method, err)
+	}
+
+	return result, nil
+}
+
+// kamailioCommand calls the RPC method of the current sbc and returns its result as text.
+// The sbcs without the tsbc rendered configuration have no JSONRPC endpoint,
+// so the method is called with kamcmd inside their Kamailio container.
+func (s *sbc) kamailioCommand(method string, params ...any) (string, error) {
+	result, err := s.kamailioRPC(method, params...)
+	if err == nil {
+		return rpcResultText(result), nil
+	}
+
+	if !errors.Is(err, ErrKamailioRPCNotAvailable) {
+		return "", err
+	}
+
+	cmd := []string{"kamcmd", method}
+	for _, param := range params {
+		cmd = append(cmd, fmt.Sprint(param))
+	}
+
+	s.logger.Debug("Calling Kamailio RPC with kamcmd", "fqdn", s.sbcData.SbcName, "cmd", cmd)
+
+	out, exitCode, err := s.execInContainer(s.sbcData.KamailioContainerID, cmd)
+	if err != nil {
+		return "", fmt.Errorf("kamcmd %s: %w", method, err)
+	}
+
+	if exitCode != 0 {
+		return "", fmt.Errorf("%w: %s: %s", ErrKamcmdFailed, method, strings.TrimSpace(string(out)))
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// rpcResultText returns the string result of an RPC method as is, and any other result as JSON
+func rpcResultText(result json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(result, &text); err == nil {
+		return text
+	}
+
+	return string(result)
+}
+
+// parseKamailioStats parses the group:name = value lines of stats.get_statistics,
+// or the group.name keys of the object returned by stats.fetch
+func parseKamailioStats(result json.RawMessage) ([]types.KamailioStat, error) {
+	stats := make([]types.KamailioStat, 0)
+
+	var lines []string
+
+	if err := json.Unmarshal(result, &lines); err == nil {
+		for _, line := range lines {
+			name, value, found := strings.Cut(line, "=")
+			if !found {
+				return nil, fmt.Errorf("%w: statistic %q", ErrKamailioRPCResult, line)
+			}
+
+			group, name, _ := strings.Cut(strings.TrimSpace(name), ":")
+			stats = append(stats, types.KamailioStat{Group: group, Name: name, Value: strings.TrimSpace(value)})
+		}
+
+		return stats, nil
+	}
+
+	var values map[string]any
+
+	if err := json.Unmarshal(result, &values); err != nil {
+		return nil, fmt.Errorf("%w: statistics: %s", ErrKamailioRPCResult, err)
+	}
+
+	for key, value := range values {
+		group, name, _ := strings.Cut(key, ".")
+		stats = append(stats, types.KamailioStat{Group: group, Name: name, Value: fmt.Sprint(value)})
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Group != stats[j].Group {
+			return stats[i].Group < stats[j].Group
+		}
+
+		return stats[i].Name < stats[j].Name
+	})
+
+	return stats, nil
+}
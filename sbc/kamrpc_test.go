@@ -0,0 +1,63 @@
+package sbc
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+)
+
+func TestParseKamailioStats(t *testing.T) {
+	tests := []struct {
+		name   string
+		result string
+		want   []types.KamailioStat
+	}{
+		{
+			name:   "get_statistics lines",
+			result: `["tm:current = 3", "core:rcv_requests = 120", "dispatcher:set_state = 0"]`,
+			want: []types.KamailioStat{
+				{Group: "tm", Name: "current", Value: "3"},
+				{Group: "core", Name: "rcv_requests", Value: "120"},
+				{Group: "dispatcher", Name: "set_state", Value: "0"},
+			},
+		},
+		{
+			name:   "fetch object sorted by group and name",
+			result: `{"tm.current": 3, "core.rcv_requests": 120, "core.fwd_requests": 7}`,
+			want: []types.KamailioStat{
+				{Group: "core", Name: "fwd_requests", Value: "7"},
+				{Group: "core", Name: "rcv_requests", Value: "120"},
+				{Group: "tm", Name: "current", Value: "3"},
+			},
+		},
+		{
+			name:   "empty group",
+			result: `[]`,
+			want:   []types.KamailioStat{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := parseKamailioStats(json.RawMessage(tt.result))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(stats, tt.want) {
+				t.Errorf("got %+v, want %+v", stats, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseKamailioStatsInvalid(t *testing.T) {
+	for _, result := range []string{`["tm:current"]`, `"tm:current = 3"`, `42`} {
+		if _, err := parseKamailioStats(json.RawMessage(result)); !errors.Is(err, ErrKamailioRPCResult) {
+			t.Errorf("parseKamailioStats(%s) got error %v, want %v", result, err, ErrKamailioRPCResult)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package sbc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
+	"sync"
+	"syscall"
+
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/fatih/color"
+)
+
+var ErrComponentNotSupported = errors.New("component not supported")
+
+// componentColors distinguish the multiplexed component logs
+var componentColors = map[string]*color.Color{
+	componentKamailio:    color.New(color.FgCyan),
+	componentRTPEngine:   color.New(color.FgMagenta),
+	componentLetsEncrypt: color.New(color.FgYellow),
+}
+
+// Logs streams the logs of the selected sbc components to the output, prefixing every line with the component name
+func (s *sbc) Logs(fqdnName string, opts types.LogOptions, out io.Writer) error {
+	var grep *regexp.Regexp
+
+	if opts.Grep != "" {
+		var err error
+
+		grep, err = regexp.Compile(opts.Grep)
+		if err != nil {
+			return fmt.Errorf("could not compile grep expression: %w", err)
+		}
+	}
+
+	if len(opts.Components) == 0 {
+		opts.Components = []string{componentKamailio, componentRTPEngine}
+	}
+
+	containerIDs, err := s.componentContainerIDs(fqdnName, opts.Components)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(s.ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var (
+		wg      sync.WaitGroup
+		outLock sync.Mutex
+		errs    = make(chan error, len(opts.Components))
+	)
+
+	for _, component := range opts.Components {
+		logs, err := s.dockerCl.ContainerLogs(ctx, containerIDs[component], dockerTypes.ContainerLogsOptions{
+			ShowStdout: true,
+			ShowStderr: true,
+			Follow:     opts.Follow,
+			Since:      opts.Since,
+		})
+		if err != nil {
+			return fmt.Errorf("could not get %s logs: %w", component, err)
+		}
+
+		wg.Add(1)
+
+		go func(component string, logs io.ReadCloser) {
+			defer wg.Done()
+			defer logs.Close()
+
+			prefix := componentColors[component].Sprintf("%-9s |", component)
+
+			// containers run without tty, so stdout and stderr are multiplexed in a single stream
+			pr, pw := io.Pipe()
+
+			go func() {
+				_, err := stdcopy.StdCopy(pw, pw, logs)
+				_ = pw.CloseWithError(err)
+			}()
+
+			scanner := bufio.NewScanner(pr)
+			for scanner.Scan() {
+				if grep != nil && !grep.MatchString(scanner.Text()) {
+					continue
+				}
+
+				outLock.Lock()
+				_, _ = fmt.Fprintln(out, prefix, scanner.Text())
+				outLock.Unlock()
+			}
+
+			// the stream is closed on interrupt, which is not an error
+			if err := scanner.Err(); err != nil && ctx.Err() == nil {
+				errs <- fmt.Errorf("could not read %s logs: %w", component, err)
+			}
+		}(component, logs)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	return <-errs
+}
+
+// componentContainerIDs resolves the container id of every requested component from the database
+func (s *sbc) componentContainerIDs(fqdnName string, components []string) (map[string]string, error) {
+	containerIDs := make(map[string]string, len(components))
+
+	for _, component := range components {
+		switch component {
+		case componentKamailio, componentRTPEngine:
+			ids := s.db.GetContainerIDsFromSbcFqdn(fqdnName)
+			if ids == nil {
+				return nil, fmt.Errorf("%w: %s", ErrCouldNotGetContainerIDs, fqdnName)
+			}
+
+			containerIDs[componentKamailio] = ids[0]
+			containerIDs[componentRTPEngine] = ids[1]
+		case componentLetsEncrypt:
+			nodeID, err := s.db.GetLetsEncryptNodeID()
+			if err != nil {
+				return nil, fmt.Errorf("could not get letsencrypt node id: %w", err)
+			}
+
+			if nodeID == "" {
+				return nil, ErrLetsEncryptNodeNotFound
+			}
+
+			containerIDs[componentLetsEncrypt] = nodeID
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrComponentNotSupported, component)
+		}
+	}
+
+	return containerIDs, nil
+}
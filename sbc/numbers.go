@@ -0,0 +1,260 @@
+package sbc
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+)
+
+// number rule directions
+const (
+	NumberDirectionTeamsToPbx = "teams-to-pbx"
+	NumberDirectionPbxToTeams = "pbx-to-teams"
+)
+
+// number rule fields, the user part of the uri is rewritten
+const (
+	NumberFieldRURI = "ruri"
+	NumberFieldFrom = "from"
+	NumberFieldTo   = "to"
+	NumberFieldPAI  = "pai"
+)
+
+// NumberDirections and NumberFields are in the dialplan id order
+var (
+	NumberDirections = []string{NumberDirectionTeamsToPbx, NumberDirectionPbxToTeams}
+	NumberFields     = []string{NumberFieldRURI, NumberFieldFrom, NumberFieldTo, NumberFieldPAI}
+)
+
+var (
+	ErrNumberRuleInvalid = errors.New("invalid number rule")
+
+	// kamailioPVPattern matches the pseudo-variables, that Kamailio would expand in the dialplan expressions
+	kamailioPVPattern = regexp.MustCompile(`\$[a-zA-Z({]`)
+	// replaceBackRefPattern matches the back references of the replacement, \0 is the whole match
+	replaceBackRefPattern = regexp.MustCompile(`\\[0-9]`)
+)
+
+// numberRuleSet is the Kamailio dialplan of a single direction and field
+type numberRuleSet struct {
+	DPID      int
+	Direction string
+	Field     string
+	Rules     []types.NumberRule
+}
+
+// NumberRules returns the number rules of the sbc
+func (s *sbc) NumberRules(fqdnName string) ([]types.NumberRule, error) {
+	if err := s.loadDeployedSbc(fqdnName); err != nil {
+		return nil, err
+	}
+
+	return s.db.GetNumberRules(fqdnName)
+}
+
+// AddNumberRule stores the number rule of the sbc and reloads the Kamailio dialplan
+func (s *sbc) AddNumberRule(rule types.NumberRule) (types.NumberRule, error) {
+	if err := validateNumberRule(rule); err != nil {
+		return rule, err
+	}
+
+	if err := s.loadDeployedSbc(rule.Fqdn); err != nil {
+		return rule, err
+	}
+
+	var err error
+
+	rule.ID, err = s.db.AddNumberRule(rule)
+	if err != nil {
+		return rule, err
+	}
+
+	s.logger.Info("Number rule added", "fqdn", rule.Fqdn, "id", rule.ID,
+		"direction", rule.Direction, "field", rule.Field)
+
+	return rule, s.reloadKamailioConfig(rule.Fqdn)
+}
+
+// RemoveNumberRule removes the number rule of the sbc and reloads the Kamailio dialplan
+func (s *sbc) RemoveNumberRule(fqdnName string, ruleID int64) error {
+	if err := s.loadDeployedSbc(fqdnName); err != nil {
+		return err
+	}
+
+	if err := s.db.RemoveNumberRule(fqdnName, ruleID); err != nil {
+		return err
+	}
+
+	s.logger.Info("Number rule removed", "fqdn", fqdnName, "id", ruleID)
+
+	return s.reloadKamailioConfig(fqdnName)
+}
+
+// TestNumberRules evaluates the number against the rules of the direction and field, the same way as Kamailio
+func (s *sbc) TestNumberRules(fqdnName, direction, field, number string) (types.NumberRuleResult, error) {
+	result := types.NumberRuleResult{Direction: direction, Field: field, Input: number, Output: number}
+
+	if err := validateNumberRuleSet(direction, field); err != nil {
+		return result, err
+	}
+
+	rules, err := s.NumberRules(fqdnName)
+	if err != nil {
+		return result, err
+	}
+
+	for _, set := range numberRuleSets(rules) {
+		if set.Direction != direction || set.Field != field {
+			continue
+		}
+
+		return translateNumber(set.Rules, number)
+	}
+
+	return result, nil
+}
+
+// translateNumber applies the first matching rule, the rules are already in the evaluation order.
+// Like the Kamailio dialplan, the whole number is replaced with the expanded replacement.
+func translateNumber(rules []types.NumberRule, number string) (types.NumberRuleResult, error) {
+	result := types.NumberRuleResult{Input: number, Output: number}
+
+	for _, rule := range rules {
+		result.Direction, result.Field = rule.Direction, rule.Field
+
+		re, err := regexp.Compile(rule.Match)
+		if err != nil {
+			return result, fmt.Errorf("%w: rule %d: %s", ErrNumberRuleInvalid, rule.ID, err)
+		}
+
+		groups := re.FindStringSubmatch(number)
+		if groups == nil {
+			continue
+		}
+
+		result.Output = replaceBackRefPattern.ReplaceAllStringFunc(rule.Replace, func(backRef string) string {
+			return groups[backRef[1]-'0']
+		})
+		result.Matched = true
+		result.RuleID = rule.ID
+
+		return result, nil
+	}
+
+	return result, nil
+}
+
+// numberRuleSets groups the rules by direction and field, ordered by the dialplan id,
+// the rules of every set are ordered by priority
+func numberRuleSets(rules []types.NumberRule) []numberRuleSet {
+	sets := make(map[int]*numberRuleSet)
+
+	for _, rule := range rules {
+		dpid := numberRuleDPID(rule.Direction, rule.Field)
+		if dpid == 0 {
+			continue
+		}
+
+		if sets[dpid] == nil {
+			sets[dpid] = &numberRuleSet{DPID: dpid, Direction: rule.Direction, Field: rule.Field}
+		}
+
+		sets[dpid].Rules = append(sets[dpid].Rules, rule)
+	}
+
+	resp := make([]numberRuleSet, 0, len(sets))
+
+	for _, set := range sets {
+		sort.SliceStable(set.Rules, func(i, j int) bool {
+			if set.Rules[i].Priority != set.Rules[j].Priority {
+				return set.Rules[i].Priority < set.Rules[j].Priority
+			}
+
+			return set.Rules[i].ID < set.Rules[j].ID
+		})
+
+		resp = append(resp, *set)
+	}
+
+	sort.Slice(resp, func(i, j int) bool {
+		return resp[i].DPID < resp[j].DPID
+	})
+
+	return resp
+}
+
+// numberRuleDPID returns the dialplan id of the direction and field, or 0 if they are not supported.
+// The tens are the direction and the units are the field, e.g. 11 is the request uri from Teams to the PBX.
+func numberRuleDPID(direction, field string) int {
+	directionIdx, fieldIdx := indexOf(NumberDirections, direction), indexOf(NumberFields, field)
+	if directionIdx < 0 || fieldIdx < 0 {
+		return 0
+	}
+
+	return (directionIdx+1)*10 + fieldIdx + 1
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func validateNumberRuleSet(direction, field string) error {
+	if indexOf(NumberDirections, direction) < 0 {
+		return fmt.Errorf("%w: direction %s (use one of %s)",
+			ErrNumberRuleInvalid, direction, strings.Join(NumberDirections, ", "))
+	}
+
+	if indexOf(NumberFields, field) < 0 {
+		return fmt.Errorf("%w: field %s (use one of %s)",
+			ErrNumberRuleInvalid, field, strings.Join(NumberFields, ", "))
+	}
+
+	return nil
+}
+
+// validateNumberRule makes sure that the rule is evaluated the same way by Go and by Kamailio
+func validateNumberRule(rule types.NumberRule) error {
+	if err := validateNumberRuleSet(rule.Direction, rule.Field); err != nil {
+		return err
+	}
+
+	// the Go regular expressions are a subset of the PCRE expressions used by Kamailio
+	re, err := regexp.Compile(rule.Match)
+	if err != nil {
+		return fmt.Errorf("%w: match expression: %s", ErrNumberRuleInvalid, err)
+	}
+
+	for name, value := range map[string]string{"match": rule.Match, "replace": rule.Replace} {
+		if kamailioPVPattern.MatchString(value) {
+			return fmt.Errorf("%w: %s expression must not contain pseudo-variables", ErrNumberRuleInvalid, name)
+		}
+
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("%w: %s expression must not contain line breaks", ErrNumberRuleInvalid, name)
+		}
+	}
+
+	// only the back references are escaped in the replacement
+	for _, backRef := range replaceBackRefPattern.FindAllString(rule.Replace, -1) {
+		if int(backRef[1]-'0') > re.NumSubexp() {
+			return fmt.Errorf("%w: replacement %s refers to a missing group, the expression has %d groups",
+				ErrNumberRuleInvalid, backRef, re.NumSubexp())
+		}
+	}
+
+	if strings.Contains(replaceBackRefPattern.ReplaceAllString(rule.Replace, ""), `\`) {
+		return fmt.Errorf("%w: replacement may only escape the back references \\0 to \\9", ErrNumberRuleInvalid)
+	}
+
+	return nil
+}
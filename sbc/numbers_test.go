@@ -0,0 +1,265 @@
+package sbc
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/hashicorp/go-hclog"
+)
+
+func TestTranslateNumber(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []types.NumberRule
+		number  string
+		output  string
+		matched bool
+		ruleID  int64
+	}{
+		{
+			name:    "whole number is replaced",
+			rules:   []types.NumberRule{{ID: 1, Match: `^0`, Replace: "+381"}},
+			number:  "0111234567",
+			output:  "+381",
+			matched: true,
+			ruleID:  1,
+		},
+		{
+			name:    "group back reference",
+			rules:   []types.NumberRule{{ID: 1, Match: `^0(\d+)$`, Replace: `+381\1`}},
+			number:  "0111234567",
+			output:  "+381111234567",
+			matched: true,
+			ruleID:  1,
+		},
+		{
+			name:    "whole match back reference",
+			rules:   []types.NumberRule{{ID: 1, Match: `^\d{4}$`, Replace: `+38111\0`}},
+			number:  "1234",
+			output:  "+381111234",
+			matched: true,
+			ruleID:  1,
+		},
+		{
+			name:    "ninth group back reference",
+			rules:   []types.NumberRule{{ID: 1, Match: `^(1)(2)(3)(4)(5)(6)(7)(8)(9)$`, Replace: `\9\5\1`}},
+			number:  "123456789",
+			output:  "951",
+			matched: true,
+			ruleID:  1,
+		},
+		{
+			name:    "optional group that does not match is empty",
+			rules:   []types.NumberRule{{ID: 1, Match: `^(\+)?(\d+)$`, Replace: `00\1\2`}},
+			number:  "381111234",
+			output:  "00381111234",
+			matched: true,
+			ruleID:  1,
+		},
+		{
+			name:   "no matching rule keeps the number",
+			rules:  []types.NumberRule{{ID: 1, Match: `^0`, Replace: "+381"}},
+			number: "+381111234",
+			output: "+381111234",
+		},
+		{
+			name: "first matching rule wins",
+			rules: []types.NumberRule{
+				{ID: 1, Match: `^9`, Replace: "first"},
+				{ID: 2, Match: `^1`, Replace: "second"},
+				{ID: 3, Match: `^1`, Replace: "third"},
+			},
+			number:  "123",
+			output:  "second",
+			matched: true,
+			ruleID:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := translateNumber(tt.rules, tt.number)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.Output != tt.output || result.Matched != tt.matched || result.RuleID != tt.ruleID {
+				t.Errorf("got output=%q matched=%t rule=%d, want output=%q matched=%t rule=%d",
+					result.Output, result.Matched, result.RuleID, tt.output, tt.matched, tt.ruleID)
+			}
+		})
+	}
+}
+
+func TestNumberRuleSetsOrder(t *testing.T) {
+	rules := []types.NumberRule{
+		{ID: 1, Direction: NumberDirectionPbxToTeams, Field: NumberFieldFrom, Priority: 1, Match: `^1`, Replace: "pbx"},
+		{ID: 2, Direction: NumberDirectionTeamsToPbx, Field: NumberFieldRURI, Priority: 20, Match: `^1`, Replace: "low"},
+		{ID: 3, Direction: NumberDirectionTeamsToPbx, Field: NumberFieldRURI, Priority: 10, Match: `^1`, Replace: "id3"},
+		{ID: 4, Direction: NumberDirectionTeamsToPbx, Field: NumberFieldRURI, Priority: 10, Match: `^1`, Replace: "id4"},
+		{ID: 5, Direction: "unknown", Field: NumberFieldRURI, Priority: 1, Match: `^1`, Replace: "skipped"},
+	}
+
+	sets := numberRuleSets(rules)
+
+	if len(sets) != 2 {
+		t.Fatalf("got %d rule sets, want 2", len(sets))
+	}
+
+	if sets[0].DPID != 11 || sets[1].DPID != 22 {
+		t.Errorf("got dialplan ids %d and %d, want 11 and 22", sets[0].DPID, sets[1].DPID)
+	}
+
+	var ids []int64
+	for _, rule := range sets[0].Rules {
+		ids = append(ids, rule.ID)
+	}
+
+	// lower priority first, the same priority by id
+	if len(ids) != 3 || ids[0] != 3 || ids[1] != 4 || ids[2] != 2 {
+		t.Errorf("got rule order %v, want [3 4 2]", ids)
+	}
+
+	result, err := translateNumber(sets[0].Rules, "123")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.RuleID != 3 || result.Output != "id3" {
+		t.Errorf("got rule %d output %q, want rule 3 output %q", result.RuleID, result.Output, "id3")
+	}
+}
+
+func TestValidateNumberRule(t *testing.T) {
+	valid := types.NumberRule{
+		Direction: NumberDirectionTeamsToPbx,
+		Field:     NumberFieldRURI,
+		Match:     `^\+381(\d+)$`,
+		Replace:   `0\1`,
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(rule *types.NumberRule)
+		invalid bool
+	}{
+		{name: "valid rule", modify: func(rule *types.NumberRule) {}},
+		{name: "whole match back reference", modify: func(rule *types.NumberRule) { rule.Replace = `00\0` }},
+		{name: "plain replacement", modify: func(rule *types.NumberRule) { rule.Replace = "+381111" }},
+		{
+			name:    "unknown direction",
+			modify:  func(rule *types.NumberRule) { rule.Direction = "teams" },
+			invalid: true,
+		},
+		{
+			name:    "unknown field",
+			modify:  func(rule *types.NumberRule) { rule.Field = "contact" },
+			invalid: true,
+		},
+		{
+			name:    "invalid expression",
+			modify:  func(rule *types.NumberRule) { rule.Match = `^(\d+` },
+			invalid: true,
+		},
+		{
+			name:    "pseudo-variable in match",
+			modify:  func(rule *types.NumberRule) { rule.Match = `^$rU$` },
+			invalid: true,
+		},
+		{
+			name:    "pseudo-variable in replace",
+			modify:  func(rule *types.NumberRule) { rule.Replace = `$(fU)` },
+			invalid: true,
+		},
+		{
+			name:    "line break in replace",
+			modify:  func(rule *types.NumberRule) { rule.Replace = "0\n1" },
+			invalid: true,
+		},
+		{
+			name:    "back reference to a missing group",
+			modify:  func(rule *types.NumberRule) { rule.Replace = `0\2` },
+			invalid: true,
+		},
+		{
+			name:    "stray backslash in replace",
+			modify:  func(rule *types.NumberRule) { rule.Replace = `0\d` },
+			invalid: true,
+		},
+		{
+			name:    "escaped backslash in replace",
+			modify:  func(rule *types.NumberRule) { rule.Replace = `0\\1` },
+			invalid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := valid
+			tt.modify(&rule)
+
+			err := validateNumberRule(rule)
+
+			switch {
+			case tt.invalid && !errors.Is(err, ErrNumberRuleInvalid):
+				t.Errorf("got error %v, want %v", err, ErrNumberRuleInvalid)
+			case !tt.invalid && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestDialplanTemplate(t *testing.T) {
+	s := &sbc{
+		logger:  hclog.NewNullLogger(),
+		sbcData: types.Sbc{SQLiteFileLocation: filepath.Join(t.TempDir(), "sbc.db")},
+	}
+
+	tmpl, err := s.kamailioTemplate("sbc.test.com", "dbtext/dialplan")
+	if err != nil {
+		t.Fatalf("could not parse the dialplan template: %v", err)
+	}
+
+	rules := []types.NumberRule{
+		{ID: 7, Direction: NumberDirectionPbxToTeams, Field: NumberFieldPAI, Priority: 1, Match: `^1$`, Replace: "+1"},
+		{ID: 3, Direction: NumberDirectionTeamsToPbx, Field: NumberFieldRURI, Priority: 5,
+			Match: `^sip:(\d+)$`, Replace: `\1:a`},
+	}
+
+	var buff bytes.Buffer
+
+	if err = tmpl.Execute(&buff, kamailioConfigData{NumberRuleSets: numberRuleSets(rules)}); err != nil {
+		t.Fatalf("could not render the dialplan template: %v", err)
+	}
+
+	want := "id(int,auto) dpid(int) pr(int) match_op(int) match_exp(string) match_len(int) " +
+		"subst_exp(string) repl_exp(string) attrs(string,null)\n" +
+		`3:11:5:1:^sip\:(\\d+)$:0:^sip\:(\\d+)$:\\1\:a:` + "\n" +
+		`7:24:1:1:^1$:0:^1$:+1:` + "\n"
+
+	if buff.String() != want {
+		t.Errorf("got dialplan\n%s\nwant\n%s", buff.String(), want)
+	}
+}
+
+func TestDbtextValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "+381", want: "+381"},
+		{value: "a:b", want: `a\:b`},
+		{value: `\1`, want: `\\1`},
+		{value: `^(\d+):\1$`, want: `^(\\d+)\:\\1$`},
+	}
+
+	for _, tt := range tests {
+		if got := dbtextValue(tt.value); got != tt.want {
+			t.Errorf("dbtextValue(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
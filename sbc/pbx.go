@@ -0,0 +1,233 @@
+package sbc
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+)
+
+// supported pbx target transports
+const (
+	PbxTransportUDP = "udp"
+	PbxTransportTCP = "tcp"
+	PbxTransportTLS = "tls"
+)
+
+// dispatcher algorithms used for the pbx set
+const (
+	// dsAlgorithmPriority sends the calls to the highest priority target and fails over in the priority order
+	dsAlgorithmPriority = "8"
+	// dsAlgorithmRelativeWeight distributes the calls by the relative target weights
+	dsAlgorithmRelativeWeight = "11"
+)
+
+// pbx target weight is the dispatcher rweight attribute, which must be between 1 and 100
+const (
+	pbxTargetMinWeight = 1
+	pbxTargetMaxWeight = 100
+)
+
+var (
+	ErrPbxTargetInvalid = errors.New("invalid pbx target")
+	ErrPbxTargetPrimary = errors.New("pbx target is the primary pbx of the sbc, change it with the update command")
+)
+
+// PbxTargets returns the primary pbx of the sbc, followed by its additional targets
+func (s *sbc) PbxTargets(fqdnName string) ([]types.PbxTarget, error) {
+	if err := s.loadDeployedSbc(fqdnName); err != nil {
+		return nil, err
+	}
+
+	return s.pbxTargets()
+}
+
+// AddPbxTarget stores an additional pbx target of the sbc and reloads the Kamailio dispatcher
+func (s *sbc) AddPbxTarget(target types.PbxTarget) error {
+	if err := validatePbxTarget(&target); err != nil {
+		return err
+	}
+
+	if err := s.loadDeployedSbc(target.Fqdn); err != nil {
+		return err
+	}
+
+	targets, err := s.pbxTargets()
+	if err != nil {
+		return err
+	}
+
+	if isPrimaryPbxTarget(targets[0], target) {
+		return fmt.Errorf("%w: %s:%s/%s", ErrPbxTargetPrimary, target.Address, target.Port, target.Transport)
+	}
+
+	if err = validatePbxStreamTransports(append(targets, target)); err != nil {
+		return err
+	}
+
+	if err = s.db.AddPbxTarget(target); err != nil {
+		return err
+	}
+
+	s.logger.Info("PBX target added", "fqdn", target.Fqdn,
+		"target", fmt.Sprintf("%s:%s/%s", target.Address, target.Port, target.Transport))
+
+	return s.reloadKamailioConfig(target.Fqdn)
+}
+
+// RemovePbxTarget removes an additional pbx target of the sbc and reloads the Kamailio dispatcher
+func (s *sbc) RemovePbxTarget(target types.PbxTarget) error {
+	if target.Transport == "" {
+		target.Transport = PbxTransportUDP
+	}
+
+	if err := s.loadDeployedSbc(target.Fqdn); err != nil {
+		return err
+	}
+
+	transport, err := s.pbxTransport()
+	if err != nil {
+		return err
+	}
+
+	if isPrimaryPbxTarget(primaryPbxTarget(s.sbcData, transport.Transport), target) {
+		return fmt.Errorf("%w: %s:%s/%s", ErrPbxTargetPrimary, target.Address, target.Port, target.Transport)
+	}
+
+	if err = s.db.RemovePbxTarget(target); err != nil {
+		return err
+	}
+
+	s.logger.Info("PBX target removed", "fqdn", target.Fqdn,
+		"target", fmt.Sprintf("%s:%s/%s", target.Address, target.Port, target.Transport))
+
+	return s.reloadKamailioConfig(target.Fqdn)
+}
+
+// loadDeployedSbc applies the schema updates of older databases and loads the parameters of the deployed sbc
+func (s *sbc) loadDeployedSbc(fqdnName string) error {
+	if err := s.db.CreateFreshDB(); err != nil {
+		return fmt.Errorf("could not update database schema: %w", err)
+	}
+
+	sbcID := s.db.GetSBCIdFromFqdn(fqdnName)
+	if sbcID == 0 {
+		return fmt.Errorf("%w: %s", ErrSbcNotFound, fqdnName)
+	}
+
+	sbcData, err := s.db.GetSBCParameters(sbcID)
+	if err != nil {
+		return fmt.Errorf("could not get sbc parameters: %w", err)
+	}
+
+	// the file locations are not stored in the database
+	sbcData.LogFileLocation = s.sbcData.LogFileLocation
+	sbcData.DockerLogFileLocation = s.sbcData.DockerLogFileLocation
+	sbcData.SQLiteFileLocation = s.sbcData.SQLiteFileLocation
+
+	s.sbcData = sbcData
+
+	return nil
+}
+
+// pbxTargets returns the primary pbx of the current sbc, followed by its additional targets
+func (s *sbc) pbxTargets() ([]types.PbxTarget, error) {
+	transport, err := s.pbxTransport()
+	if err != nil {
+		return nil, err
+	}
+
+	targets, err := s.db.GetPbxTargets(s.sbcData.SbcName)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]types.PbxTarget{primaryPbxTarget(s.sbcData, transport.Transport)}, targets...), nil
+}
+
+// primaryPbxTarget is the pbx ip and port of the sbc, which is reached over the pbx transport with the priority 0
+func primaryPbxTarget(sbcData types.Sbc, transport string) types.PbxTarget {
+	return types.PbxTarget{
+		Fqdn:      sbcData.SbcName,
+		Address:   sbcData.PbxIP,
+		Port:      sbcData.PbxPort,
+		Transport: transport,
+		Weight:    pbxTargetMinWeight,
+		Primary:   true,
+	}
+}
+
+func isPrimaryPbxTarget(primary, target types.PbxTarget) bool {
+	return target.Address == primary.Address && target.Port == primary.Port && target.Transport == primary.Transport
+}
+
+// validatePbxTarget checks the target and sets the default transport and weight
+func validatePbxTarget(target *types.PbxTarget) error {
+	if target.Transport == "" {
+		target.Transport = PbxTransportUDP
+	}
+
+	if target.Weight == 0 {
+		target.Weight = pbxTargetMinWeight
+	}
+
+	target.Transport = strings.ToLower(target.Transport)
+
+	switch target.Transport {
+	case PbxTransportUDP, PbxTransportTCP, PbxTransportTLS:
+	default:
+		return fmt.Errorf("%w: transport %s (use one of %s, %s, %s)",
+			ErrPbxTargetInvalid, target.Transport, PbxTransportUDP, PbxTransportTCP, PbxTransportTLS)
+	}
+
+	if net.ParseIP(target.Address) == nil && !isHostname(target.Address) {
+		return fmt.Errorf("%w: address %q is not an ip address or a host name", ErrPbxTargetInvalid, target.Address)
+	}
+
+	if port, err := strconv.Atoi(target.Port); err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("%w: port %q", ErrPbxTargetInvalid, target.Port)
+	}
+
+	if target.Weight < pbxTargetMinWeight || target.Weight > pbxTargetMaxWeight {
+		return fmt.Errorf("%w: weight %d must be between %d and %d",
+			ErrPbxTargetInvalid, target.Weight, pbxTargetMinWeight, pbxTargetMaxWeight)
+	}
+
+	return nil
+}
+
+// isHostname reports if the name consists of valid dns labels
+func isHostname(name string) bool {
+	if name == "" || len(name) > 253 {
+		return false
+	}
+
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+
+		for _, char := range label {
+			if !(char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z' || char >= '0' && char <= '9' || char == '-') {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// pbxDispatcherAlgorithm load balances the targets by weight when they share the same priority,
+// otherwise the calls fail over in the priority order
+func pbxDispatcherAlgorithm(targets []types.PbxTarget) string {
+	for _, target := range targets {
+		if target.Priority != targets[0].Priority {
+			return dsAlgorithmPriority
+		}
+	}
+
+	return dsAlgorithmRelativeWeight
+}
@@ -0,0 +1,136 @@
+package sbc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+)
+
+// pbx registration expiry limits in seconds
+const (
+	pbxRegisterDefaultExpires = 3600
+	pbxRegisterMinExpires     = 60
+	pbxRegisterMaxExpires     = 86400
+)
+
+var ErrPbxCredentialsInvalid = errors.New("invalid pbx credentials")
+
+// PbxCredentials returns the pbx credentials of the sbc without the password,
+// the username is empty if the sbc has no credentials
+func (s *sbc) PbxCredentials(fqdnName string) (types.PbxCredentials, error) {
+	if err := s.loadDeployedSbc(fqdnName); err != nil {
+		return types.PbxCredentials{}, err
+	}
+
+	creds, err := s.db.GetPbxCredentials(fqdnName)
+	if err != nil {
+		return types.PbxCredentials{}, err
+	}
+
+	creds.Password = ""
+
+	return creds, nil
+}
+
+// SetPbxCredentials encrypts and stores the pbx credentials of the sbc and applies them to Kamailio
+func (s *sbc) SetPbxCredentials(creds types.PbxCredentials) error {
+	if err := s.loadDeployedSbc(creds.Fqdn); err != nil {
+		return err
+	}
+
+	// the realm of most PBXs is their address
+	if creds.Realm == "" {
+		creds.Realm = s.sbcData.PbxIP
+	}
+
+	if err := validatePbxCredentials(&creds); err != nil {
+		return err
+	}
+
+	var err error
+
+	creds.Password, err = s.encryptSecret(creds.Password)
+	if err != nil {
+		return err
+	}
+
+	if err = s.db.SavePbxCredentials(creds); err != nil {
+		return err
+	}
+
+	s.logger.Info("PBX credentials saved", "fqdn", creds.Fqdn, "username", creds.Username,
+		"realm", creds.Realm, "register", creds.Register)
+
+	return s.reloadKamailioConfig(creds.Fqdn)
+}
+
+// RemovePbxCredentials removes the pbx credentials of the sbc, so the trunk is authenticated by ip again
+func (s *sbc) RemovePbxCredentials(fqdnName string) error {
+	if err := s.loadDeployedSbc(fqdnName); err != nil {
+		return err
+	}
+
+	if err := s.db.RemovePbxCredentials(fqdnName); err != nil {
+		return err
+	}
+
+	s.logger.Info("PBX credentials removed", "fqdn", fqdnName)
+
+	return s.reloadKamailioConfig(fqdnName)
+}
+
+// pbxCredentials returns the decrypted pbx credentials of the current sbc, or nil if it has no credentials
+func (s *sbc) pbxCredentials() (*types.PbxCredentials, error) {
+	creds, err := s.db.GetPbxCredentials(s.sbcData.SbcName)
+	if err != nil {
+		return nil, err
+	}
+
+	if creds.Username == "" {
+		return nil, nil
+	}
+
+	creds.Password, err = s.decryptSecret(creds.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	return &creds, nil
+}
+
+// validatePbxCredentials checks the credentials and sets the default registration expiry
+func validatePbxCredentials(creds *types.PbxCredentials) error {
+	if creds.Username == "" || creds.Password == "" {
+		return fmt.Errorf("%w: username and password must be set", ErrPbxCredentialsInvalid)
+	}
+
+	// the values are written to the Kamailio configuration, where line breaks can not be escaped
+	for name, value := range map[string]string{
+		"username": creds.Username,
+		"password": creds.Password,
+		"realm":    creds.Realm,
+	} {
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("%w: %s must not contain line breaks", ErrPbxCredentialsInvalid, name)
+		}
+	}
+
+	if !creds.Register {
+		creds.Expires = 0
+
+		return nil
+	}
+
+	if creds.Expires == 0 {
+		creds.Expires = pbxRegisterDefaultExpires
+	}
+
+	if creds.Expires < pbxRegisterMinExpires || creds.Expires > pbxRegisterMaxExpires {
+		return fmt.Errorf("%w: expires %d must be between %d and %d seconds",
+			ErrPbxCredentialsInvalid, creds.Expires, pbxRegisterMinExpires, pbxRegisterMaxExpires)
+	}
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package sbc
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+)
+
+var ErrPbxTransportInvalid = errors.New("invalid pbx transport")
+
+// PbxTransport returns the transport of the primary pbx of the sbc and the TLS settings of the tls pbx targets
+func (s *sbc) PbxTransport(fqdnName string) (types.PbxTransport, error) {
+	if err := s.loadDeployedSbc(fqdnName); err != nil {
+		return types.PbxTransport{}, err
+	}
+
+	return s.pbxTransport()
+}
+
+// SetPbxTransport stores the pbx transport of the sbc and applies it to Kamailio
+func (s *sbc) SetPbxTransport(transport types.PbxTransport) error {
+	if err := validatePbxTransport(&transport); err != nil {
+		return err
+	}
+
+	if err := s.loadDeployedSbc(transport.Fqdn); err != nil {
+		return err
+	}
+
+	targets, err := s.db.GetPbxTargets(transport.Fqdn)
+	if err != nil {
+		return err
+	}
+
+	primary := primaryPbxTarget(s.sbcData, transport.Transport)
+
+	for _, target := range targets {
+		if isPrimaryPbxTarget(primary, target) {
+			return fmt.Errorf("%w: %s:%s/%s is an additional pbx target, remove it first",
+				ErrPbxTransportInvalid, target.Address, target.Port, target.Transport)
+		}
+	}
+
+	if err = validatePbxStreamTransports(append([]types.PbxTarget{primary}, targets...)); err != nil {
+		return err
+	}
+
+	if err = s.db.SavePbxTransport(transport); err != nil {
+		return err
+	}
+
+	s.logger.Info("PBX transport saved", "fqdn", transport.Fqdn, "transport", transport.Transport,
+		"client_certificate", transport.ClientCertificate, "verify_certificate", transport.VerifyCertificate,
+		"ca_certificate", transport.CACertificate != "")
+
+	return s.reloadKamailioConfig(transport.Fqdn)
+}
+
+// pbxTransport returns the pbx transport of the current sbc, the primary pbx is reached over udp if none is stored
+func (s *sbc) pbxTransport() (types.PbxTransport, error) {
+	transport, err := s.db.GetPbxTransport(s.sbcData.SbcName)
+	if err != nil {
+		return types.PbxTransport{}, err
+	}
+
+	if transport.Transport == "" {
+		transport.Transport = PbxTransportUDP
+	}
+
+	return transport, nil
+}
+
+// validatePbxTransport checks the transport and its TLS settings, the CA certificate enables the verification
+func validatePbxTransport(transport *types.PbxTransport) error {
+	transport.Transport = strings.ToLower(transport.Transport)
+	if transport.Transport == "" {
+		transport.Transport = PbxTransportUDP
+	}
+
+	switch transport.Transport {
+	case PbxTransportUDP, PbxTransportTCP, PbxTransportTLS:
+	default:
+		return fmt.Errorf("%w: transport %s (use one of %s, %s, %s)",
+			ErrPbxTransportInvalid, transport.Transport, PbxTransportUDP, PbxTransportTCP, PbxTransportTLS)
+	}
+
+	transport.CACertificate = strings.TrimSpace(transport.CACertificate)
+
+	if transport.Transport != PbxTransportTLS {
+		if transport.ClientCertificate || transport.VerifyCertificate || transport.CACertificate != "" {
+			return fmt.Errorf("%w: the certificate settings are only used with the %s transport",
+				ErrPbxTransportInvalid, PbxTransportTLS)
+		}
+
+		return nil
+	}
+
+	if transport.CACertificate == "" {
+		return nil
+	}
+
+	if err := validateCACertificate(transport.CACertificate); err != nil {
+		return err
+	}
+
+	transport.CACertificate += "\n"
+	transport.VerifyCertificate = true
+
+	return nil
+}
+
+// validateCACertificate checks that the PEM data holds only valid certificates
+func validateCACertificate(pemData string) error {
+	rest := []byte(pemData)
+	certCount := 0
+
+	for {
+		var block *pem.Block
+
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			return fmt.Errorf("%w: CA file holds a %s block, only certificates are allowed",
+				ErrPbxTransportInvalid, block.Type)
+		}
+
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return fmt.Errorf("%w: CA certificate: %s", ErrPbxTransportInvalid, err)
+		}
+
+		certCount++
+	}
+
+	if certCount == 0 || strings.TrimSpace(string(rest)) != "" {
+		return fmt.Errorf("%w: CA file must hold PEM encoded certificates", ErrPbxTransportInvalid)
+	}
+
+	return nil
+}
+
+// validatePbxStreamTransports checks that the pbx targets do not use both tcp and tls,
+// as both are listening on the sbc udp port
+func validatePbxStreamTransports(targets []types.PbxTarget) error {
+	var tcp, tls bool
+
+	for _, target := range targets {
+		tcp = tcp || target.Transport == PbxTransportTCP
+		tls = tls || target.Transport == PbxTransportTLS
+	}
+
+	if tcp && tls {
+		return fmt.Errorf("%w: the pbx targets can not use both %s and %s",
+			ErrPbxTransportInvalid, PbxTransportTCP, PbxTransportTLS)
+	}
+
+	return nil
+}
+
+// pbxTLSAddresses returns the ip:port of the tls pbx targets with an ip address,
+// the connections without a server id, e.g. the dispatcher keepalives, select the TLS profile by the address
+func pbxTLSAddresses(targets []types.PbxTarget) []string {
+	addresses := make([]string, 0, len(targets))
+	seen := make(map[string]bool)
+
+	for _, target := range targets {
+		if target.Transport != PbxTransportTLS || net.ParseIP(target.Address) == nil {
+			continue
+		}
+
+		address := net.JoinHostPort(target.Address, target.Port)
+		if !seen[address] {
+			seen[address] = true
+			addresses = append(addresses, address)
+		}
+	}
+
+	return addresses
+}
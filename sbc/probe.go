@@ -0,0 +1,84 @@
+package sbc
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/ZeljkoBenovic/tsbc/sip"
+	"github.com/spf13/viper"
+)
+
+// Probe sends SIP OPTIONS to the sbc over UDP, the way the PBX reaches it, and over TLS with the fqdn as SNI,
+// the way MS Teams reaches it. The sbc is healthy if it answers with a 2xx response.
+func (s *sbc) Probe(fqdnName string, opts types.ProbeOptions) ([]types.ProbeResult, error) {
+	if err := s.loadDeployedSbc(fqdnName); err != nil {
+		return nil, err
+	}
+
+	// Kamailio listens only on the host ip, which is taken from the running container if not set
+	if viper.GetString(flagnames.HostIP) == "" {
+		if err := s.inheritContainerConfig(s.sbcData.KamailioContainerID, flagnames.KamailioImage); err != nil {
+			return nil, err
+		}
+	}
+
+	hostIP := viper.GetString(flagnames.HostIP)
+	if hostIP == "" {
+		return nil, ErrHostIPNotSet
+	}
+
+	targets := []sip.Target{
+		{
+			Transport:  sip.TransportUDP,
+			Address:    net.JoinHostPort(hostIP, s.sbcData.SbcUDPPort),
+			ServerName: fqdnName,
+		},
+		{
+			Transport:          sip.TransportTLS,
+			Address:            net.JoinHostPort(hostIP, s.sbcData.SbcTLSPort),
+			ServerName:         fqdnName,
+			InsecureSkipVerify: opts.InsecureSkipVerify,
+		},
+	}
+
+	results := make([]types.ProbeResult, 0, len(targets))
+
+	for _, target := range targets {
+		results = append(results, s.probeTarget(fqdnName, target, opts.Timeout))
+	}
+
+	return results, nil
+}
+
+func (s *sbc) probeTarget(fqdnName string, target sip.Target, timeout time.Duration) types.ProbeResult {
+	result := types.ProbeResult{
+		Fqdn:      fqdnName,
+		Transport: target.Transport,
+		Address:   target.Address,
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, timeout)
+	defer cancel()
+
+	resp, err := sip.Options(ctx, target)
+	if err != nil {
+		s.logger.Debug("SIP OPTIONS probe failed", "transport", target.Transport, "address", target.Address, "err", err)
+		result.Error = err.Error()
+
+		return result
+	}
+
+	s.logger.Debug("SIP OPTIONS probe answered", "transport", target.Transport, "address", target.Address,
+		"code", resp.StatusCode, "latency", resp.Latency)
+
+	result.StatusCode = resp.StatusCode
+	result.Reason = resp.Reason
+	result.Server = resp.Server
+	result.LatencyMs = float64(resp.Latency.Microseconds()) / 1000
+	result.Healthy = resp.StatusCode >= 200 && resp.StatusCode < 300
+
+	return result
+}
@@ -7,6 +7,12 @@ func (s *sbc) Recreate(fqdnName string) error {
 
 	s.logger.Info("Recreating cluster", "fqdn", fqdnName)
 
+	if err = s.db.CreateFreshDB(); err != nil {
+		s.logger.Error("Could not update database schema", "err", err)
+
+		return err
+	}
+
 	// get container ids from the fqdn
 	for _, containerID := range s.db.GetContainerIDsFromSbcFqdn(fqdnName) {
 		if err = s.dockerCl.ContainerRemove(s.ctx, containerID, types.ContainerRemoveOptions{
@@ -27,6 +33,13 @@ func (s *sbc) Recreate(fqdnName string) error {
 		return err
 	}
 
+	// make sure the sbc has its own certificate, as older deployments shared a single one
+	if err = s.handleTLSCertificates(); err != nil {
+		s.logger.Error("Could not handle TLS certificate", "fqdn", fqdnName, "err", err)
+
+		return err
+	}
+
 	// create and run the cluster
 	if err = s.createAndRunSbcInfra(); err != nil {
 		s.logger.Error("Could not create and run cluster", "fqdn", fqdnName, "err", err)
@@ -0,0 +1,340 @@
+package sbc
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/db"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/spf13/viper"
+)
+
+var ErrRestoreTargetNotEmpty = errors.New("sbcs are already deployed on this host, restore needs a fresh installation")
+
+// Restore verifies the backup archive and recreates the database, the archived volumes
+// and all the containers with the archived image digests
+func (s *sbc) Restore(archiveFile string) (err error) {
+	stageDir, err := os.MkdirTemp("", "tsbc-restore-")
+	if err != nil {
+		return fmt.Errorf("could not create staging directory: %w", err)
+	}
+
+	defer os.RemoveAll(stageDir)
+
+	// nothing is changed until the whole archive is verified
+	manifest, err := readBackupArchive(archiveFile, stageDir)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("Backup verified", "created", manifest.Created, "sbcs", len(manifest.Sbcs))
+
+	if err = s.checkRestoreTarget(); err != nil {
+		return err
+	}
+
+	// the containers are created with the staged database, which replaces the database file only
+	// after all of them are running, so that a failed restore can be retried from the same archive
+	dbFile := s.sbcData.SQLiteFileLocation
+
+	if err = s.openStagedDatabase(filepath.Join(stageDir, backupDBFile)); err != nil {
+		return err
+	}
+
+	var (
+		containerID  string
+		containerIDs []string
+	)
+
+	defer func() {
+		if err != nil {
+			s.rollbackRestore(containerIDs, dbFile)
+		}
+	}()
+
+	if cont := findBackupContainer(manifest, letsEncryptStatusName, componentLetsEncrypt); cont.Name != "" {
+		containerID, err = s.restoreContainer(manifest, stageDir, LetsEncryptContainer, cont, cont.Env)
+		containerIDs = appendContainerID(containerIDs, containerID)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	hostIP := viper.GetString(flagnames.HostIP)
+
+	for _, fqdn := range manifest.Sbcs {
+		s.sbcData, err = s.db.GetSBCParameters(s.db.GetSBCIdFromFqdn(fqdn))
+		if err != nil {
+			return fmt.Errorf("could not get sbc parameters of %s: %w", fqdn, err)
+		}
+
+		rtpEngine := findBackupContainer(manifest, fqdn, componentRTPEngine)
+		setImageFlag(flagnames.RTPImage, rtpEngine)
+
+		containerID, err = s.restoreContainer(manifest, stageDir, RTPEngineContainer, rtpEngine, s.rtpEngineEnvVars())
+		containerIDs = appendContainerID(containerIDs, containerID)
+
+		if err != nil {
+			return err
+		}
+
+		kamailio := findBackupContainer(manifest, fqdn, componentKamailio)
+		setImageFlag(flagnames.KamailioImage, kamailio)
+
+		// the host ip is not stored in the database, so the archived one is used if the flag is not set
+		viper.Set(flagnames.HostIP, hostIP)
+		if hostIP == "" {
+			viper.Set(flagnames.HostIP, envVarValue(kamailio.Env, "HOST_IP"))
+		}
+
+		if viper.GetString(flagnames.HostIP) == "" {
+			return fmt.Errorf("%w: %s", ErrHostIPNotSet, fqdn)
+		}
+
+		// the restored configuration volume must not be overwritten by a generated config
+		s.sbcData.NewConfig = false
+
+		containerID, err = s.restoreContainer(manifest, stageDir, KamailioContainer, kamailio, s.kamailioEnvVars())
+		containerIDs = appendContainerID(containerIDs, containerID)
+
+		if err != nil {
+			return err
+		}
+
+		s.logger.Info("SBC restored", "fqdn", fqdn)
+	}
+
+	// the secret key goes first, as the restore can not be retried once the database is replaced
+	if _, ok := manifest.Files[backupSecretKey]; ok {
+		if err = s.restoreSecretKey(filepath.Join(stageDir, backupSecretKey)); err != nil {
+			return err
+		}
+	}
+
+	if err = s.restoreDatabase(filepath.Join(stageDir, backupDBFile), dbFile); err != nil {
+		return err
+	}
+
+	s.logger.Info("Backup restored", "file", archiveFile)
+
+	return nil
+}
+
+// appendContainerID appends the id of a created container, the empty id of a failed creation is skipped
+func appendContainerID(containerIDs []string, containerID string) []string {
+	if containerID == "" {
+		return containerIDs
+	}
+
+	return append(containerIDs, containerID)
+}
+
+// rollbackRestore removes the restored containers and reopens the untouched database file
+func (s *sbc) rollbackRestore(containerIDs []string, dbFile string) {
+	s.logger.Warn("Restore failed, removing the restored containers", "containers", len(containerIDs))
+
+	for _, containerID := range containerIDs {
+		if err := s.destroyContainerWithVolumes(containerID); err != nil {
+			s.logger.Error("Could not remove restored container", "id", containerID, "err", err)
+		}
+	}
+
+	if err := s.reopenDatabase(dbFile); err != nil {
+		s.logger.Error("Could not reopen database", "file", dbFile, "err", err)
+	}
+}
+
+// checkRestoreTarget makes sure that the restore does not overwrite an existing installation
+func (s *sbc) checkRestoreTarget() error {
+	schemaExists, err := s.db.SchemaExists()
+	if err != nil {
+		return fmt.Errorf("could not check database schema: %w", err)
+	}
+
+	if !schemaExists {
+		return nil
+	}
+
+	fqdns, err := s.db.GetAllFqdnNames()
+	if err != nil {
+		return fmt.Errorf("could not get SBC names: %w", err)
+	}
+
+	nodeID, err := s.db.GetLetsEncryptNodeID()
+	if err != nil {
+		return fmt.Errorf("could not get letsencrypt node id: %w", err)
+	}
+
+	if len(fqdns) > 0 || nodeID != "" {
+		return ErrRestoreTargetNotEmpty
+	}
+
+	return nil
+}
+
+// openStagedDatabase switches to the archived database snapshot, the database file is left untouched
+func (s *sbc) openStagedDatabase(snapshotFile string) error {
+	if err := s.reopenDatabase(snapshotFile); err != nil {
+		return err
+	}
+
+	// snapshots of older installations still need the tables that were added later
+	if err := s.db.CreateFreshDB(); err != nil {
+		return fmt.Errorf("could not update database schema: %w", err)
+	}
+
+	return nil
+}
+
+// restoreDatabase replaces the database file with the staged snapshot and reopens it
+func (s *sbc) restoreDatabase(snapshotFile, dbFile string) error {
+	if err := s.db.Close(); err != nil {
+		return fmt.Errorf("could not close database: %w", err)
+	}
+
+	content, err := os.ReadFile(snapshotFile)
+	if err != nil {
+		return fmt.Errorf("could not read database snapshot: %w", err)
+	}
+
+	if err = os.WriteFile(dbFile, content, 0644); err != nil {
+		return fmt.Errorf("could not write database file: %w", err)
+	}
+
+	if s.db, err = db.NewDB(s.logger, dbFile); err != nil {
+		return err
+	}
+
+	s.logger.Info("Database restored", "file", dbFile)
+
+	return nil
+}
+
+// reopenDatabase closes the current database and opens the database file
+func (s *sbc) reopenDatabase(dbFile string) error {
+	if err := s.db.Close(); err != nil {
+		s.logger.Warn("Could not close database", "err", err)
+	}
+
+	var err error
+
+	s.db, err = db.NewDB(s.logger, dbFile)
+
+	return err
+}
+
+// restoreSecretKey replaces the secret key file with the archived one, which encrypted the restored secrets
+func (s *sbc) restoreSecretKey(keyFile string) error {
+	key, err := os.ReadFile(keyFile)
+	if err != nil {
+		return fmt.Errorf("could not read archived secret key: %w", err)
+	}
+
+	if err = os.WriteFile(s.secretKeyFile(), key, 0600); err != nil {
+		return fmt.Errorf("could not write secret key file: %w", err)
+	}
+
+	s.logger.Info("Secret key restored", "file", s.secretKeyFile())
+
+	return nil
+}
+
+// restoreContainer creates the container, copies its archived volumes into it and starts it.
+// It returns the id of the created container, also if the container could not be started.
+func (s *sbc) restoreContainer(manifest types.BackupManifest, stageDir string, contName ContainerName,
+	cont archivedContainer, envVars []string,
+) (string, error) {
+	containerID, containerName, err := s.createContainer(contName, envVars)
+	if err != nil {
+		return "", fmt.Errorf("could not create %s container of %s: %w", cont.Component, cont.Fqdn, err)
+	}
+
+	for _, volume := range manifest.Volumes {
+		if volume.Fqdn != cont.Fqdn || volume.Component != cont.Component {
+			continue
+		}
+
+		if err = s.restoreVolume(containerID, stageDir, volume); err != nil {
+			return containerID, err
+		}
+	}
+
+	if err = s.dockerCl.ContainerStart(s.ctx, containerID, dockerTypes.ContainerStartOptions{}); err != nil {
+		return containerID, fmt.Errorf("could not start %s container: %w", containerName, err)
+	}
+
+	s.logger.Info("Container restored", "name", containerName, "image", cont.imageRef())
+
+	return containerID, nil
+}
+
+// restoreVolume copies the archived directory back to the container, which writes it to the mounted volume
+func (s *sbc) restoreVolume(containerID, stageDir string, volume types.BackupVolume) error {
+	content, err := os.Open(filepath.Join(stageDir, filepath.FromSlash(volume.File)))
+	if err != nil {
+		return fmt.Errorf("could not open %s volume archive: %w", volume.Name, err)
+	}
+
+	defer content.Close()
+
+	// the archive is rooted at the mounted directory, so it is copied to its parent
+	if err = s.dockerCl.CopyToContainer(s.ctx, containerID, path.Dir(volume.Target), content,
+		dockerTypes.CopyToContainerOptions{}); err != nil {
+		return fmt.Errorf("could not restore %s volume: %w", volume.Name, err)
+	}
+
+	s.logger.Debug("Volume restored", "volume", volume.Name)
+
+	return nil
+}
+
+// archivedContainer is a container listed in the backup manifest
+type archivedContainer struct {
+	types.BackupContainer
+}
+
+// imageRef returns the archived image digest, or the image name if the image had no registry digest,
+// so that the restored containers run exactly the same images
+func (c archivedContainer) imageRef() string {
+	if c.ImageDigest != "" {
+		return c.ImageDigest
+	}
+
+	return c.Image
+}
+
+// findBackupContainer returns the archived container, only the fqdn and component are set if it was not archived
+func findBackupContainer(manifest types.BackupManifest, fqdn, component string) archivedContainer {
+	for _, cont := range manifest.Containers {
+		if cont.Fqdn == fqdn && cont.Component == component {
+			return archivedContainer{cont}
+		}
+	}
+
+	return archivedContainer{types.BackupContainer{Fqdn: fqdn, Component: component}}
+}
+
+// setImageFlag pins the image of the restored container, the flag default is kept if the image was not archived
+func setImageFlag(imageFlag string, cont archivedContainer) {
+	if imageRef := cont.imageRef(); imageRef != "" {
+		viper.Set(imageFlag, imageRef)
+	}
+}
+
+// envVarValue returns the value of the environment variable, or an empty string if it is not set
+func envVarValue(envVars []string, name string) string {
+	for _, envVar := range envVars {
+		if strings.HasPrefix(envVar, name+"=") {
+			return strings.TrimPrefix(envVar, name+"=")
+		}
+	}
+
+	return ""
+}
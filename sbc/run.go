@@ -12,24 +12,34 @@ import (
 )
 
 func (s *sbc) Run() {
+	if err := s.deploy(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// deploy saves the sbc configuration read from the flags and creates all of its containers
+func (s *sbc) deploy() error {
 	// create new schema if db is empty
 	if err := s.db.CreateFreshDB(); err != nil {
 		s.logger.Error("Could not create fresh DB", "err", err)
-		os.Exit(1)
+
+		return err
 	}
 
 	// save sbc configuration information
 	sbcID, err := s.db.SaveSBCInformation()
 	if err != nil {
 		s.logger.Error("Could not save SBC information", "err", err)
-		os.Exit(1)
+
+		return err
 	}
 
 	// get data from database
 	s.sbcData, err = s.db.GetSBCParameters(sbcID)
 	if err != nil {
 		s.logger.Error("Could not get SBC parameters", "err", err)
-		os.Exit(1)
+
+		return err
 	}
 
 	// create and run lets encrypt node
@@ -37,7 +47,8 @@ func (s *sbc) Run() {
 		s.logger.Error("Could not handle TLS certificate", "err", err)
 		// if tls deployment fails, cleanup the database
 		s.db.RevertLastInsert()
-		os.Exit(1)
+
+		return err
 	}
 
 	// create and run containers infrastructure
@@ -45,11 +56,15 @@ func (s *sbc) Run() {
 		s.logger.Error("Could not create SBC infrastructure", "err", err)
 		// if docker deployment fails, cleanup the database
 		s.db.RevertLastInsert()
-		os.Exit(1)
+
+		return err
 	}
+
+	return nil
 }
 
-func (s *sbc) setFilePaths() error {
+// setFilePaths sets the log and database file paths and creates their directories, unless only planning
+func (s *sbc) setFilePaths(planOnly bool) error {
 	var err error
 
 	// set file paths from flag defaults
@@ -62,6 +77,10 @@ func (s *sbc) setFilePaths() error {
 		s.sbcData.SQLiteFileLocation = db.DefaultDBLocation()
 	}
 
+	if planOnly {
+		return nil
+	}
+
 	// create docker log directory
 	if err = os.MkdirAll(filepath.Dir(s.sbcData.DockerLogFileLocation), 755); err != nil && !os.IsExist(err) {
 		return fmt.Errorf("could not create docker log directory: %w", err)
@@ -2,8 +2,11 @@ package sbc
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"time"
 
 	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
 	"github.com/ZeljkoBenovic/tsbc/db"
@@ -17,9 +20,53 @@ type ISBC interface {
 	Run()
 	Restart(fqdnName string) error
 	Recreate(fqdnName string) error
+	Update(fqdnName string, update types.SbcUpdate) error
 	Destroy(fqdnName string) error
 	DestroyLetsEncryptNode() error
+	PlanRun() (types.DryRunPlan, error)
+	PlanRecreate(fqdnName string) (types.DryRunPlan, error)
+	PlanDestroy(fqdnName string) (types.DryRunPlan, error)
+	PlanDestroyLetsEncryptNode() (types.DryRunPlan, error)
 	List() ([]types.Sbc, error)
+	Plan(specs []types.SbcSpec, prune bool) ([]types.ApplyAction, error)
+	Apply(plan []types.ApplyAction) error
+	Status(fqdnName string) ([]types.ContainerStatus, error)
+	Logs(fqdnName string, opts types.LogOptions, out io.Writer) error
+	Probe(fqdnName string, opts types.ProbeOptions) ([]types.ProbeResult, error)
+	StartCapture(fqdnName string, retention types.CaptureRetention) error
+	StopCapture(fqdnName string) error
+	CaptureStatus(fqdnName string) (types.CaptureStatus, error)
+	RotateCaptures(fqdnName string) ([]string, error)
+	ExportCapture(fqdnName string, from, to time.Time, fileName string) (int, error)
+	Calls(fqdnName string, from, to time.Time, all bool) ([]types.Call, error)
+	KamailioRPC(fqdnName, method string, params ...any) (json.RawMessage, error)
+	KamailioStats(fqdnName, group string) ([]types.KamailioStat, error)
+	KamailioTLSConnections(fqdnName string) ([]types.KamailioTLSConnection, error)
+	ReloadKamailio(fqdnName string) ([]string, error)
+	RenderFirewall() (string, error)
+	ApplyFirewall() (string, error)
+	TeamsPrefixes() ([]types.TeamsPrefix, error)
+	UpdateTeamsPrefixes(fileName string) error
+	WatchCertificates(interval time.Duration) error
+	IssueWildcardCertificate(domain, dnsPlugin, credentialsFile string) error
+	PromoteCertificates() error
+	ExportCertificate(fqdnName, outDir, pkcs12Password string) error
+	DiffKamailioConfig(fqdnName string) ([]types.KamailioConfigDiff, error)
+	ApplyKamailioConfig(fqdnName string) error
+	PbxTargets(fqdnName string) ([]types.PbxTarget, error)
+	AddPbxTarget(target types.PbxTarget) error
+	RemovePbxTarget(target types.PbxTarget) error
+	PbxCredentials(fqdnName string) (types.PbxCredentials, error)
+	SetPbxCredentials(creds types.PbxCredentials) error
+	RemovePbxCredentials(fqdnName string) error
+	PbxTransport(fqdnName string) (types.PbxTransport, error)
+	SetPbxTransport(transport types.PbxTransport) error
+	NumberRules(fqdnName string) ([]types.NumberRule, error)
+	AddNumberRule(rule types.NumberRule) (types.NumberRule, error)
+	RemoveNumberRule(fqdnName string, ruleID int64) error
+	TestNumberRules(fqdnName, direction, field, number string) (types.NumberRuleResult, error)
+	Backup(outFile string) error
+	Restore(archiveFile string) error
 
 	Close()
 }
@@ -34,6 +81,17 @@ type sbc struct {
 }
 
 func NewSBC() (ISBC, error) {
+	return newSBC(false)
+}
+
+// NewPlanSBC creates the sbc instance of the dry-run plans, which makes no changes on the host.
+// No directories or log files are created and the database is opened read-only,
+// or replaced by an empty in-memory database if its file does not exist yet.
+func NewPlanSBC() (ISBC, error) {
+	return newSBC(true)
+}
+
+func newSBC(planOnly bool) (ISBC, error) {
 	// create sbc instance
 	sbcInst := &sbc{
 		ctx:     context.Background(),
@@ -41,25 +99,33 @@ func NewSBC() (ISBC, error) {
 	}
 
 	// create folders and file paths
-	if err := sbcInst.setFilePaths(); err != nil {
+	if err := sbcInst.setFilePaths(planOnly); err != nil {
 		return nil, fmt.Errorf("could not set file paths: %w", err)
 	}
 
-	var err error
-
-	// create docker log file
-	sbcInst.dockerLogFile, err = os.OpenFile(
-		sbcInst.sbcData.DockerLogFileLocation,
-		os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
-	if err != nil {
-		return nil, fmt.Errorf("could not create docker log file: %w", err)
+	var (
+		err       error
+		logOutput io.Writer
+	)
+
+	// the plans are logged to the console, and docker logs are only written by the deployments
+	if !planOnly {
+		// create docker log file
+		sbcInst.dockerLogFile, err = os.OpenFile(
+			sbcInst.sbcData.DockerLogFileLocation,
+			os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("could not create docker log file: %w", err)
+		}
+
+		logOutput = sbcInst.setLogOutput()
 	}
 
 	// create new logger instance
 	lg := hclog.New(&hclog.LoggerOptions{
 		Name:                 "sbc",
 		Level:                hclog.LevelFromString(viper.GetString(flagnames.LogLevel)),
-		Output:               sbcInst.setLogOutput(),
+		Output:               logOutput,
 		Color:                hclog.AutoColor,
 		ColorHeaderAndFields: true,
 	})
@@ -76,8 +142,14 @@ func NewSBC() (ISBC, error) {
 
 	lg.Debug("New docker client instance created")
 
+	dbLocation := sbcInst.sbcData.SQLiteFileLocation
+
+	if planOnly {
+		dbLocation = planDBLocation(dbLocation)
+	}
+
 	// create new database instance
-	dbInst, err := db.NewDB(lg, sbcInst.sbcData.SQLiteFileLocation)
+	dbInst, err := db.NewDB(lg, dbLocation)
 	if err != nil {
 		lg.Error("Could not instantiate new database instance", "err", err)
 
@@ -93,6 +165,16 @@ func NewSBC() (ISBC, error) {
 	return sbcInst, nil
 }
 
+// planDBLocation returns the read-only data source of the database file,
+// opening a missing sqlite file would create it
+func planDBLocation(dbLocation string) string {
+	if _, err := os.Stat(dbLocation); err != nil {
+		return ":memory:"
+	}
+
+	return "file:" + dbLocation + "?mode=ro"
+}
+
 func (s *sbc) Close() {
 	if err := s.dockerCl.Close(); err != nil {
 		s.logger.Error("Could not close docker client", "err", err)
@@ -102,6 +184,11 @@ func (s *sbc) Close() {
 		s.logger.Error("Could not close database client", "err", err)
 	}
 
+	// the plans do not open the docker log file
+	if s.dockerLogFile == nil {
+		return
+	}
+
 	if err := s.dockerLogFile.Close(); err != nil {
 		s.logger.Error("Could not close docker log file handle", "err", err)
 	}
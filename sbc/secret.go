@@ -0,0 +1,124 @@
+package sbc
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// secretKeyFileName is the AES-256 key, that encrypts the secrets stored in the database, next to the database file
+const secretKeyFileName = "secret.key"
+
+const secretKeySize = 32
+
+var (
+	ErrSecretKeyInvalid = errors.New("secret key file is invalid")
+	ErrSecretInvalid    = errors.New("could not decrypt secret, the secret key file does not match the database")
+)
+
+// secretKeyFile returns the location of the secret key file
+func (s *sbc) secretKeyFile() string {
+	return filepath.Join(filepath.Dir(s.sbcData.SQLiteFileLocation), secretKeyFileName)
+}
+
+// secretKey reads the secret key, a new key is created if the file does not exist and create is set
+func (s *sbc) secretKey(create bool) ([]byte, error) {
+	key, err := os.ReadFile(s.secretKeyFile())
+
+	switch {
+	case err == nil:
+		if len(key) != secretKeySize {
+			return nil, fmt.Errorf("%w: %s", ErrSecretKeyInvalid, s.secretKeyFile())
+		}
+
+		return key, nil
+	case !errors.Is(err, os.ErrNotExist) || !create:
+		return nil, fmt.Errorf("could not read secret key: %w", err)
+	}
+
+	key = make([]byte, secretKeySize)
+	if _, err = io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("could not generate secret key: %w", err)
+	}
+
+	// the key must never be replaced, so the file is only created if it still does not exist
+	file, err := os.OpenFile(s.secretKeyFile(), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("could not create secret key file: %w", err)
+	}
+
+	defer file.Close()
+
+	if _, err = file.Write(key); err != nil {
+		return nil, fmt.Errorf("could not write secret key file: %w", err)
+	}
+
+	s.logger.Info("Secret key created, back it up together with the database", "file", s.secretKeyFile())
+
+	return key, nil
+}
+
+// encryptSecret encrypts the secret with AES-GCM and returns the base64 encoded nonce and ciphertext
+func (s *sbc) encryptSecret(secret string) (string, error) {
+	key, err := s.secretKey(true)
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("could not generate nonce: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(secret), nil)), nil
+}
+
+// decryptSecret decrypts the secret encrypted by encryptSecret
+func (s *sbc) decryptSecret(encrypted string) (string, error) {
+	key, err := s.secretKey(false)
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil || len(content) < gcm.NonceSize() {
+		return "", ErrSecretInvalid
+	}
+
+	secret, err := gcm.Open(nil, content[:gcm.NonceSize()], content[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", ErrSecretInvalid
+	}
+
+	return string(secret), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("could not create cipher: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("could not create cipher: %w", err)
+	}
+
+	return gcm, nil
+}
@@ -0,0 +1,105 @@
+package sbc
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	"github.com/docker/docker/client"
+)
+
+const (
+	componentKamailio    = "kamailio"
+	componentRTPEngine   = "rtpengine"
+	componentLetsEncrypt = "certs"
+
+	// letsEncryptStatusName is shown instead of the fqdn for the LetsEncrypt node, as it is shared by all sbcs
+	letsEncryptStatusName = "certificates"
+
+	containerStateMissing = "missing"
+)
+
+// Status returns the live container state of the sbc, or of all the sbcs and the LetsEncrypt node
+// if the fqdn is not set
+func (s *sbc) Status(fqdnName string) ([]types.ContainerStatus, error) {
+	fqdnNames := []string{fqdnName}
+
+	if fqdnName == "" {
+		var err error
+
+		fqdnNames, err = s.db.GetAllFqdnNames()
+		if err != nil {
+			return nil, fmt.Errorf("could not get SBC names: %w", err)
+		}
+	}
+
+	statuses := make([]types.ContainerStatus, 0, len(fqdnNames)*2+1)
+
+	for _, fqdn := range fqdnNames {
+		ids := s.db.GetContainerIDsFromSbcFqdn(fqdn)
+		if ids == nil {
+			return nil, fmt.Errorf("%w: %s", ErrCouldNotGetContainerIDs, fqdn)
+		}
+
+		statuses = append(statuses,
+			s.containerStatus(fqdn, componentKamailio, ids[0]),
+			s.containerStatus(fqdn, componentRTPEngine, ids[1]),
+		)
+	}
+
+	if fqdnName == "" {
+		nodeID, err := s.db.GetLetsEncryptNodeID()
+		if err != nil {
+			return nil, fmt.Errorf("could not get letsencrypt node id: %w", err)
+		}
+
+		if nodeID != "" {
+			statuses = append(statuses, s.containerStatus(letsEncryptStatusName, componentLetsEncrypt, nodeID))
+		}
+	}
+
+	return statuses, nil
+}
+
+func (s *sbc) containerStatus(fqdn, component, containerID string) types.ContainerStatus {
+	status := types.ContainerStatus{
+		Fqdn:        fqdn,
+		Component:   component,
+		ContainerID: containerID,
+		State:       containerStateMissing,
+	}
+
+	cDetails, err := s.dockerCl.ContainerInspect(s.ctx, containerID)
+	if err != nil {
+		if !client.IsErrNotFound(err) {
+			s.logger.Error("Could not inspect container", "id", containerID, "err", err)
+		}
+
+		return status
+	}
+
+	status.State = cDetails.State.Status
+	status.RestartCount = cDetails.RestartCount
+	status.ExitCode = cDetails.State.ExitCode
+	status.OOMKilled = cDetails.State.OOMKilled
+	status.Image = cDetails.Config.Image
+	status.ImageDigest = cDetails.Image
+
+	if startedAt, err := time.Parse(time.RFC3339Nano, cDetails.State.StartedAt); err == nil {
+		status.StartedAt = startedAt
+
+		if cDetails.State.Running {
+			status.UptimeSeconds = int64(time.Since(startedAt).Seconds())
+		}
+	}
+
+	// prefer the registry digest, as the local image id differs between hosts
+	if imageDetails, _, err := s.dockerCl.ImageInspectWithRaw(s.ctx, cDetails.Image); err == nil &&
+		len(imageDetails.RepoDigests) > 0 {
+		status.ImageDigest = imageDetails.RepoDigests[0]
+	}
+
+	status.Healthy = cDetails.State.Running && !cDetails.State.Restarting && !cDetails.State.OOMKilled
+
+	return status
+}
@@ -1,29 +1,338 @@
 package types
 
+import "time"
+
 type Sbc struct {
-	Fqdn string
-	Kamailio
-	RTPEngine
+	Fqdn      string `json:"fqdn" yaml:"fqdn"`
+	Kamailio  `yaml:",inline"`
+	RTPEngine `yaml:",inline"`
+
+	KamailioContainerID  string    `json:"kamailio_container_id" yaml:"kamailio_container_id"`
+	RTPEngineContainerID string    `json:"rtp_engine_container_id" yaml:"rtp_engine_container_id"`
+	Created              time.Time `json:"created" yaml:"created"`
 
-	LogFileLocation       string
-	DockerLogFileLocation string
-	SQLiteFileLocation    string
+	LogFileLocation       string `json:"-" yaml:"-"`
+	DockerLogFileLocation string `json:"-" yaml:"-"`
+	SQLiteFileLocation    string `json:"-" yaml:"-"`
 }
 
 type Kamailio struct {
-	NewConfig     bool
-	EnableSIPDump bool
-	SbcName       string
-	SbcTLSPort    string
-	SbcUDPPort    string
-	PbxIP         string
-	PbxPort       string
-	RTPEnginePort string
+	NewConfig     bool   `json:"new_config" yaml:"new_config"`
+	EnableSIPDump bool   `json:"enable_sip_dump" yaml:"enable_sip_dump"`
+	SbcName       string `json:"sbc_name" yaml:"sbc_name"`
+	SbcTLSPort    string `json:"sbc_tls_port" yaml:"sbc_tls_port"`
+	SbcUDPPort    string `json:"sbc_udp_port" yaml:"sbc_udp_port"`
+	PbxIP         string `json:"pbx_ip" yaml:"pbx_ip"`
+	PbxPort       string `json:"pbx_port" yaml:"pbx_port"`
+	RTPEnginePort string `json:"rtp_engine_port" yaml:"rtp_engine_port"`
 }
 
 type RTPEngine struct {
-	RTPMaxPort    string
-	RTPMinPort    string
+	RTPMaxPort    string `json:"rtp_max_port" yaml:"rtp_max_port"`
+	RTPMinPort    string `json:"rtp_min_port" yaml:"rtp_min_port"`
+	MediaPublicIP string `json:"media_public_ip" yaml:"media_public_ip"`
+	NgListen      string `json:"ng_listen" yaml:"ng_listen"`
+}
+
+// CertRenewal is a single certificate change detected by the renewal watcher
+type CertRenewal struct {
+	Fqdn         string
+	Serial       string
+	NotAfter     time.Time
+	Detected     time.Time
+	ReloadMethod string
+	ReloadResult string
+}
+
+// ACMEIssuance is a single certificate issued by the LetsEncrypt ACME server
+type ACMEIssuance struct {
+	CertName         string
+	Domains          []string
+	RegisteredDomain string
+	Staging          bool
+}
+
+// ContainerStatus is the live state of a single sbc or LetsEncrypt container
+type ContainerStatus struct {
+	Fqdn          string    `json:"fqdn" yaml:"fqdn"`
+	Component     string    `json:"component" yaml:"component"`
+	ContainerID   string    `json:"container_id" yaml:"container_id"`
+	State         string    `json:"state" yaml:"state"`
+	StartedAt     time.Time `json:"started_at" yaml:"started_at"`
+	UptimeSeconds int64     `json:"uptime_seconds" yaml:"uptime_seconds"`
+	RestartCount  int       `json:"restart_count" yaml:"restart_count"`
+	ExitCode      int       `json:"exit_code" yaml:"exit_code"`
+	OOMKilled     bool      `json:"oom_killed" yaml:"oom_killed"`
+	Image         string    `json:"image" yaml:"image"`
+	ImageDigest   string    `json:"image_digest" yaml:"image_digest"`
+	Healthy       bool      `json:"healthy" yaml:"healthy"`
+}
+
+// LogOptions select and filter the streamed component logs
+type LogOptions struct {
+	Components []string
+	Follow     bool
+	Since      string
+	Grep       string
+}
+
+// DesiredState is the declarative list of sbcs read by the apply command
+type DesiredState struct {
+	Sbcs []SbcSpec `yaml:"sbcs"`
+}
+
+// SbcSpec is the desired configuration of a single sbc, empty optional values use the run command defaults
+type SbcSpec struct {
+	Fqdn          string `yaml:"fqdn"`
+	PbxIP         string `yaml:"pbx_ip"`
+	PbxPort       string `yaml:"pbx_port"`
+	PublicIP      string `yaml:"public_ip"`
+	HostIP        string `yaml:"host_ip"`
+	EnableSIPDump bool   `yaml:"enable_sip_dump"`
+
+	SbcTLSPort    string `yaml:"sbc_tls_port"`
+	SbcUDPPort    string `yaml:"sbc_udp_port"`
+	RTPEnginePort string `yaml:"rtp_engine_port"`
+	RTPMinPort    string `yaml:"rtp_min_port"`
+	RTPMaxPort    string `yaml:"rtp_max_port"`
+
+	KamailioImage  string `yaml:"kamailio_image"`
+	RTPEngineImage string `yaml:"rtp_engine_image"`
+}
+
+// apply plan actions
+const (
+	ApplyActionCreate   = "create"
+	ApplyActionRecreate = "recreate"
+	ApplyActionDestroy  = "destroy"
+	ApplyActionNone     = "none"
+)
+
+// ApplyAction is a single step of the plan that converges the deployed sbcs to the desired state
+type ApplyAction struct {
+	Action  string
+	Fqdn    string
+	Reasons []string
+	Spec    SbcSpec
+}
+
+// SbcUpdate holds the sbc parameters that can be changed in place, empty values are left unchanged
+type SbcUpdate struct {
+	PbxIP         string
+	PbxPort       string
 	MediaPublicIP string
-	NgListen      string
+	EnableSIPDump *bool
+}
+
+// DryRunPlan describes the changes a command would make, without making them
+type DryRunPlan struct {
+	Command          string             `json:"command" yaml:"command"`
+	Fqdn             string             `json:"fqdn" yaml:"fqdn"`
+	Sbc              *Sbc               `json:"sbc,omitempty" yaml:"sbc,omitempty"`
+	Certificate      string             `json:"certificate,omitempty" yaml:"certificate,omitempty"`
+	RemoveContainers []PlannedContainer `json:"remove_containers" yaml:"remove_containers"`
+	CreateContainers []PlannedContainer `json:"create_containers" yaml:"create_containers"`
+	RemoveVolumes    []string           `json:"remove_volumes" yaml:"remove_volumes"`
+	Database         []string           `json:"database" yaml:"database"`
+	Warnings         []string           `json:"warnings" yaml:"warnings"`
+}
+
+// PlannedContainer is a container that would be created or removed
+type PlannedContainer struct {
+	Name   string   `json:"name" yaml:"name"`
+	ID     string   `json:"id,omitempty" yaml:"id,omitempty"`
+	Image  string   `json:"image" yaml:"image"`
+	Env    []string `json:"env,omitempty" yaml:"env,omitempty"`
+	Mounts []string `json:"mounts" yaml:"mounts"`
+}
+
+// BackupManifest describes the content of a backup archive, every archived file is listed with its sha256 checksum
+type BackupManifest struct {
+	Version    int               `json:"version"`
+	Created    time.Time         `json:"created"`
+	Sbcs       []string          `json:"sbcs"`
+	Containers []BackupContainer `json:"containers"`
+	Volumes    []BackupVolume    `json:"volumes"`
+	Files      map[string]string `json:"files"`
+}
+
+// BackupContainer is a container running at the time of the backup, together with its image digest
+type BackupContainer struct {
+	Fqdn        string   `json:"fqdn"`
+	Component   string   `json:"component"`
+	Name        string   `json:"name"`
+	Image       string   `json:"image"`
+	ImageDigest string   `json:"image_digest"`
+	Env         []string `json:"env"`
+}
+
+// BackupVolume is a docker volume archived from the container directory it is mounted to
+type BackupVolume struct {
+	Name      string `json:"name"`
+	Fqdn      string `json:"fqdn"`
+	Component string `json:"component"`
+	Target    string `json:"target"`
+	File      string `json:"file"`
+}
+
+// KamailioConfigDiff is the difference between the deployed and the rendered Kamailio configuration file
+type KamailioConfigDiff struct {
+	File     string `json:"file" yaml:"file"`
+	Template string `json:"template" yaml:"template"`
+	Diff     string `json:"diff" yaml:"diff"`
+}
+
+// KamailioStat is a single Kamailio statistic, the values are kept as Kamailio reports them
+type KamailioStat struct {
+	Group string `json:"group" yaml:"group"`
+	Name  string `json:"name" yaml:"name"`
+	Value string `json:"value" yaml:"value"`
+}
+
+// KamailioTLSConnection is a TLS connection of Kamailio, the timeout is in seconds
+type KamailioTLSConnection struct {
+	ID      int    `json:"id" yaml:"id"`
+	SrcIP   string `json:"src_ip" yaml:"src_ip"`
+	SrcPort int    `json:"src_port" yaml:"src_port"`
+	DstIP   string `json:"dst_ip" yaml:"dst_ip"`
+	DstPort int    `json:"dst_port" yaml:"dst_port"`
+	Cipher  string `json:"cipher" yaml:"cipher"`
+	State   string `json:"state" yaml:"state"`
+	Timeout int    `json:"timeout" yaml:"timeout"`
+}
+
+// PbxTarget is a PBX destination of the sbc, the primary target is the pbx ip and port of the sbc
+type PbxTarget struct {
+	Fqdn      string `json:"fqdn" yaml:"fqdn"`
+	Address   string `json:"address" yaml:"address"`
+	Port      string `json:"port" yaml:"port"`
+	Transport string `json:"transport" yaml:"transport"`
+	Priority  int    `json:"priority" yaml:"priority"`
+	Weight    int    `json:"weight" yaml:"weight"`
+	Primary   bool   `json:"primary" yaml:"primary"`
+}
+
+// PbxTransport is the transport of the primary pbx of the sbc and the TLS settings of all the tls pbx targets
+type PbxTransport struct {
+	Fqdn      string `json:"fqdn" yaml:"fqdn"`
+	Transport string `json:"transport" yaml:"transport"`
+	// ClientCertificate presents the sbc certificate to the pbx
+	ClientCertificate bool `json:"client_certificate" yaml:"client_certificate"`
+	// VerifyCertificate verifies the pbx certificate, with the CA certificate if set, or the system CAs
+	VerifyCertificate bool `json:"verify_certificate" yaml:"verify_certificate"`
+	// CACertificate holds the PEM encoded CA certificates of the pbx
+	CACertificate string `json:"ca_certificate,omitempty" yaml:"ca_certificate,omitempty"`
+}
+
+// CaptureRetention limits the sip capture files of an sbc, the oldest files are removed first
+type CaptureRetention struct {
+	MaxSizeMB int
+	MaxAge    time.Duration
+}
+
+// CaptureStatus is the sip capture state of an sbc and the usage of its capture directory
+type CaptureStatus struct {
+	Fqdn    string `json:"fqdn" yaml:"fqdn"`
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+	// EnabledOnStart is the capture state after a Kamailio restart, set with --kamailio-sip-dump
+	EnabledOnStart bool      `json:"enabled_on_start" yaml:"enabled_on_start"`
+	Directory      string    `json:"directory" yaml:"directory"`
+	Files          int       `json:"files" yaml:"files"`
+	SizeBytes      int64     `json:"size_bytes" yaml:"size_bytes"`
+	Oldest         time.Time `json:"oldest,omitempty" yaml:"oldest,omitempty"`
+	Newest         time.Time `json:"newest,omitempty" yaml:"newest,omitempty"`
+	MaxSizeMB      int       `json:"max_size_mb" yaml:"max_size_mb"`
+	MaxAge         string    `json:"max_age" yaml:"max_age"`
+}
+
+// Call is a call, or another dialog, found in the sip capture, with its messages in the captured order
+type Call struct {
+	Summary  CallSummary   `json:"summary" yaml:"summary"`
+	Messages []CallMessage `json:"messages" yaml:"messages"`
+}
+
+// CallSummary is the outcome of a call, the caller and the callee are the user parts of the initial request
+type CallSummary struct {
+	CallID string    `json:"call_id" yaml:"call_id"`
+	Method string    `json:"method" yaml:"method"`
+	Start  time.Time `json:"start" yaml:"start"`
+	Caller string    `json:"caller" yaml:"caller"`
+	Callee string    `json:"callee" yaml:"callee"`
+	Result string    `json:"result" yaml:"result"`
+	// Status and Reason are of the final response to the initial request, the status is 0 without one
+	Status int    `json:"status" yaml:"status"`
+	Reason string `json:"reason" yaml:"reason"`
+	// Duration is the time between the answer and the first BYE, empty if the call was not answered or not ended
+	Duration      string `json:"duration" yaml:"duration"`
+	FailureReason string `json:"failure_reason" yaml:"failure_reason"`
+	Messages      int    `json:"messages" yaml:"messages"`
+}
+
+// CallMessage is a single captured message of a call, the addresses are ip:port
+type CallMessage struct {
+	Time      time.Time `json:"time" yaml:"time"`
+	Sent      bool      `json:"sent" yaml:"sent"`
+	Transport string    `json:"transport" yaml:"transport"`
+	Src       string    `json:"src" yaml:"src"`
+	Dst       string    `json:"dst" yaml:"dst"`
+	// Label is the method of the requests and the status code and reason of the responses
+	Label string `json:"label" yaml:"label"`
+}
+
+// PbxCredentials authenticate the sbc on the PBX trunk, the password is encrypted in the database
+type PbxCredentials struct {
+	Fqdn     string `json:"fqdn" yaml:"fqdn"`
+	Username string `json:"username" yaml:"username"`
+	Password string `json:"-" yaml:"-"`
+	Realm    string `json:"realm" yaml:"realm"`
+	Register bool   `json:"register" yaml:"register"`
+	Expires  int    `json:"expires" yaml:"expires"`
+}
+
+// NumberRule rewrites the numbers of a sip header field in a single direction, the rules with the lowest priority
+// are evaluated first and only the first matching rule is applied
+type NumberRule struct {
+	ID        int64  `json:"id" yaml:"id"`
+	Fqdn      string `json:"fqdn" yaml:"fqdn"`
+	Direction string `json:"direction" yaml:"direction"`
+	Field     string `json:"field" yaml:"field"`
+	Priority  int    `json:"priority" yaml:"priority"`
+	Match     string `json:"match" yaml:"match"`
+	Replace   string `json:"replace" yaml:"replace"`
+}
+
+// NumberRuleResult is the result of a sample number evaluated against the number rules
+type NumberRuleResult struct {
+	Direction string `json:"direction" yaml:"direction"`
+	Field     string `json:"field" yaml:"field"`
+	Input     string `json:"input" yaml:"input"`
+	Output    string `json:"output" yaml:"output"`
+	Matched   bool   `json:"matched" yaml:"matched"`
+	RuleID    int64  `json:"rule_id" yaml:"rule_id"`
+}
+
+// ProbeOptions configure the SIP OPTIONS probe of an sbc
+type ProbeOptions struct {
+	Timeout            time.Duration
+	InsecureSkipVerify bool
+}
+
+// ProbeResult is the response of an sbc to a single SIP OPTIONS probe
+type ProbeResult struct {
+	Fqdn       string  `json:"fqdn" yaml:"fqdn"`
+	Transport  string  `json:"transport" yaml:"transport"`
+	Address    string  `json:"address" yaml:"address"`
+	StatusCode int     `json:"status_code" yaml:"status_code"`
+	Reason     string  `json:"reason" yaml:"reason"`
+	Server     string  `json:"server" yaml:"server"`
+	LatencyMs  float64 `json:"latency_ms" yaml:"latency_ms"`
+	Error      string  `json:"error,omitempty" yaml:"error,omitempty"`
+	Healthy    bool    `json:"healthy" yaml:"healthy"`
+}
+
+// TeamsPrefix is an MS Teams address range, allowed by the host firewall to the signaling or media ports
+type TeamsPrefix struct {
+	Prefix  string `json:"prefix" yaml:"prefix"`
+	Kind    string `json:"kind" yaml:"kind"`
+	Default bool   `json:"default" yaml:"default"`
 }
@@ -0,0 +1,142 @@
+package sbc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ZeljkoBenovic/tsbc/cmd/helpers/flagnames"
+	"github.com/ZeljkoBenovic/tsbc/sbc/types"
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/spf13/viper"
+)
+
+var ErrSbcNotFound = errors.New("sbc not found")
+
+// Update changes the sbc parameters in the database and recreates only the containers using them,
+// the allocated ports and the certificate are kept
+func (s *sbc) Update(fqdnName string, update types.SbcUpdate) error {
+	if err := s.db.CreateFreshDB(); err != nil {
+		return fmt.Errorf("could not update database schema: %w", err)
+	}
+
+	current, err := s.db.GetSBCParameters(s.db.GetSBCIdFromFqdn(fqdnName))
+	if err != nil {
+		return fmt.Errorf("could not get sbc parameters: %w", err)
+	}
+
+	if current.Fqdn == "" {
+		return fmt.Errorf("%w: %s", ErrSbcNotFound, fqdnName)
+	}
+
+	updated := current
+	recreateKamailio, recreateRTPEngine := false, false
+
+	if update.PbxIP != "" && update.PbxIP != current.PbxIP {
+		updated.PbxIP = update.PbxIP
+		recreateKamailio = true
+	}
+
+	if update.PbxPort != "" && update.PbxPort != current.PbxPort {
+		updated.PbxPort = update.PbxPort
+		recreateKamailio = true
+	}
+
+	if update.EnableSIPDump != nil && *update.EnableSIPDump != current.EnableSIPDump {
+		updated.EnableSIPDump = *update.EnableSIPDump
+		recreateKamailio = true
+	}
+
+	if update.MediaPublicIP != "" && update.MediaPublicIP != current.MediaPublicIP {
+		updated.MediaPublicIP = update.MediaPublicIP
+		recreateRTPEngine = true
+	}
+
+	if !recreateKamailio && !recreateRTPEngine {
+		s.logger.Info("SBC parameters unchanged, nothing to update", "fqdn", fqdnName)
+
+		return nil
+	}
+
+	// containers are replaced before the database is updated, so a failed update can be retried
+	s.sbcData = updated
+
+	ids := s.db.GetContainerIDsFromSbcFqdn(fqdnName)
+	if ids == nil {
+		return fmt.Errorf("%w: %s", ErrCouldNotGetContainerIDs, fqdnName)
+	}
+
+	if recreateRTPEngine {
+		if err = s.replaceContainer(RTPEngineContainer, ids[1], flagnames.RTPImage, s.rtpEngineEnvVars); err != nil {
+			return fmt.Errorf("could not recreate rtp-engine container: %w", err)
+		}
+	}
+
+	if recreateKamailio {
+		if err = s.replaceContainer(KamailioContainer, ids[0], flagnames.KamailioImage, s.kamailioEnvVars); err != nil {
+			return fmt.Errorf("could not recreate kamailio container: %w", err)
+		}
+	}
+
+	if err = s.db.UpdateSBCInformation(fqdnName, updated); err != nil {
+		return fmt.Errorf("could not update sbc information: %w", err)
+	}
+
+	s.logger.Info("SBC updated", "fqdn", fqdnName,
+		"kamailio_recreated", recreateKamailio, "rtp_engine_recreated", recreateRTPEngine)
+
+	return nil
+}
+
+// replaceContainer removes the container and creates it again with the same image and host ip,
+// the environment variables are built after the inherited settings are set
+func (s *sbc) replaceContainer(contName ContainerName, containerID, imageFlag string, envVars func() []string) error {
+	if err := s.inheritContainerConfig(containerID, imageFlag); err != nil {
+		return err
+	}
+
+	// kamailio can not be configured without the host ip
+	if contName == KamailioContainer && viper.GetString(flagnames.HostIP) == "" {
+		return ErrHostIPNotSet
+	}
+
+	if err := s.dockerCl.ContainerRemove(s.ctx, containerID, dockerTypes.ContainerRemoveOptions{
+		RemoveVolumes: true,
+		Force:         true,
+	}); err != nil && !client.IsErrNotFound(err) {
+		return fmt.Errorf("could not remove container: %w", err)
+	}
+
+	s.logger.Debug("Container removed", "id", containerID)
+
+	return s.createAndRunContainer(contName, envVars())
+}
+
+// inheritContainerConfig keeps the image and the host ip of the replaced container, unless they are set with flags
+func (s *sbc) inheritContainerConfig(containerID, imageFlag string) error {
+	cDetails, err := s.dockerCl.ContainerInspect(s.ctx, containerID)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return nil
+		}
+
+		return fmt.Errorf("could not inspect container: %w", err)
+	}
+
+	if !viper.IsSet(imageFlag) {
+		viper.Set(imageFlag, cDetails.Config.Image)
+	}
+
+	if viper.GetString(flagnames.HostIP) != "" {
+		return nil
+	}
+
+	for _, envVar := range cDetails.Config.Env {
+		if strings.HasPrefix(envVar, "HOST_IP=") {
+			viper.Set(flagnames.HostIP, strings.TrimPrefix(envVar, "HOST_IP="))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package sbc
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// letsEncryptDNSConfDir holds the DNS provider credentials inside the LetsEncrypt container
+const letsEncryptDNSConfDir = "/config/dns-conf"
+
+var (
+	ErrLetsEncryptNodeNotFound = errors.New("letsencrypt node not found, deploy the base sbc first")
+	ErrDNSPluginNotDefined     = errors.New("dns plugin not defined")
+)
+
+// IssueWildcardCertificate requests a wildcard certificate for the domain, using DNS-01 validation.
+// Tenant sbcs deployed under the domain share this certificate instead of requesting their own.
+func (s *sbc) IssueWildcardCertificate(domain, dnsPlugin, credentialsFile string) error {
+	if dnsPlugin == "" {
+		return ErrDNSPluginNotDefined
+	}
+
+	domain = strings.TrimPrefix(domain, "*.")
+
+	if err := s.db.CreateFreshDB(); err != nil {
+		return fmt.Errorf("could not update database schema: %w", err)
+	}
+
+	nodeID, err := s.db.GetLetsEncryptNodeID()
+	if err != nil {
+		return fmt.Errorf("could not get letsencrypt node id: %w", err)
+	}
+
+	if nodeID == "" {
+		return ErrLetsEncryptNodeNotFound
+	}
+
+	credentials, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return fmt.Errorf("could not read dns credentials file: %w", err)
+	}
+
+	// certbot refuses to use credentials readable by others
+	credentialsName := dnsPlugin + ".ini"
+	if err = s.copyFileToContainer(nodeID, letsEncryptDNSConfDir, credentialsName, credentials, 0600); err != nil {
+		return fmt.Errorf("could not copy dns credentials to letsencrypt node: %w", err)
+	}
+
+	s.logger.Info("Issuing wildcard certificate", "domain", domain, "dns_plugin", dnsPlugin)
+
+	if err = s.runCertbotCertonly(
+		nodeID,
+		wildcardCertName(domain),
+		[]string{
+			"--dns-" + dnsPlugin,
+			"--dns-" + dnsPlugin + "-credentials", path.Join(letsEncryptDNSConfDir, credentialsName),
+		},
+		"*."+domain, domain,
+	); err != nil {
+		return err
+	}
+
+	if err = s.db.SaveWildcardDomain(domain, dnsPlugin); err != nil {
+		return fmt.Errorf("could not save wildcard domain: %w", err)
+	}
+
+	s.logger.Info("Wildcard certificate issued", "domain", domain)
+
+	return nil
+}
+
+// certName returns the name of the certificate used by the sbc, which is also its folder name in the
+// certificates volume. Tenant sbcs in hosting mode share the wildcard certificate of their parent domain.
+func (s *sbc) certName(fqdn string) string {
+	wildcardDomains, err := s.db.GetWildcardDomains()
+	if err != nil {
+		s.logger.Debug("Could not get wildcard domains", "err", err)
+
+		return fqdn
+	}
+
+	// a wildcard certificate covers only a single subdomain level
+	fqdnSplitByDot := strings.SplitN(fqdn, ".", 2)
+	if len(fqdnSplitByDot) != 2 {
+		return fqdn
+	}
+
+	for _, domain := range wildcardDomains {
+		if fqdnSplitByDot[1] == domain {
+			return wildcardCertName(domain)
+		}
+	}
+
+	return fqdn
+}
+
+func wildcardCertName(domain string) string {
+	return "wildcard." + domain
+}
@@ -0,0 +1,256 @@
+package sip
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// supported transports of the OPTIONS probe
+const (
+	TransportUDP = "udp"
+	TransportTLS = "tls"
+)
+
+const (
+	// branchMagicCookie starts every RFC 3261 Via branch
+	branchMagicCookie = "z9hG4bK"
+	// timerT1 is the initial UDP retransmission interval, doubled on every retransmission
+	timerT1 = 500 * time.Millisecond
+	// timerT2 caps the UDP retransmission interval
+	timerT2 = 4 * time.Second
+
+	userAgent = "tsbc"
+)
+
+var (
+	ErrTransportNotSupported = errors.New("transport not supported")
+	ErrNoResponse            = errors.New("no response")
+)
+
+// Target is the SIP server probed with OPTIONS
+type Target struct {
+	Transport string
+	// Address is the host:port the request is sent to
+	Address string
+	// ServerName is the host of the request uri and, over TLS, the SNI and the verified certificate name.
+	// The host of the address is used if not set.
+	ServerName string
+	// InsecureSkipVerify accepts any TLS certificate, e.g. of a local test responder
+	InsecureSkipVerify bool
+}
+
+// Response is the final response to the OPTIONS request
+type Response struct {
+	StatusCode int
+	Reason     string
+	// Server is the Server or the User-Agent header of the response
+	Server string
+	// Latency is measured from sending the first request, the TLS handshake is not included
+	Latency time.Duration
+}
+
+// Options sends an OPTIONS request to the target and waits for the final response until the context is done
+func Options(ctx context.Context, target Target) (Response, error) {
+	if target.ServerName == "" {
+		host, _, err := net.SplitHostPort(target.Address)
+		if err != nil {
+			return Response{}, fmt.Errorf("invalid address %s: %w", target.Address, err)
+		}
+
+		target.ServerName = host
+	}
+
+	switch target.Transport {
+	case TransportUDP:
+		return optionsUDP(ctx, target)
+	case TransportTLS:
+		return optionsTLS(ctx, target)
+	default:
+		return Response{}, fmt.Errorf("%w: %s", ErrTransportNotSupported, target.Transport)
+	}
+}
+
+func optionsUDP(ctx context.Context, target Target) (Response, error) {
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, "udp", target.Address)
+	if err != nil {
+		return Response{}, fmt.Errorf("could not connect: %w", err)
+	}
+
+	defer conn.Close()
+
+	req := newOptionsRequest(target, conn.LocalAddr())
+	payload := req.Bytes()
+
+	started := time.Now()
+	retransmit := timerT1
+	buff := make([]byte, 65535)
+
+	for {
+		if _, err = conn.Write(payload); err != nil {
+			return Response{}, fmt.Errorf("could not send request: %w", err)
+		}
+
+		// the request is retransmitted until a response arrives, as UDP does not guarantee the delivery
+		deadline := time.Now().Add(retransmit)
+		if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+			deadline = ctxDeadline
+		}
+
+		if err = conn.SetReadDeadline(deadline); err != nil {
+			return Response{}, err
+		}
+
+		resp, err := readUDPResponse(conn, buff, req)
+		if err == nil {
+			return newResponse(resp, started), nil
+		}
+
+		var netErr net.Error
+		if !errors.As(err, &netErr) || !netErr.Timeout() {
+			return Response{}, fmt.Errorf("could not read response: %w", err)
+		}
+
+		if ctx.Err() != nil || ctxDeadlineReached(ctx) {
+			return Response{}, fmt.Errorf("%w within %s", ErrNoResponse, time.Since(started).Round(time.Millisecond))
+		}
+
+		if retransmit *= 2; retransmit > timerT2 {
+			retransmit = timerT2
+		}
+	}
+}
+
+// readUDPResponse reads the datagrams until the final response to the request, or until the read deadline
+func readUDPResponse(conn net.Conn, buff []byte, req *Message) (*Message, error) {
+	for {
+		n, err := conn.Read(buff)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := ParseMessage(buff[:n])
+		if err != nil || !isFinalResponse(resp, req) {
+			continue
+		}
+
+		return resp, nil
+	}
+}
+
+func optionsTLS(ctx context.Context, target Target) (Response, error) {
+	dialer := tls.Dialer{
+		Config: &tls.Config{
+			ServerName: target.ServerName,
+			MinVersion: tls.VersionTLS12,
+			//nolint:gosec // only set explicitly, to probe the servers with self-signed certificates
+			InsecureSkipVerify: target.InsecureSkipVerify,
+		},
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", target.Address)
+	if err != nil {
+		return Response{}, fmt.Errorf("could not connect: %w", err)
+	}
+
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err = conn.SetDeadline(deadline); err != nil {
+			return Response{}, err
+		}
+	}
+
+	req := newOptionsRequest(target, conn.LocalAddr())
+	started := time.Now()
+
+	if _, err = conn.Write(req.Bytes()); err != nil {
+		return Response{}, fmt.Errorf("could not send request: %w", err)
+	}
+
+	reader := bufio.NewReader(conn)
+
+	for {
+		resp, err := ReadMessage(reader)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return Response{}, fmt.Errorf("%w within %s", ErrNoResponse, time.Since(started).Round(time.Millisecond))
+			}
+
+			return Response{}, fmt.Errorf("could not read response: %w", err)
+		}
+
+		if isFinalResponse(resp, req) {
+			return newResponse(resp, started), nil
+		}
+	}
+}
+
+func ctxDeadlineReached(ctx context.Context) bool {
+	deadline, ok := ctx.Deadline()
+
+	return ok && !time.Now().Before(deadline)
+}
+
+// newOptionsRequest creates the OPTIONS request, the Via and Contact headers are set to the local address
+func newOptionsRequest(target Target, localAddr net.Addr) *Message {
+	_, port, _ := net.SplitHostPort(target.Address)
+	transport := strings.ToUpper(target.Transport)
+	uri := fmt.Sprintf("sip:%s:%s;transport=%s", target.ServerName, port, target.Transport)
+
+	return &Message{
+		Method:     "OPTIONS",
+		RequestURI: uri,
+		Headers: []Header{
+			{Name: "Via", Value: fmt.Sprintf("%s/%s %s;branch=%s%s;rport",
+				sipVersion, transport, localAddr, branchMagicCookie, randomToken())},
+			{Name: "Max-Forwards", Value: "70"},
+			{Name: "From", Value: fmt.Sprintf("<sip:%s@%s>;tag=%s", userAgent, target.ServerName, randomToken())},
+			{Name: "To", Value: fmt.Sprintf("<%s>", uri)},
+			{Name: "Call-ID", Value: randomToken() + "@" + userAgent},
+			{Name: "CSeq", Value: "1 OPTIONS"},
+			{Name: "Contact", Value: fmt.Sprintf("<sip:%s@%s;transport=%s>", userAgent, localAddr, target.Transport)},
+			{Name: "Accept", Value: "application/sdp"},
+			{Name: "User-Agent", Value: userAgent},
+		},
+	}
+}
+
+// isFinalResponse reports if the message is the final response to the request, matched by the Call-ID and CSeq
+func isFinalResponse(msg, req *Message) bool {
+	return !msg.IsRequest() && msg.StatusCode >= 200 &&
+		msg.Header("Call-ID") == req.Header("Call-ID") &&
+		msg.Header("CSeq") == req.Header("CSeq")
+}
+
+func newResponse(msg *Message, started time.Time) Response {
+	server := msg.Header("Server")
+	if server == "" {
+		server = msg.Header("User-Agent")
+	}
+
+	return Response{
+		StatusCode: msg.StatusCode,
+		Reason:     msg.Reason,
+		Server:     server,
+		Latency:    time.Since(started),
+	}
+}
+
+func randomToken() string {
+	token := make([]byte, 8)
+	// crypto/rand only fails if the system random source is not available
+	_, _ = rand.Read(token)
+
+	return hex.EncodeToString(token)
+}
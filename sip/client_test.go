@@ -0,0 +1,297 @@
+package sip
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testServerName = "sbc.test.com"
+
+// testResponse answers the request with the status, copying the transaction headers
+func testResponse(req *Message, statusCode int, reason string) *Message {
+	resp := &Message{StatusCode: statusCode, Reason: reason}
+
+	for _, name := range []string{"Via", "From", "To", "Call-ID", "CSeq"} {
+		resp.Headers = append(resp.Headers, Header{Name: name, Value: req.Header(name)})
+	}
+
+	resp.Headers = append(resp.Headers, Header{Name: "Server", Value: "test responder"})
+
+	return resp
+}
+
+// udpResponder answers the OPTIONS requests with a provisional, an unrelated and the final response,
+// the first dropRequests requests are not answered
+func udpResponder(t *testing.T, dropRequests int, delay time.Duration) (string, <-chan *Message) {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	requests := make(chan *Message, 10)
+
+	go func() {
+		buff := make([]byte, 65535)
+
+		for received := 1; ; received++ {
+			n, addr, err := conn.ReadFrom(buff)
+			if err != nil {
+				return
+			}
+
+			req, err := ParseMessage(buff[:n])
+			if err != nil {
+				continue
+			}
+
+			requests <- req
+
+			if received <= dropRequests {
+				continue
+			}
+
+			time.Sleep(delay)
+
+			unrelated := testResponse(req, 200, "OK")
+			unrelated.Headers[3].Value = "other-call-id"
+
+			responses := []*Message{testResponse(req, 100, "Trying"), unrelated, testResponse(req, 404, "Not Here")}
+
+			for _, resp := range responses {
+				_, _ = conn.WriteTo(resp.Bytes(), addr)
+			}
+		}
+	}()
+
+	return conn.LocalAddr().String(), requests
+}
+
+func TestOptionsUDP(t *testing.T) {
+	delay := 50 * time.Millisecond
+	addr, requests := udpResponder(t, 0, delay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := Options(ctx, Target{Transport: TransportUDP, Address: addr, ServerName: testServerName})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the provisional and the unrelated responses are skipped
+	if resp.StatusCode != 404 || resp.Reason != "Not Here" || resp.Server != "test responder" {
+		t.Errorf("got response %d %q server %q, want 404 \"Not Here\" server \"test responder\"",
+			resp.StatusCode, resp.Reason, resp.Server)
+	}
+
+	if resp.Latency < delay || resp.Latency > timerT1 {
+		t.Errorf("got latency %s, want between %s and %s", resp.Latency, delay, timerT1)
+	}
+
+	req := <-requests
+
+	if req.Method != "OPTIONS" || !strings.HasPrefix(req.RequestURI, "sip:"+testServerName+":") {
+		t.Errorf("got request %s %s, want OPTIONS to %s", req.Method, req.RequestURI, testServerName)
+	}
+}
+
+func TestOptionsUDPRetransmission(t *testing.T) {
+	addr, requests := udpResponder(t, 1, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := Options(ctx, Target{Transport: TransportUDP, Address: addr})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the latency is measured from the first request, not from the retransmission
+	if resp.StatusCode != 404 || resp.Latency < timerT1 {
+		t.Errorf("got status %d latency %s, want 404 after at least %s", resp.StatusCode, resp.Latency, timerT1)
+	}
+
+	first, second := <-requests, <-requests
+
+	if first.Header("Call-ID") != second.Header("Call-ID") || first.Header("Via") != second.Header("Via") {
+		t.Error("the retransmission is not the same request")
+	}
+}
+
+func TestOptionsUDPTimeout(t *testing.T) {
+	// every request is dropped
+	addr, _ := udpResponder(t, 1000, 0)
+
+	timeout := 200 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	started := time.Now()
+
+	_, err := Options(ctx, Target{Transport: TransportUDP, Address: addr})
+	if !errors.Is(err, ErrNoResponse) {
+		t.Fatalf("got error %v, want %v", err, ErrNoResponse)
+	}
+
+	if elapsed := time.Since(started); elapsed < timeout || elapsed > timerT1 {
+		t.Errorf("gave up after %s, want the context timeout of %s", elapsed, timeout)
+	}
+}
+
+// tlsResponder answers the OPTIONS requests over TLS with a self-signed certificate, unless silent is set.
+// It returns the SNI of every connection.
+func tlsResponder(t *testing.T, silent bool) (string, <-chan string) {
+	t.Helper()
+
+	serverNames := make(chan string, 10)
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{selfSignedCertificate(t)},
+		MinVersion:   tls.VersionTLS12,
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			serverNames <- hello.ServerName
+
+			return nil, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+
+				req, err := ReadMessage(bufio.NewReader(conn))
+				if err != nil || silent {
+					// keep the connection open until the client gives up
+					_, _ = conn.Read(make([]byte, 1))
+
+					return
+				}
+
+				provisional, final := testResponse(req, 100, "Trying"), testResponse(req, 200, "OK")
+				_, _ = conn.Write(append(provisional.Bytes(), final.Bytes()...))
+			}()
+		}
+	}()
+
+	return listener.Addr().String(), serverNames
+}
+
+func selfSignedCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("could not generate key: %v", err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: testServerName},
+		DNSNames:     []string{testServerName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("could not create certificate: %v", err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestOptionsTLS(t *testing.T) {
+	addr, serverNames := tlsResponder(t, false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := Options(ctx, Target{
+		Transport:          TransportTLS,
+		Address:            addr,
+		ServerName:         testServerName,
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.StatusCode != 200 || resp.Reason != "OK" || resp.Server != "test responder" {
+		t.Errorf("got response %d %q server %q, want 200 \"OK\" server \"test responder\"",
+			resp.StatusCode, resp.Reason, resp.Server)
+	}
+
+	if resp.Latency <= 0 || resp.Latency > time.Second {
+		t.Errorf("got latency %s", resp.Latency)
+	}
+
+	if serverName := <-serverNames; serverName != testServerName {
+		t.Errorf("got SNI %q, want %q", serverName, testServerName)
+	}
+}
+
+func TestOptionsTLSVerify(t *testing.T) {
+	addr, _ := tlsResponder(t, false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// the self-signed certificate is not trusted
+	_, err := Options(ctx, Target{Transport: TransportTLS, Address: addr, ServerName: testServerName})
+
+	var authorityErr x509.UnknownAuthorityError
+	if !errors.As(err, &authorityErr) {
+		t.Fatalf("got error %v, want a certificate verification error", err)
+	}
+}
+
+func TestOptionsTLSTimeout(t *testing.T) {
+	addr, _ := tlsResponder(t, true)
+
+	timeout := 200 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	_, err := Options(ctx, Target{Transport: TransportTLS, Address: addr, InsecureSkipVerify: true})
+	if !errors.Is(err, ErrNoResponse) {
+		t.Fatalf("got error %v, want %v", err, ErrNoResponse)
+	}
+}
+
+func TestOptionsTransportNotSupported(t *testing.T) {
+	_, err := Options(context.Background(), Target{Transport: "tcp", Address: "127.0.0.1:5060"})
+	if !errors.Is(err, ErrTransportNotSupported) {
+		t.Fatalf("got error %v, want %v", err, ErrTransportNotSupported)
+	}
+}
@@ -0,0 +1,174 @@
+package sip
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the lines of the Kamailio sipdump text format, that delimit the records and their messages
+const (
+	dumpRecordStart  = "===================="
+	dumpMessageStart = "~~~~~~~~~~~~~~~~~~~~"
+	dumpRecordEnd    = "||||||||||||||||||||"
+)
+
+// sipdump tags of the received and the sent messages
+const (
+	DumpTagReceived = "rcv"
+	DumpTagSent     = "snd"
+)
+
+var ErrInvalidDump = errors.New("invalid sipdump record")
+
+// DumpRecord is a single message written by the Kamailio sipdump module
+type DumpRecord struct {
+	// Tag is rcv for the received and snd for the sent messages
+	Tag  string
+	Time time.Time
+	// Transport is the lowercase transport, e.g. udp or tls, of the message
+	Transport string
+	SrcIP     net.IP
+	SrcPort   int
+	DstIP     net.IP
+	DstPort   int
+	// Data is the raw message, the TLS messages are captured decrypted
+	Data []byte
+}
+
+// DumpReader reads the records of a sipdump text file
+type DumpReader struct {
+	r      *bufio.Reader
+	lineNo int
+}
+
+// NewDumpReader creates a reader of the sipdump text format
+func NewDumpReader(r io.Reader) *DumpReader {
+	return &DumpReader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next record, or io.EOF after the last one.
+// An incomplete last record, that Kamailio is still writing, is reported as io.EOF as well.
+func (d *DumpReader) Next() (*DumpRecord, error) {
+	line, err := d.readLine()
+	for err == nil && line != dumpRecordStart {
+		line, err = d.readLine()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	record := &DumpRecord{}
+
+	for {
+		line, err = d.readLine()
+		if err != nil {
+			return nil, err
+		}
+
+		if line == dumpMessageStart {
+			break
+		}
+
+		if err = record.setField(line); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %s", ErrInvalidDump, d.lineNo, err)
+		}
+	}
+
+	var data bytes.Buffer
+
+	for {
+		raw, err := d.r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		d.lineNo++
+
+		// the end line follows the message directly, also if the message does not end with a line break
+		if line := strings.TrimRight(raw, "\r\n"); strings.HasSuffix(line, dumpRecordEnd) {
+			data.WriteString(strings.TrimSuffix(line, dumpRecordEnd))
+
+			break
+		}
+
+		data.WriteString(raw)
+	}
+
+	record.Data = data.Bytes()
+
+	return record, nil
+}
+
+func (d *DumpReader) readLine() (string, error) {
+	line, err := d.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	d.lineNo++
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (r *DumpRecord) setField(line string) error {
+	name, value, found := strings.Cut(line, ":")
+	if !found {
+		return fmt.Errorf("field line %q", line)
+	}
+
+	value = strings.TrimSpace(value)
+
+	var err error
+
+	switch name {
+	case "tag":
+		r.Tag = value
+	case "time":
+		r.Time, err = parseDumpTime(value)
+	case "proto":
+		// the protocol is written with the address family, e.g. udp ipv4
+		r.Transport = strings.ToLower(strings.Fields(value + " ")[0])
+	case "srcip":
+		r.SrcIP = net.ParseIP(value)
+	case "srcport":
+		r.SrcPort, err = strconv.Atoi(value)
+	case "dstip":
+		r.DstIP = net.ParseIP(value)
+	case "dstport":
+		r.DstPort, err = strconv.Atoi(value)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return nil
+}
+
+// parseDumpTime parses the seconds.microseconds unix time of the record
+func parseDumpTime(value string) (time.Time, error) {
+	secValue, usecValue, _ := strings.Cut(value, ".")
+
+	sec, err := strconv.ParseInt(secValue, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var usec int64
+
+	if usecValue != "" {
+		if usec, err = strconv.ParseInt(usecValue, 10, 64); err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	return time.Unix(sec, usec*int64(time.Microsecond)), nil
+}
@@ -0,0 +1,120 @@
+package sip
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testDump holds a udp and a tls record and the incomplete record that Kamailio is still writing
+const testDump = "====================\n" +
+	"tag: rcv\n" +
+	"pid: 91\n" +
+	"process: 4\n" +
+	"time: 1697011200.250000\n" +
+	"date: Wed Oct 11 08:00:00 2023\n" +
+	"proto: udp ipv4\n" +
+	"srcip: 192.168.1.10\n" +
+	"srcport: 5060\n" +
+	"dstip: 192.168.10.2\n" +
+	"dstport: 5070\n" +
+	"~~~~~~~~~~~~~~~~~~~~\n" +
+	"OPTIONS sip:sbc.test.com SIP/2.0\r\n" +
+	"Call-ID: options-1\r\n" +
+	"\r\n" +
+	"||||||||||||||||||||\n" +
+	"====================\n" +
+	"tag: snd\n" +
+	"time: 1697011200.000042\n" +
+	"proto: TLS ipv4\n" +
+	"srcip: 192.168.10.2\n" +
+	"srcport: 5061\n" +
+	"dstip: 52.114.75.24\n" +
+	"dstport: 5061\n" +
+	"~~~~~~~~~~~~~~~~~~~~\n" +
+	"SIP/2.0 200 OK\r\n" +
+	"Call-ID: options-2\r\n" +
+	"\r\n" +
+	"v=0||||||||||||||||||||\n" +
+	"====================\n" +
+	"tag: rcv\n" +
+	"time: 1697011201.000000\n" +
+	"~~~~~~~~~~~~~~~~~~~~\n" +
+	"BYE sip:sbc.test.com SIP/2.0\r\n"
+
+func TestDumpReader(t *testing.T) {
+	want := []DumpRecord{
+		{
+			Tag:       DumpTagReceived,
+			Time:      time.Unix(1697011200, 250*int64(time.Millisecond)),
+			Transport: "udp",
+			SrcPort:   5060,
+			DstPort:   5070,
+			Data:      []byte("OPTIONS sip:sbc.test.com SIP/2.0\r\nCall-ID: options-1\r\n\r\n"),
+		},
+		{
+			Tag:       DumpTagSent,
+			Time:      time.Unix(1697011200, 42*int64(time.Microsecond)),
+			Transport: "tls",
+			SrcPort:   5061,
+			DstPort:   5061,
+			// the end line follows the message without a line break
+			Data: []byte("SIP/2.0 200 OK\r\nCall-ID: options-2\r\n\r\nv=0"),
+		},
+	}
+
+	reader := NewDumpReader(strings.NewReader(testDump))
+
+	for i, wantRecord := range want {
+		record, err := reader.Next()
+		if err != nil {
+			t.Fatalf("record %d: unexpected error: %v", i, err)
+		}
+
+		if record.Tag != wantRecord.Tag || !record.Time.Equal(wantRecord.Time) ||
+			record.Transport != wantRecord.Transport || record.SrcPort != wantRecord.SrcPort ||
+			record.DstPort != wantRecord.DstPort || string(record.Data) != string(wantRecord.Data) {
+			t.Errorf("record %d: got %+v, want %+v", i, record, wantRecord)
+		}
+	}
+
+	// the incomplete last record is not returned
+	if record, err := reader.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("got record %+v error %v, want %v", record, err, io.EOF)
+	}
+}
+
+func TestDumpReaderAddresses(t *testing.T) {
+	record, err := NewDumpReader(strings.NewReader(testDump)).Next()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if record.SrcIP.String() != "192.168.1.10" || record.DstIP.String() != "192.168.10.2" {
+		t.Errorf("got addresses %s -> %s", record.SrcIP, record.DstIP)
+	}
+}
+
+func TestDumpReaderInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+	}{
+		{name: "invalid port", field: "srcport: 50a0"},
+		{name: "invalid time", field: "time: now"},
+		{name: "invalid microseconds", field: "time: 1697011200.x"},
+		{name: "line without a field name", field: "rcv"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dump := "====================\n" + tt.field + "\n~~~~~~~~~~~~~~~~~~~~\nBYE\n||||||||||||||||||||\n"
+
+			if _, err := NewDumpReader(strings.NewReader(dump)).Next(); !errors.Is(err, ErrInvalidDump) {
+				t.Errorf("got error %v, want %v", err, ErrInvalidDump)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package sip
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const sipVersion = "SIP/2.0"
+
+var ErrInvalidMessage = errors.New("invalid sip message")
+
+// compactHeaders maps the compact header forms to their full names
+var compactHeaders = map[string]string{
+	"i": "Call-ID",
+	"m": "Contact",
+	"e": "Content-Encoding",
+	"l": "Content-Length",
+	"c": "Content-Type",
+	"f": "From",
+	"s": "Subject",
+	"k": "Supported",
+	"t": "To",
+	"v": "Via",
+}
+
+// Header is a single header field, in the order of the message
+type Header struct {
+	Name  string
+	Value string
+}
+
+// Message is a SIP request or response
+type Message struct {
+	// Method and RequestURI are set for the requests
+	Method     string
+	RequestURI string
+	// StatusCode and Reason are set for the responses
+	StatusCode int
+	Reason     string
+
+	Headers []Header
+	Body    []byte
+}
+
+// IsRequest reports if the message is a request
+func (m *Message) IsRequest() bool {
+	return m.Method != ""
+}
+
+// Header returns the first value of the header, the name is case-insensitive and may be in the compact form
+func (m *Message) Header(name string) string {
+	name = canonicalHeaderName(name)
+
+	for _, header := range m.Headers {
+		if strings.EqualFold(header.Name, name) {
+			return header.Value
+		}
+	}
+
+	return ""
+}
+
+// CSeqMethod returns the method of the CSeq header
+func (m *Message) CSeqMethod() string {
+	fields := strings.Fields(m.Header("CSeq"))
+	if len(fields) != 2 {
+		return ""
+	}
+
+	return fields[1]
+}
+
+// Bytes serializes the message, the Content-Length header is always set to the body length
+func (m *Message) Bytes() []byte {
+	var buff bytes.Buffer
+
+	if m.IsRequest() {
+		fmt.Fprintf(&buff, "%s %s %s\r\n", m.Method, m.RequestURI, sipVersion)
+	} else {
+		fmt.Fprintf(&buff, "%s %d %s\r\n", sipVersion, m.StatusCode, m.Reason)
+	}
+
+	for _, header := range m.Headers {
+		if canonicalHeaderName(header.Name) == "Content-Length" {
+			continue
+		}
+
+		fmt.Fprintf(&buff, "%s: %s\r\n", header.Name, header.Value)
+	}
+
+	fmt.Fprintf(&buff, "Content-Length: %d\r\n\r\n", len(m.Body))
+	buff.Write(m.Body)
+
+	return buff.Bytes()
+}
+
+// ParseMessage parses a single message, e.g. the payload of a UDP datagram
+func ParseMessage(data []byte) (*Message, error) {
+	return ReadMessage(bufio.NewReader(bytes.NewReader(data)))
+}
+
+// ReadMessage reads a single message from a stream, the body length is taken from the Content-Length header
+func ReadMessage(r *bufio.Reader) (*Message, error) {
+	startLine, err := readLine(r)
+	// the keep-alive line breaks between the stream messages are skipped
+	for err == nil && startLine == "" {
+		startLine, err = readLine(r)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := parseStartLine(startLine)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: headers: %s", ErrInvalidMessage, err)
+		}
+
+		if line == "" {
+			break
+		}
+
+		// folded header lines continue the previous header
+		if (line[0] == ' ' || line[0] == '\t') && len(msg.Headers) > 0 {
+			msg.Headers[len(msg.Headers)-1].Value += " " + strings.TrimSpace(line)
+
+			continue
+		}
+
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			return nil, fmt.Errorf("%w: header line %q", ErrInvalidMessage, line)
+		}
+
+		msg.Headers = append(msg.Headers, Header{
+			Name:  canonicalHeaderName(strings.TrimSpace(name)),
+			Value: strings.TrimSpace(value),
+		})
+	}
+
+	if contentLength := msg.Header("Content-Length"); contentLength != "" {
+		length, err := strconv.Atoi(contentLength)
+		if err != nil || length < 0 {
+			return nil, fmt.Errorf("%w: content length %q", ErrInvalidMessage, contentLength)
+		}
+
+		msg.Body = make([]byte, length)
+		if _, err = io.ReadFull(r, msg.Body); err != nil {
+			return nil, fmt.Errorf("%w: body: %s", ErrInvalidMessage, err)
+		}
+	}
+
+	return msg, nil
+}
+
+func parseStartLine(line string) (*Message, error) {
+	parts := strings.SplitN(line, " ", 3)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: start line %q", ErrInvalidMessage, line)
+	}
+
+	if parts[0] == sipVersion {
+		code, err := strconv.Atoi(parts[1])
+		if err != nil || code < 100 || code > 699 {
+			return nil, fmt.Errorf("%w: status line %q", ErrInvalidMessage, line)
+		}
+
+		return &Message{StatusCode: code, Reason: parts[2]}, nil
+	}
+
+	if parts[2] != sipVersion {
+		return nil, fmt.Errorf("%w: request line %q", ErrInvalidMessage, line)
+	}
+
+	return &Message{Method: parts[0], RequestURI: parts[1]}, nil
+}
+
+// readLine reads a line without the CRLF, a bare LF is accepted as well
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		if errors.Is(err, io.EOF) && line != "" {
+			return "", io.ErrUnexpectedEOF
+		}
+
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func canonicalHeaderName(name string) string {
+	if fullName, ok := compactHeaders[strings.ToLower(name)]; ok {
+		return fullName
+	}
+
+	return name
+}
@@ -0,0 +1,161 @@
+package sip
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    *Message
+		cseqNum int
+	}{
+		{
+			name: "request with compact and folded headers",
+			data: "INVITE sip:+381111234@sbc.test.com SIP/2.0\r\n" +
+				"v: SIP/2.0/TLS 52.114.75.24:5061;branch=z9hG4bK1\r\n" +
+				"f: <sip:+381117654@sip.pstnhub.microsoft.com>;tag=abc\r\n" +
+				"t: <sip:+381111234@sbc.test.com>\r\n" +
+				"i: call-1\r\n" +
+				"CSeq: 1 INVITE\r\n" +
+				"Subject: first line\r\n" +
+				" second line\r\n" +
+				"l: 4\r\n" +
+				"\r\n" +
+				"v=0\n",
+			want: &Message{
+				Method:     "INVITE",
+				RequestURI: "sip:+381111234@sbc.test.com",
+				Headers: []Header{
+					{Name: "Via", Value: "SIP/2.0/TLS 52.114.75.24:5061;branch=z9hG4bK1"},
+					{Name: "From", Value: "<sip:+381117654@sip.pstnhub.microsoft.com>;tag=abc"},
+					{Name: "To", Value: "<sip:+381111234@sbc.test.com>"},
+					{Name: "Call-ID", Value: "call-1"},
+					{Name: "CSeq", Value: "1 INVITE"},
+					{Name: "Subject", Value: "first line second line"},
+					{Name: "Content-Length", Value: "4"},
+				},
+				Body: []byte("v=0\n"),
+			},
+			cseqNum: 1,
+		},
+		{
+			name: "response with bare line feeds",
+			data: "SIP/2.0 487 Request Terminated\n" +
+				"Call-ID: call-2\n" +
+				"CSeq: 2 INVITE\n" +
+				"\n",
+			want: &Message{
+				StatusCode: 487,
+				Reason:     "Request Terminated",
+				Headers: []Header{
+					{Name: "Call-ID", Value: "call-2"},
+					{Name: "CSeq", Value: "2 INVITE"},
+				},
+			},
+			cseqNum: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := ParseMessage([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(msg, tt.want) {
+				t.Errorf("got %+v, want %+v", msg, tt.want)
+			}
+
+			if msg.CSeqNumber() != tt.cseqNum || msg.CSeqMethod() != "INVITE" {
+				t.Errorf("got CSeq %d %s, want %d INVITE", msg.CSeqNumber(), msg.CSeqMethod(), tt.cseqNum)
+			}
+		})
+	}
+}
+
+func TestParseMessageInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "short start line", data: "INVITE sip:sbc.test.com\r\n\r\n"},
+		{name: "unknown version", data: "INVITE sip:sbc.test.com SIP/3.0\r\n\r\n"},
+		{name: "invalid status", data: "SIP/2.0 99 Too Low\r\n\r\n"},
+		{name: "header without colon", data: "BYE sip:sbc.test.com SIP/2.0\r\nCSeq 2 BYE\r\n\r\n"},
+		{name: "headers not ended", data: "BYE sip:sbc.test.com SIP/2.0\r\nCSeq: 2 BYE\r\n"},
+		{name: "short body", data: "BYE sip:sbc.test.com SIP/2.0\r\nContent-Length: 10\r\n\r\nv=0\r\n"},
+		{name: "negative content length", data: "BYE sip:sbc.test.com SIP/2.0\r\nContent-Length: -1\r\n\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseMessage([]byte(tt.data)); !errors.Is(err, ErrInvalidMessage) {
+				t.Errorf("got error %v, want %v", err, ErrInvalidMessage)
+			}
+		})
+	}
+}
+
+func TestHeaderValues(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		uri   string
+		user  string
+		tag   string
+	}{
+		{
+			name:  "name-addr with uri parameters",
+			value: `"Alice" <sip:+381117654@sbc.test.com;transport=tls;tag=uri>;tag=4fa1`,
+			uri:   "sip:+381117654@sbc.test.com;transport=tls;tag=uri",
+			user:  "+381117654",
+			tag:   "4fa1",
+		},
+		{
+			name:  "addr-spec without a tag",
+			value: "sip:1000:secret@192.168.1.10",
+			uri:   "sip:1000:secret@192.168.1.10",
+			user:  "1000",
+		},
+		{
+			name:  "tel uri",
+			value: "<tel:+381111234;phone-context=test>;tag=7",
+			uri:   "tel:+381111234;phone-context=test",
+			user:  "+381111234",
+			tag:   "7",
+		},
+		{
+			name:  "sip uri without a user",
+			value: "<sip:sbc.test.com>",
+			uri:   "sip:sbc.test.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uri := AddressURI(tt.value)
+
+			if uri != tt.uri || URIUser(uri) != tt.user || HeaderParam(tt.value, "tag") != tt.tag {
+				t.Errorf("got uri %q user %q tag %q, want uri %q user %q tag %q",
+					uri, URIUser(uri), HeaderParam(tt.value, "tag"), tt.uri, tt.user, tt.tag)
+			}
+		})
+	}
+}
+
+func TestHeaderParamQuoted(t *testing.T) {
+	value := `Q.850;cause=16;text="Normal; \"call\" clearing"`
+
+	if text := HeaderParam(value, "text"); text != `Normal; "call" clearing` {
+		t.Errorf("got text %q", text)
+	}
+
+	if cause := HeaderParam(value, "Cause"); cause != "16" {
+		t.Errorf("got cause %q", cause)
+	}
+}
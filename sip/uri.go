@@ -0,0 +1,114 @@
+package sip
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CSeqNumber returns the sequence number of the CSeq header, or 0 if it is not valid
+func (m *Message) CSeqNumber() int {
+	fields := strings.Fields(m.Header("CSeq"))
+	if len(fields) != 2 {
+		return 0
+	}
+
+	number, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0
+	}
+
+	return number
+}
+
+// AddressURI returns the uri of a name-addr or addr-spec header value, e.g. of the From and To headers
+func AddressURI(value string) string {
+	value = strings.TrimSpace(value)
+
+	if start := strings.Index(value, "<"); start >= 0 {
+		if end := strings.Index(value[start:], ">"); end > 0 {
+			return value[start+1 : start+end]
+		}
+	}
+
+	// the parameters of an addr-spec belong to the header, not to the uri
+	uri, _, _ := strings.Cut(value, ";")
+
+	return strings.TrimSpace(uri)
+}
+
+// URIUser returns the user part of a sip, sips or tel uri
+func URIUser(uri string) string {
+	scheme, rest, found := strings.Cut(uri, ":")
+	if !found {
+		return ""
+	}
+
+	switch strings.ToLower(scheme) {
+	case "tel":
+		number, _, _ := strings.Cut(rest, ";")
+
+		return number
+	case "sip", "sips":
+		user, _, found := strings.Cut(rest, "@")
+		if !found {
+			return ""
+		}
+
+		// the password is not part of the user
+		user, _, _ = strings.Cut(user, ":")
+
+		return user
+	default:
+		return ""
+	}
+}
+
+// HeaderParam returns the value of the header parameter, e.g. the tag of the From header or the text
+// of the Reason header. The quotes of a quoted value are removed.
+func HeaderParam(value, name string) string {
+	// the uri parameters of a name-addr are not header parameters
+	if end := strings.LastIndex(value, ">"); end >= 0 {
+		value = value[end+1:]
+	}
+
+	for _, param := range splitParams(value)[1:] {
+		paramName, paramValue, _ := strings.Cut(param, "=")
+		if !strings.EqualFold(strings.TrimSpace(paramName), name) {
+			continue
+		}
+
+		paramValue = strings.TrimSpace(paramValue)
+		if unquoted, err := strconv.Unquote(paramValue); err == nil {
+			return unquoted
+		}
+
+		return strings.Trim(paramValue, `"`)
+	}
+
+	return ""
+}
+
+// splitParams splits the value on the semicolons outside the quoted strings
+func splitParams(value string) []string {
+	params := make([]string, 0)
+	quoted := false
+	start := 0
+
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			if quoted {
+				i++
+			}
+		case '"':
+			quoted = !quoted
+		case ';':
+			if !quoted {
+				params = append(params, value[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(params, value[start:])
+}